@@ -0,0 +1,154 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ArgError is returned by ArgsBuilder.Build for the first invalid arg, and
+// by queries for the first invalid element of a slice arg filling a (?).
+type ArgError struct {
+	Index int
+	Arg   interface{}
+	Err   error
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("sqlpp: invalid argument %d (%T): %v", e.Index, e.Arg, e.Err)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errArgNil    = errors.New("untyped nil, use a typed nil or sql.Null* for NULL")
+	errArgKind   = errors.New("unsupported kind")
+	errArgNested = errors.New("nested slice")
+)
+
+// ArgsBuilder collects the args of a query, checking each as it's added
+// so a bad arg fails where it was added instead of in the driver.
+type ArgsBuilder struct {
+	args   []interface{}
+	err    error
+	nested bool
+}
+
+func NewArgs(args ...interface{}) *ArgsBuilder {
+	return (&ArgsBuilder{}).Add(args...)
+}
+
+// AllowNested accepts slices of slices, for drivers binding array params.
+// Only the outer slice fills a (?).
+func (b *ArgsBuilder) AllowNested() *ArgsBuilder {
+	b.nested = true
+	return b
+}
+
+// Add appends args, keeping the first error for Build.
+func (b *ArgsBuilder) Add(args ...interface{}) *ArgsBuilder {
+	for _, arg := range args {
+		if b.err == nil {
+			if err := b.check(arg, true); err != nil {
+				b.err = &ArgError{Index: len(b.args), Arg: arg, Err: err}
+			}
+		}
+
+		b.args = append(b.args, arg)
+	}
+
+	return b
+}
+
+// Build returns the args, or the error of the first invalid one.
+func (b *ArgsBuilder) Build() ([]interface{}, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return b.args, nil
+}
+
+func (b *ArgsBuilder) check(arg interface{}, top bool) error {
+	switch arg.(type) {
+	case nil:
+		return errArgNil
+	case driver.Valuer, []byte, time.Time, sql.Out, sql.NamedArg:
+		return nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+
+		return b.check(rv.Elem().Interface(), top)
+	case reflect.Slice, reflect.Array:
+		if !top && !b.nested {
+			return errArgNested
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			if err := b.check(rv.Index(i).Interface(), false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return errArgKind
+}
+
+// WithFlattenNested makes the nested slices of a slice arg fill the same
+// (?), e.g. [][]int{{1, 2}, {3}} fills it like []int{1, 2, 3}. Without it
+// a nested slice fails the query.
+func WithFlattenNested() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.flattenNested = true
+	}
+}
+
+// checkSlices checks the elements of the slice args filling a (?) of
+// query, so a bad element fails with its index instead of in the driver.
+// Untyped nil elements are NULLs.
+func (sqlpp *DB) checkSlices(query string, args []interface{}) error {
+	if !strings.Contains(query, "(?)") {
+		return nil
+	}
+
+	b := &ArgsBuilder{nested: sqlpp.config().flattenNested}
+	for i, arg := range args {
+		arg = encoded(arg)
+		if !expands(arg) {
+			continue
+		}
+
+		rv := reflect.ValueOf(arg)
+		for j := 0; j < rv.Len(); j++ {
+			elem := encoded(rv.Index(j).Interface())
+			if elem == nil {
+				continue
+			}
+
+			if err := b.check(elem, false); err != nil {
+				return &ArgError{Index: i, Arg: arg, Err: fmt.Errorf("element %d (%T): %w", j, elem, err)}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgsBuilder(t *testing.T) {
+	var nilString *string
+	s := "s"
+	valid := []interface{}{
+		1, "s", 1.5, true, uint8(1), []byte("b"), time.Time{}, sql.NullString{}, nilString, &s,
+		[]int{1, 2}, [2]string{"a", "b"}, sql.Out{Dest: &s}, valuerSlice{"v"},
+	}
+
+	args, err := NewArgs(valid...).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, valid, args)
+
+	cases := []struct {
+		args  []interface{}
+		index int
+		err   error
+	}{
+		{[]interface{}{1, nil}, 1, errArgNil},
+		{[]interface{}{make(chan int)}, 0, errArgKind},
+		{[]interface{}{"s", func() {}}, 1, errArgKind},
+		{[]interface{}{map[string]int{}}, 0, errArgKind},
+		{[]interface{}{struct{}{}}, 0, errArgKind},
+		{[]interface{}{[]interface{}{1, nil}}, 0, errArgNil},
+		{[]interface{}{[][]int{{1}}}, 0, errArgNested},
+	}
+
+	for _, c := range cases {
+		_, err := NewArgs(c.args...).Build()
+		var argErr *ArgError
+		assert.True(t, errors.As(err, &argErr))
+		assert.Equal(t, c.index, argErr.Index)
+		assert.Equal(t, c.err, errors.Unwrap(err))
+	}
+
+	args, err = NewArgs().AllowNested().Add([][]int{{1}}, 2).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{[][]int{{1}}, 2}, args)
+
+	_, err = NewArgs(1).Add(nil).Add(func() {}).Build()
+	assert.EqualError(t, err, "sqlpp: invalid argument 1 (<nil>): untyped nil, use a typed nil or sql.Null* for NULL")
+}
+
+func TestDB_checkSlices(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db)
+	f := NewMySQL(db, WithFlattenNested())
+	s := "s"
+
+	mock.ExpectPrepare("select * from foo where i in (?,?,?,?,?,?) and j = ?").
+		ExpectExec().WithArgs(1, "a", []byte("b"), nil, "s", sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("select * from foo where i in (?,?,?)").
+		ExpectExec().WithArgs(1, 2, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = m.Exec("select * from foo where i in (?) and j = ?", []interface{}{1, "a", []byte("b"), nil, &s, valuerSlice{"v"}}, 2)
+	assert.Nil(t, err)
+	_, err = f.Exec("select * from foo where i in (?)", [][]int{{1, 2}, {3}})
+	assert.Nil(t, err)
+
+	_, err = m.Exec("select * from foo where i in (?)", []interface{}{1, struct{}{}})
+	assert.EqualError(t, err, "sqlpp: invalid argument 0 ([]interface {}): element 1 (struct {}): unsupported kind")
+	assert.True(t, errors.Is(err, errArgKind))
+
+	_, err = m.Exec("select * from foo where j = ? and i in (?)", 1, []interface{}{[]int{1}})
+	var argErr *ArgError
+	assert.True(t, errors.As(err, &argErr))
+	assert.Equal(t, 1, argErr.Index)
+	assert.True(t, errors.Is(err, errArgNested))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,77 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrClosed = errors.New("sqlpp: closed")
+)
+
+// AsyncResult is the pending result of an ExecAsync.
+type AsyncResult struct {
+	done   chan struct{}
+	result sql.Result
+	err    error
+}
+
+func (r *AsyncResult) resolve(result sql.Result, err error) *AsyncResult {
+	r.result = result
+	r.err = err
+	close(r.done)
+	return r
+}
+
+// Done is closed once the result is available.
+func (r *AsyncResult) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until the exec finishes and returns its result.
+func (r *AsyncResult) Wait() (sql.Result, error) {
+	<-r.done
+	return r.result, r.err
+}
+
+// ExecAsync runs the exec on the async worker pool without waiting for it.
+// The exec is cancelled with ctx, so fire-and-forget writes should not use
+// a request scoped context. Close waits for pending execs to finish.
+func (sqlpp *DB) ExecAsync(ctx context.Context, query string, args ...interface{}) *AsyncResult {
+	r := &AsyncResult{done: make(chan struct{})}
+
+	sqlpp.asyncMu.Lock()
+	if sqlpp.closed {
+		sqlpp.asyncMu.Unlock()
+		return r.resolve(nil, ErrClosed)
+	}
+
+	sqlpp.asyncWg.Add(1)
+	sqlpp.asyncMu.Unlock()
+
+	go func() {
+		defer sqlpp.asyncWg.Done()
+
+		select {
+		case sqlpp.asyncSem <- struct{}{}:
+			defer func() { <-sqlpp.asyncSem }()
+		case <-ctx.Done():
+			r.resolve(nil, ctx.Err())
+			return
+		}
+
+		r.resolve(sqlpp.ExecContext(ctx, query, args...))
+	}()
+
+	return r
+}
+
+// drain stops accepting async execs and waits for the pending ones.
+func (sqlpp *DB) drain() {
+	sqlpp.asyncMu.Lock()
+	sqlpp.closed = true
+	sqlpp.asyncMu.Unlock()
+
+	sqlpp.asyncWg.Wait()
+}
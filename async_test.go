@@ -0,0 +1,59 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_ExecAsync(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+
+	s := NewMySQL(db, WithAsyncWorkers(1))
+
+	// sqlmock expectations are not safe to add while execs run
+	p := mock.ExpectPrepare("^insert into audit select (.+)$").WillBeClosed()
+	mock.ExpectClose()
+	p.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	p.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(2, 1))
+	p.ExpectExec().WithArgs(3).WillReturnError(errors.New("error"))
+
+	r1 := s.ExecAsync(context.Background(), "insert into audit select ?", 1)
+	r2 := s.ExecAsync(context.Background(), "insert into audit select ?", 2)
+	r3 := s.ExecAsync(context.Background(), "insert into audit select ?", 3)
+
+	<-r1.Done()
+	result, err := r1.Wait()
+	assert.Nil(t, err)
+	id, _ := result.LastInsertId()
+	assert.Equal(t, int64(1), id)
+
+	_, err = r3.Wait()
+	assert.Equal(t, errors.New("error"), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.asyncSem <- struct{}{}
+	_, err = s.ExecAsync(ctx, "insert into audit select ?", 4).Wait()
+	assert.Equal(t, context.Canceled, err)
+	<-s.asyncSem
+
+	assert.Nil(t, s.Close())
+
+	// drained by close
+	select {
+	case <-r2.Done():
+	default:
+		t.Error("pending exec not drained")
+	}
+
+	_, err = s.ExecAsync(context.Background(), "insert into audit select ?", 5).Wait()
+	assert.Equal(t, ErrClosed, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
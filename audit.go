@@ -0,0 +1,144 @@
+package sqlpp
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// WithActor returns a ctx whose data-modifying statements are audited as
+// done by actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func ActorFrom(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey).(string)
+	return actor, ok
+}
+
+// AuditEvent describes a successful INSERT, UPDATE or DELETE.
+type AuditEvent struct {
+	// Statement is INSERT, UPDATE or DELETE, REPLACE counts as INSERT.
+	Statement    string
+	Fingerprint  string
+	RowsAffected int64
+	Actor        string
+	Time         time.Time
+}
+
+// WithAudit calls audit after every successful INSERT, UPDATE and DELETE
+// run by Exec. audit runs on the goroutine of the statement, it can write
+// to an audit table with the same ctx.
+func WithAudit(audit func(ctx context.Context, e *AuditEvent)) Option {
+	return WithHook(func(ctx context.Context, e *QueryEvent) {
+		if e.Err != nil || e.Result == nil {
+			return
+		}
+
+		statement := statementType(e.Query)
+		if statement == "" {
+			return
+		}
+
+		affected, _ := e.Result.RowsAffected()
+		actor, _ := ActorFrom(ctx)
+		audit(ctx, &AuditEvent{
+			Statement:    statement,
+			Fingerprint:  Fingerprint(e.Query),
+			RowsAffected: affected,
+			Actor:        actor,
+			Time:         e.Start,
+		})
+	})
+}
+
+// statementType returns INSERT, UPDATE or DELETE by the first keyword of
+// query, or of the statement after a WITH clause, and "" for others.
+func statementType(query string) string {
+	keyword, rest := firstKeyword(query)
+	if keyword != "WITH" {
+		return dmlType(keyword)
+	}
+
+	// the main statement follows the ctes, outside their parentheses, but
+	// ctes can modify data too on postgres
+	depth := 0
+	for i := 0; i < len(rest); {
+		switch c := rest[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case isIdentByte(c):
+			j := i
+			for j < len(rest) && isIdentByte(rest[j]) {
+				j++
+			}
+
+			word := strings.ToUpper(rest[i:j])
+			if word == "SELECT" && depth == 0 {
+				return ""
+			} else if statement := dmlType(word); statement != "" {
+				return statement
+			}
+
+			i = j
+			continue
+		}
+
+		i++
+	}
+
+	return ""
+}
+
+func dmlType(keyword string) string {
+	switch keyword {
+	case "INSERT", "REPLACE":
+		return "INSERT"
+	case "UPDATE", "DELETE":
+		return keyword
+	}
+
+	return ""
+}
+
+// firstKeyword returns the upper cased first word of query after spaces
+// and comments, and the query after it.
+func firstKeyword(query string) (string, string) {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n(")
+		if strings.HasPrefix(query, "--") || strings.HasPrefix(query, "#") {
+			i := strings.IndexByte(query, '\n')
+			if i == -1 {
+				return "", ""
+			}
+
+			query = query[i+1:]
+		} else if strings.HasPrefix(query, "/*!") {
+			// the body of a mysql executable comment runs
+			query = strings.TrimLeft(query[3:], "0123456789")
+		} else if strings.HasPrefix(query, "/*") {
+			i := strings.Index(query, "*/")
+			if i == -1 {
+				return "", ""
+			}
+
+			query = query[i+2:]
+		} else {
+			break
+		}
+	}
+
+	i := 0
+	for i < len(query) && isIdentByte(query[i]) {
+		i++
+	}
+
+	if i == 0 && len(query) > 0 {
+		i = 1
+	}
+
+	return strings.ToUpper(query[:i]), query[i:]
+}
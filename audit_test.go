@@ -0,0 +1,73 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementType(t *testing.T) {
+	cases := []struct {
+		query, statement string
+	}{
+		{"insert into foo values (1)", "INSERT"},
+		{"  REPLACE into foo values (1)", "INSERT"},
+		{"update foo set i = 1", "UPDATE"},
+		{"/* app */ delete from foo", "DELETE"},
+		{"-- comment\ndelete from foo", "DELETE"},
+		{"(select 1)", ""},
+		{"select * from foo", ""},
+		{"with x as (select id from foo) delete from bar where id in (select id from x)", "DELETE"},
+		{"WITH RECURSIVE x AS (SELECT 1), y AS (SELECT 2) SELECT * FROM x", ""},
+		{"with x as (delete from foo returning id) select * from x", "DELETE"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.statement, statementType(c.query), c.query)
+	}
+}
+
+func TestWithAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var events []AuditEvent
+	s := NewMySQL(db, WithAudit(func(ctx context.Context, e *AuditEvent) {
+		events = append(events, *e)
+	}))
+
+	mock.ExpectPrepare("^update foo").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectPrepare("^delete from foo").ExpectExec().WillReturnError(errors.New("error"))
+	mock.ExpectPrepare("^select 1").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("^insert into foo").ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := WithActor(context.Background(), "alice")
+	_, err = s.ExecContext(ctx, "update foo set i = ?", 1)
+	assert.Nil(t, err)
+	_, err = s.ExecContext(ctx, "delete from foo")
+	assert.NotNil(t, err)
+	_, err = s.ExecContext(ctx, "select 1")
+	assert.Nil(t, err)
+	_, err = s.Exec("insert into foo values (1)")
+	assert.Nil(t, err)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, "UPDATE", events[0].Statement)
+	assert.Equal(t, "update foo set i = ?", events[0].Fingerprint)
+	assert.Equal(t, int64(3), events[0].RowsAffected)
+	assert.Equal(t, "alice", events[0].Actor)
+	assert.False(t, events[0].Time.IsZero())
+
+	assert.Equal(t, "INSERT", events[1].Statement)
+	assert.Equal(t, "insert into foo values (?)", events[1].Fingerprint)
+	assert.Equal(t, "", events[1].Actor)
+
+	actor, ok := ActorFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", actor)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package sqlpp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// TamperError is returned by AuditTrail.Verify for the first row that
+// doesn't chain to the one before it.
+type TamperError struct {
+	ID     int64
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("sqlpp: audit trail tampered at row %d: %s", e.ID, e.Reason)
+}
+
+// AuditTrail writes AuditEvents to an append-only table, each row holding
+// the hash of the one before it, so changing or removing a row breaks the
+// chain. Removing the newest rows keeps the chain intact, keep the hash
+// Verify returns somewhere else to detect it. Without a Key the hashes are
+// plain SHA-256, anyone able to edit the table can recompute them, so the
+// chain only detects accidental corruption.
+type AuditTrail struct {
+	db    *DB
+	table string
+	mu    sync.Mutex
+
+	// Key keys the hashes with HMAC-SHA256, so rows can't be rehashed
+	// without it. Keep it from the db users writing the table.
+	Key []byte
+
+	// OnError is called with the errors of writes done by Audit.
+	OnError func(error)
+}
+
+func NewAuditTrail(db *DB, table string) *AuditTrail {
+	return &AuditTrail{db: db, table: table}
+}
+
+// Create creates the table of the trail if it doesn't exist.
+func (a *AuditTrail) Create(ctx context.Context) error {
+	if err := a.db.helperSQL(); err != nil {
+		return err
+	}
+
+	id := "id BIGINT AUTO_INCREMENT PRIMARY KEY"
+	if a.db.dialect.flavor == postgresFlavor {
+		id = "id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY"
+	}
+
+	_, err := a.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+a.db.QuoteIdent(a.table)+" ("+id+
+		", prev_hash CHAR(64) NOT NULL, hash CHAR(64) NOT NULL, statement VARCHAR(16) NOT NULL"+
+		", fingerprint TEXT NOT NULL, rows_affected BIGINT NOT NULL, actor VARCHAR(255) NOT NULL, at BIGINT NOT NULL)")
+	return err
+}
+
+// Audit appends e, for WithAudit(trail.Audit).
+func (a *AuditTrail) Audit(ctx context.Context, e *AuditEvent) {
+	if err := a.Append(ctx, e); err != nil && a.OnError != nil {
+		a.OnError(err)
+	}
+}
+
+// Append writes e chained to the newest row. The table is locked while
+// writing so concurrent writers can't fork the chain. It writes on a raw
+// transaction, past the hooks and policies, as its INSERT would be audited
+// again through WithAudit.
+func (a *AuditTrail) Append(ctx context.Context, e *AuditEvent) error {
+	if err := a.db.helperSQL(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	table := a.db.QuoteIdent(a.table)
+	last := "SELECT hash FROM " + table + " ORDER BY id DESC LIMIT 1 FOR UPDATE"
+	if a.db.dialect.flavor == postgresFlavor {
+		// a row lock doesn't stop another writer reading the same newest row
+		if _, err = tx.ExecContext(ctx, "LOCK TABLE "+table+" IN EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+
+		last = "SELECT hash FROM " + table + " ORDER BY id DESC LIMIT 1"
+	}
+
+	prev := ""
+	if err = tx.QueryRowContext(ctx, last).Scan(&prev); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	at := e.Time.UnixMicro()
+	hash := auditHash(a.Key, prev, e.Statement, e.Fingerprint, e.RowsAffected, e.Actor, at)
+
+	query, _ := a.db.transform("INSERT INTO "+table+" (prev_hash, hash, statement, fingerprint, rows_affected, actor, at) VALUES (?,?,?,?,?,?,?)", nil)
+	if _, err = tx.ExecContext(ctx, query, prev, hash, e.Statement, e.Fingerprint, e.RowsAffected, e.Actor, at); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Verify walks the trail from the oldest row checking every hash and link,
+// returning the hash of the newest row.
+func (a *AuditTrail) Verify(ctx context.Context) (string, error) {
+	rows, err := a.db.RowsContext(ctx, "SELECT id, prev_hash, hash, statement, fingerprint, rows_affected, actor, at FROM "+
+		a.db.QuoteIdent(a.table)+" ORDER BY id")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	last := ""
+	for rows.Next() {
+		var id, affected, at int64
+		var prev, hash, statement, fingerprint, actor string
+		if err := rows.Scan(&id, &prev, &hash, &statement, &fingerprint, &affected, &actor, &at); err != nil {
+			return "", err
+		}
+
+		if prev != last {
+			return "", &TamperError{ID: id, Reason: "previous hash mismatch"}
+		} else if hash != auditHash(a.Key, prev, statement, fingerprint, affected, actor, at) {
+			return "", &TamperError{ID: id, Reason: "hash mismatch"}
+		}
+
+		last = hash
+	}
+
+	return last, rows.Err()
+}
+
+func auditHash(key []byte, prev, statement, fingerprint string, affected int64, actor string, at int64) string {
+	h := sha256.New()
+	if key != nil {
+		h = hmac.New(sha256.New, key)
+	}
+	for _, field := range []string{prev, statement, fingerprint, strconv.FormatInt(affected, 10), actor, strconv.FormatInt(at, 10)} {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTrail(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	mTrail := NewAuditTrail(NewMySQL(mDb), "audit")
+	pTrail := NewAuditTrail(NewPostgreSQL(pDb), "audit")
+
+	at := time.Date(2021, 2, 3, 4, 5, 6, 7000, time.UTC)
+	e := &AuditEvent{Statement: "DELETE", Fingerprint: "delete from foo where id = ?", RowsAffected: 2, Actor: "alice", Time: at}
+	first := auditHash(nil, "", "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro())
+	second := auditHash(nil, first, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro())
+
+	mMock.ExpectPrepare("CREATE TABLE IF NOT EXISTS `audit` (id BIGINT AUTO_INCREMENT PRIMARY KEY, prev_hash CHAR(64) NOT NULL, hash CHAR(64) NOT NULL, statement VARCHAR(16) NOT NULL, fingerprint TEXT NOT NULL, rows_affected BIGINT NOT NULL, actor VARCHAR(255) NOT NULL, at BIGINT NOT NULL)").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectBegin()
+	mMock.ExpectQuery("SELECT hash FROM `audit` ORDER BY id DESC LIMIT 1 FOR UPDATE").WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	mMock.ExpectExec("INSERT INTO `audit` (prev_hash, hash, statement, fingerprint, rows_affected, actor, at) VALUES (?,?,?,?,?,?,?)").
+		WithArgs("", first, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectCommit()
+	mMock.ExpectBegin()
+	mMock.ExpectQuery("SELECT hash FROM `audit` ORDER BY id DESC LIMIT 1 FOR UPDATE").WillReturnError(errors.New("error"))
+	mMock.ExpectRollback()
+
+	pMock.ExpectBegin()
+	pMock.ExpectExec(`LOCK TABLE "audit" IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	pMock.ExpectQuery(`SELECT hash FROM "audit" ORDER BY id DESC LIMIT 1`).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(first))
+	pMock.ExpectExec(`INSERT INTO "audit" (prev_hash, hash, statement, fingerprint, rows_affected, actor, at) VALUES ($1,$2,$3,$4,$5,$6,$7)`).
+		WithArgs(first, second, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()).WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectCommit()
+
+	ctx := context.Background()
+	assert.Nil(t, mTrail.Create(ctx))
+	assert.Nil(t, mTrail.Append(ctx, e))
+
+	var errs []error
+	mTrail.OnError = func(err error) { errs = append(errs, err) }
+	mTrail.Audit(ctx, e)
+	assert.Equal(t, []error{errors.New("error")}, errs)
+
+	assert.Nil(t, pTrail.Append(ctx, e))
+
+	columns := []string{"id", "prev_hash", "hash", "statement", "fingerprint", "rows_affected", "actor", "at"}
+	verify := `SELECT id, prev_hash, hash, statement, fingerprint, rows_affected, actor, at FROM "audit" ORDER BY id`
+	pMock.ExpectPrepare(verify).ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "", first, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()).
+		AddRow(2, first, second, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()))
+	pMock.ExpectQuery(verify).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "", first, "DELETE", "delete from foo where id = ?", 1, "alice", at.UnixMicro()))
+	pMock.ExpectQuery(verify).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(2, first, second, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()))
+
+	last, err := pTrail.Verify(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, second, last)
+
+	_, err = pTrail.Verify(ctx)
+	assert.Equal(t, &TamperError{ID: 1, Reason: "hash mismatch"}, err)
+
+	_, err = pTrail.Verify(ctx)
+	assert.EqualError(t, err, "sqlpp: audit trail tampered at row 2: previous hash mismatch")
+
+	pTrail.Key = []byte("secret")
+	keyed := auditHash(pTrail.Key, "", "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro())
+	assert.NotEqual(t, first, keyed)
+	pMock.ExpectQuery(verify).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "", keyed, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()))
+	pMock.ExpectQuery(verify).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "", first, "DELETE", "delete from foo where id = ?", 2, "alice", at.UnixMicro()))
+
+	last, err = pTrail.Verify(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, keyed, last)
+
+	_, err = pTrail.Verify(ctx)
+	assert.Equal(t, &TamperError{ID: 1, Reason: "hash mismatch"}, err)
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
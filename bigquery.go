@@ -0,0 +1,37 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// WithUnnest makes a slice arg fill its (?) as one array parameter in
+// UNNEST, e.g. "a in (?)" runs as "a in UNNEST(@p1)" on bigquery, instead
+// of a parameter per element. The query is the same for any slice length.
+func WithUnnest() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.unnest = true
+	}
+}
+
+// namedArgs wraps args in sql.Named values named as their numbered
+// placeholders, e.g. p1 for @p1. Args already named are kept.
+func namedArgs(numbered string, args []interface{}) []interface{} {
+	prefix := strings.TrimPrefix(numbered, "@")
+	named := make([]interface{}, len(args))
+	for i, arg := range args {
+		if _, ok := arg.(sql.NamedArg); ok {
+			named[i] = arg
+			continue
+		}
+
+		named[i] = sql.Named(prefix+strconv.Itoa(i+1), arg)
+	}
+
+	return named
+}
+
+func bigqueryQuoteIdent(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "\\`") + "`"
+}
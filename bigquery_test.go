@@ -0,0 +1,47 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBigQuery(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewBigQuery(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = @p1 and b in (@p2,@p3)", query)
+	assert.Equal(t, []interface{}{sql.Named("p1", 1), sql.Named("p2", 2), sql.Named("p3", 3)}, args)
+	assert.Equal(t, "`ds`.`a\\`b`", s.QuoteIdent("ds.a`b"))
+
+	literal, err := s.interpolation("insert into foo values (?, ?, ?)",
+		[]interface{}{[]byte{0xca, 0xfe}, `it's \`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, `insert into foo values (FROM_HEX('cafe'), 'it\'s \\', TIMESTAMP '2024-01-02 03:04:05')`, literal)
+
+	mock.ExpectPrepare("update foo set a = @p1 where b = @p2").
+		ExpectExec().WithArgs(sql.Named("p1", 1), sql.Named("p2", 2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ? where b = ?", 1, 2)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestWithUnnest(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	ids := []int{2, 3}
+	s := NewBigQuery(db, WithUnnest())
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, ids)
+	assert.Equal(t, "select * from foo where a = @p1 and b in UNNEST(@p2)", query)
+	assert.Equal(t, []interface{}{sql.Named("p1", 1), sql.Named("p2", ids)}, args)
+
+	query, _ = s.Transform("select * from foo where b in (?)", []int{})
+	assert.Equal(t, "select * from foo where b in UNNEST(@p1)", query)
+}
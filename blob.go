@@ -0,0 +1,124 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+)
+
+var (
+	ErrBlobTooLarge = errors.New("sqlpp: blob too large")
+)
+
+// blobChunkSize is the chunk size of WriteBlob and ReadBlob when theirs is
+// not positive.
+const blobChunkSize = 1 << 20
+
+// blobMaxSize is the size WriteBlob writes up to without WithMaxBlobSize.
+const blobMaxSize = 64 << 20
+
+// WithMaxBlobSize makes WriteBlob fail with ErrBlobTooLarge past n bytes,
+// 64MiB if not positive. Each chunk rewrites the value written so far, so
+// the cost of a write grows with the square of its size.
+func WithMaxBlobSize(n int64) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.maxBlobSize = n
+	}
+}
+
+// WriteBlob streams r into column of the rows of table matching where,
+// appending chunks of up to chunkSize bytes, 1MiB if not positive, so the
+// value is never held in memory whole. Chunks must fit the packet limit of
+// mysql. Every append rewrites the whole value, so writing n bytes moves
+// O(n²/chunkSize) bytes, and values over WithMaxBlobSize fail with
+// ErrBlobTooLarge; use postgres large objects for bigger ones. It runs in a
+// transaction through the hooks and policies, readers see the old value
+// until the whole of r is written. It returns the bytes written. where is
+// written into the query as is, user input binds through its placeholders
+// with args.
+func (sqlpp *DB) WriteBlob(ctx context.Context, table, column string, r io.Reader, chunkSize int, where string, args ...interface{}) (int64, error) {
+	if err := sqlpp.helperSQL(); err != nil {
+		return 0, err
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = blobChunkSize
+	}
+
+	max := sqlpp.config().maxBlobSize
+	if max <= 0 {
+		max = blobMaxSize
+	}
+
+	column = sqlpp.QuoteIdent(column)
+	table = sqlpp.QuoteIdent(table)
+	appended := "CONCAT(" + column + ", ?)"
+	if sqlpp.dialect.flavor == postgresFlavor {
+		appended = column + " || ?"
+	}
+
+	var written int64
+	err := sqlpp.WithTx(ctx, nil, func(tx *Tx) error {
+		set := "UPDATE " + table + " SET " + column + " = ? WHERE " + where
+		if _, err := tx.ExecContext(ctx, set, append([]interface{}{[]byte{}}, args...)...); err != nil {
+			return err
+		}
+
+		query := "UPDATE " + table + " SET " + column + " = " + appended + " WHERE " + where
+		chunk := make([]byte, chunkSize)
+		for {
+			n, err := io.ReadFull(r, chunk)
+			if n > 0 {
+				if written+int64(n) > max {
+					return ErrBlobTooLarge
+				}
+
+				if _, err := tx.ExecContext(ctx, query, append([]interface{}{chunk[:n]}, args...)...); err != nil {
+					return err
+				}
+
+				written += int64(n)
+			}
+
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	})
+
+	return written, err
+}
+
+// ReadBlob streams column of the row of table matching where into w in
+// chunks of chunkSize bytes, read from one snapshot of the row through the
+// hooks and policies, without holding the value in memory whole. Each chunk
+// is a SUBSTR of the value, which mysql and compressed postgres columns
+// read whole for; a postgres column SET STORAGE EXTERNAL reads only the
+// chunk. It returns the bytes written, and sql.ErrNoRows if no row matches.
+func (sqlpp *DB) ReadBlob(ctx context.Context, w io.Writer, table, column string, chunkSize int, where string, args ...interface{}) (int64, error) {
+	if chunkSize <= 0 {
+		chunkSize = blobChunkSize
+	}
+
+	query := "SELECT SUBSTR(" + sqlpp.QuoteIdent(column) + ", ?, ?) FROM " + sqlpp.QuoteIdent(table) + " WHERE " + where
+	var read int64
+	err := sqlpp.WithTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(tx *Tx) error {
+		for {
+			var chunk []byte
+			if err := tx.RowContext(ctx, query, append([]interface{}{read + 1, chunkSize}, args...)...).Scan(&chunk); err != nil {
+				return err
+			}
+
+			n, err := w.Write(chunk)
+			read += int64(n)
+			if err != nil || len(chunk) < chunkSize {
+				return err
+			}
+		}
+	})
+
+	return read, err
+}
@@ -0,0 +1,99 @@
+package sqlpp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_WriteBlob(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE `files` SET `data` = ? WHERE id = ?").
+		ExpectExec().WithArgs([]byte{}, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	appended := mock.ExpectPrepare("UPDATE `files` SET `data` = CONCAT(`data`, ?) WHERE id = ?")
+	appended.ExpectExec().WithArgs([]byte("abcd"), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	appended.ExpectExec().WithArgs([]byte("ef"), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := m.WriteBlob(ctx, "files", "data", strings.NewReader("abcdef"), 4, "id = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), n)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`UPDATE "files" SET "data" = $1 WHERE id = $2`).
+		ExpectExec().WithArgs([]byte{}, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`UPDATE "files" SET "data" = "data" || $1 WHERE id = $2`).
+		ExpectExec().WithArgs([]byte("abcd"), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err = p.WriteBlob(ctx, "files", "data", strings.NewReader("abcd"), 4, "id = ?", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), n)
+
+	// past the max size the write is rolled back
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE `files` SET `data` = ? WHERE id = ?").
+		ExpectExec().WithArgs([]byte{}, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE `files` SET `data` = CONCAT(`data`, ?) WHERE id = ?").
+		ExpectExec().WithArgs([]byte("abcd"), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	n, err = m.Clone(WithMaxBlobSize(5)).WriteBlob(ctx, "files", "data", strings.NewReader("abcdef"), 4, "id = ?", 3)
+	assert.Equal(t, ErrBlobTooLarge, err)
+	assert.Equal(t, int64(4), n)
+
+	// policies apply
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_, err = m.Clone(WithPolicy(ReadOnly())).WriteBlob(ctx, "files", "data", strings.NewReader("abcd"), 4, "id = ?", 4)
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_ReadBlob(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+
+	query := "SELECT SUBSTR(`data`, ?, ?) FROM `files` WHERE id = ?"
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare(query)
+	stmt.ExpectQuery().WithArgs(1, 4, 1).WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte("abcd")))
+	stmt.ExpectQuery().WithArgs(5, 4, 1).WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte("abcd")))
+	stmt.ExpectQuery().WithArgs(9, 4, 1).WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte{}))
+	mock.ExpectCommit()
+
+	var b bytes.Buffer
+	n, err := m.ReadBlob(ctx, &b, "files", "data", 4, "id = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), n)
+	assert.Equal(t, "abcdabcd", b.String())
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`SELECT SUBSTR("data", $1, $2) FROM "files" WHERE id = $3`).
+		ExpectQuery().WithArgs(1, 4, 2).WillReturnRows(sqlmock.NewRows([]string{"data"}))
+	mock.ExpectRollback()
+
+	n, err = p.ReadBlob(ctx, &b, "files", "data", 4, "id = ?", 2)
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Zero(t, n)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
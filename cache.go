@@ -0,0 +1,254 @@
+package sqlpp
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache stores Query results. Implementations must be safe for
+// concurrent use, a shared store like redis can implement it as well.
+type ResultCache interface {
+	Get(key string) ([]interface{}, bool)
+	Set(key string, results []interface{}, ttl time.Duration, tags []string)
+	Delete(keys ...string)
+	Invalidate(tags ...string)
+}
+
+type cacheOptions struct {
+	ttl  time.Duration
+	tags []string
+}
+
+// Cache makes Query calls run with ctx read through the result cache,
+// keeping results for ttl. tags group entries for InvalidateTags.
+func Cache(ctx context.Context, ttl time.Duration, tags ...string) context.Context {
+	return context.WithValue(ctx, cacheKey, &cacheOptions{ttl, tags})
+}
+
+// CacheKey returns the result cache key of query with args in the cache
+// namespace of the db, see WithCacheNamespace. Args are keyed by the value
+// they bind as, pointers by what they point to and times in UTC.
+func (sqlpp *DB) CacheKey(query string, args ...interface{}) string {
+	ns := sqlpp.config().cacheNamespace
+	if ns == "" {
+		ns = fmt.Sprintf("%p", sqlpp)
+	}
+
+	var b strings.Builder
+	writeKeyPart(&b, 'n', ns)
+	writeKeyPart(&b, 'q', query)
+	for _, arg := range args {
+		writeKeyArg(&b, arg)
+	}
+
+	return b.String()
+}
+
+// writeKeyArg writes arg as the driver value it binds as, each element of
+// the slices filling a (?).
+func writeKeyArg(b *strings.Builder, arg interface{}) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			writeKeyPart(b, 'l', strconv.Itoa(rv.Len()))
+			for i := 0; i < rv.Len(); i++ {
+				writeKeyArg(b, rv.Index(i).Interface())
+			}
+
+			return
+		}
+
+		writeKeyPart(b, 'v', fmt.Sprintf("%T:%v", arg, arg))
+		return
+	}
+
+	switch v := v.(type) {
+	case nil:
+		writeKeyPart(b, '0', "")
+	case int64:
+		writeKeyPart(b, 'i', strconv.FormatInt(v, 10))
+	case float64:
+		writeKeyPart(b, 'f', strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		writeKeyPart(b, 'b', strconv.FormatBool(v))
+	case []byte:
+		writeKeyPart(b, 'x', string(v))
+	case string:
+		writeKeyPart(b, 's', v)
+	case time.Time:
+		writeKeyPart(b, 't', v.UTC().Format(time.RFC3339Nano))
+	default:
+		writeKeyPart(b, 'v', fmt.Sprintf("%T:%v", v, v))
+	}
+}
+
+// writeKeyPart writes s length prefixed, so no two keys run together.
+func writeKeyPart(b *strings.Builder, kind byte, s string) {
+	b.WriteByte(kind)
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
+
+func (sqlpp *DB) InvalidateKeys(keys ...string) {
+	if cache := sqlpp.config().resultCache; cache != nil {
+		cache.Delete(keys...)
+	}
+}
+
+func (sqlpp *DB) InvalidateTags(tags ...string) {
+	if cache := sqlpp.config().resultCache; cache != nil {
+		cache.Invalidate(tags...)
+	}
+}
+
+// cached reads the results of query from the result cache when ctx asks
+// for it, calling q and storing its results on a miss. Callers get their
+// own copy of the results slice. Queries the policies restrict fail before
+// the cache is read.
+func (sqlpp *DB) cached(ctx context.Context, query string, args []interface{}, q func() ([]interface{}, error)) ([]interface{}, error) {
+	if err := sqlpp.allow(query); err != nil {
+		return nil, err
+	}
+
+	opts, _ := ctx.Value(cacheKey).(*cacheOptions)
+	cache := sqlpp.config().resultCache
+	if opts == nil || cache == nil {
+		return q()
+	}
+
+	key := sqlpp.CacheKey(query, args...)
+	if results, ok := cache.Get(key); ok {
+		return append([]interface{}(nil), results...), nil
+	}
+
+	results, err := q()
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Set(key, append([]interface{}(nil), results...), opts.ttl, opts.tags)
+	return results, nil
+}
+
+// LRUCache is an in-memory ResultCache evicting the least recently used
+// entries over its size.
+type LRUCache struct {
+	mu sync.Mutex
+
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	tags    map[string]map[string]struct{}
+}
+
+type lruEntry struct {
+	key     string
+	results []interface{}
+	expires time.Time
+	tags    []string
+}
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		tags:    map[string]map[string]struct{}{},
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return entry.results, true
+}
+
+func (c *LRUCache) Set(key string, results []interface{}, ttl time.Duration, tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+
+	entry := &lruEntry{key: key, results: results, tags: tags}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRUCache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			c.remove(e)
+		}
+	}
+}
+
+func (c *LRUCache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if e, ok := c.entries[key]; ok {
+				c.remove(e)
+			}
+		}
+	}
+}
+
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRUCache) remove(e *list.Element) {
+	entry := c.order.Remove(e).(*lruEntry)
+	delete(c.entries, entry.key)
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], entry.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
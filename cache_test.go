@@ -0,0 +1,120 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+
+	c.Set("a", []interface{}{1}, 0, []string{"foo"})
+	c.Set("b", []interface{}{2}, 0, []string{"foo", "bar"})
+	c.Set("c", []interface{}{3}, time.Nanosecond, nil)
+	assert.Equal(t, 2, c.Len())
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "a evicted")
+
+	time.Sleep(time.Millisecond)
+	_, ok = c.Get("c")
+	assert.False(t, ok, "c expired")
+
+	r, ok := c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{2}, r)
+
+	c.Set("d", []interface{}{4}, 0, []string{"bar"})
+	c.Invalidate("bar")
+	assert.Equal(t, 0, c.Len())
+	assert.Empty(t, c.tags)
+
+	c.Set("e", []interface{}{5}, 0, nil)
+	c.Delete("e", "f")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestDB_cached(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithResultCache(NewLRUCache(10)))
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	p := mock.ExpectPrepare("^select i from foo where j = (.+)$")
+	p.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	p.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(2))
+	p.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(3))
+	p.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(4))
+	p.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(5))
+
+	ctx := Cache(context.Background(), time.Minute, "foo")
+	query := "select i from foo where j = ?"
+
+	for _, c := range []struct {
+		ctx context.Context
+		arg int
+		e   int
+	}{
+		{ctx, 1, 1},
+		{ctx, 1, 1},
+		{ctx, 2, 2},
+		{context.Background(), 1, 3},
+		{ctx, 1, 1},
+	} {
+		r, err := s.QueryContext(c.ctx, query, s.Args(c.arg), scanner)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{c.e}, r)
+	}
+
+	s.InvalidateKeys(s.CacheKey(query, 1))
+	r, _ := s.QueryContext(ctx, query, s.Args(1), scanner)
+	assert.Equal(t, []interface{}{4}, r)
+
+	// results are copied out of the cache
+	r[0] = 0
+	s.InvalidateTags("bar")
+	r, _ = s.QueryContext(ctx, query, s.Args(1), scanner)
+	assert.Equal(t, []interface{}{4}, r)
+
+	s.InvalidateTags("foo")
+	r, _ = s.Compile(query).QueryContext(ctx, s.Args(1), scanner)
+	assert.Equal(t, []interface{}{5}, r)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_CacheKey(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	query := "select i from foo where a = ? and b = ?"
+	one, now := 1, time.Now()
+
+	// args can't run together
+	assert.NotEqual(t, s.CacheKey(query, "a\x00string:b", "c"), s.CacheKey(query, "a", "b\x00string:c"))
+	assert.NotEqual(t, s.CacheKey(query, "1"), s.CacheKey(query, 1))
+	assert.NotEqual(t, s.CacheKey(query, []int{1, 2}, 3), s.CacheKey(query, []int{1}, []int{2, 3}))
+
+	// keyed by the bound value
+	assert.Equal(t, s.CacheKey(query, 1), s.CacheKey(query, &one))
+	assert.Equal(t, s.CacheKey(query, int32(1)), s.CacheKey(query, int64(1)))
+	assert.Equal(t, s.CacheKey(query, now), s.CacheKey(query, now.Round(0).In(time.FixedZone("x", 3600))))
+	assert.Equal(t, s.CacheKey(query, PII("a")), s.CacheKey(query, "a"))
+
+	// per db unless they share a namespace
+	db2, _, err := sqlmock.New()
+	assert.Nil(t, err)
+	assert.NotEqual(t, s.CacheKey(query, 1), NewMySQL(db2).CacheKey(query, 1))
+	assert.NotEqual(t, NewMySQL(db, WithCacheNamespace("a")).CacheKey(query, 1), NewMySQL(db, WithCacheNamespace("b")).CacheKey(query, 1))
+	assert.Equal(t, NewMySQL(db, WithCacheNamespace("a")).CacheKey(query, 1), NewMySQL(db, WithCacheNamespace("a")).CacheKey(query, 1))
+}
@@ -0,0 +1,221 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedServer = errors.New("sqlpp: unsupported server version")
+	ErrUnsupported       = errors.New("sqlpp: not supported by the server")
+)
+
+// oldest versions sqlpp runs against, by server
+var minVersions = map[string][3]int{
+	"mysql":       {5, 7, 0},
+	"mariadb":     {10, 2, 0},
+	"postgres":    {9, 5, 0},
+	"sqlite":      {3, 24, 0},
+	"sqlserver":   {11, 0, 0}, // 2012
+	"oracle":      {12, 1, 0},
+	"cockroachdb": {21, 2, 0},
+}
+
+// Capabilities describes the features of the server a db is connected to.
+type Capabilities struct {
+	// Version is the server version as reported, empty until detected.
+	Version string
+	MariaDB bool
+
+	// INSERT, UPDATE and DELETE ... RETURNING
+	Returning bool
+	// INSERT ... AS new ON DUPLICATE KEY UPDATE col = new.col, replacing
+	// the deprecated VALUES(col)
+	UpsertAlias bool
+	// SELECT ... FOR UPDATE SKIP LOCKED
+	SkipLocked bool
+	// WITH queries
+	CTE bool
+	// placeholders in a statement
+	MaxParams int
+
+	version [3]int
+}
+
+// WithServerVersion sets the Capabilities of the db from version instead
+// of DetectCapabilities, e.g. 8.0.34, 10.6.12-MariaDB or 16.1.
+func WithServerVersion(version string) Option {
+	return func(sqlpp *DB) {
+		caps := capabilitiesOf(sqlpp.dialect, version)
+		sqlpp.cfg.capabilities = &caps
+	}
+}
+
+// DetectCapabilities reads the server version and gates the features
+// sqlpp uses by it, failing with ErrUnsupportedServer on a server older
+// than mysql 5.7, mariadb 10.2, postgres 9.5, sqlite 3.24, sql server
+// 2012, oracle 12.1 or cockroach 21.2. Call it at startup, after NewMySQL
+// or NewPostgreSQL, so a mismatch fails there instead of on the first
+// query needing a missing feature.
+func (sqlpp *DB) DetectCapabilities(ctx context.Context) (Capabilities, error) {
+	var version string
+	if err := sqlpp.DB.QueryRowContext(ctx, sqlpp.dialect.version).Scan(&version); err != nil {
+		return Capabilities{}, err
+	}
+
+	caps := capabilitiesOf(sqlpp.dialect, version)
+	server := sqlpp.dialect.name
+	if caps.MariaDB {
+		server = "mariadb"
+	}
+
+	if min := minVersions[server]; !atLeast(caps.version, min) {
+		return caps, fmt.Errorf("%w: %s %s, need %d.%d", ErrUnsupportedServer, server, version, min[0], min[1])
+	}
+
+	sqlpp.SetOption(func(sqlpp *DB) {
+		sqlpp.cfg.capabilities = &caps
+	})
+
+	return caps, nil
+}
+
+// Capabilities returns the detected capabilities of the server, or the
+// ones of a recent server until DetectCapabilities or WithServerVersion.
+func (sqlpp *DB) Capabilities() Capabilities {
+	if caps := sqlpp.config().capabilities; caps != nil {
+		return *caps
+	}
+
+	return Capabilities{
+		Returning:  sqlpp.dialect.returning,
+		SkipLocked: sqlpp.dialect.skipLocked,
+		CTE:        true,
+		MaxParams:  sqlpp.dialect.maxParams,
+	}
+}
+
+// AtLeast reports whether the server version is version or newer, true
+// while the version is unknown.
+func (c Capabilities) AtLeast(version string) bool {
+	if c.Version == "" {
+		return true
+	}
+
+	return atLeast(c.version, parseVersion(version))
+}
+
+func capabilitiesOf(d *dialect, version string) Capabilities {
+	v := parseVersion(version)
+	caps := Capabilities{Version: version, MaxParams: d.maxParams, version: v}
+	switch {
+	case d == cockroachDialect:
+		caps.Returning = true
+		caps.SkipLocked = atLeast(v, [3]int{22, 2, 0})
+		caps.CTE = true
+	case d == redshiftDialect:
+		caps.CTE = true
+	case d.flavor == postgresFlavor:
+		caps.Returning = true
+		caps.SkipLocked = atLeast(v, [3]int{9, 5, 0})
+		caps.CTE = true
+	case strings.Contains(strings.ToLower(version), "mariadb"):
+		caps.MariaDB = true
+		caps.Returning = atLeast(v, [3]int{10, 5, 0})
+		caps.SkipLocked = atLeast(v, [3]int{10, 6, 0})
+		caps.CTE = atLeast(v, [3]int{10, 2, 1})
+	case d == sqliteDialect:
+		caps.Returning = atLeast(v, [3]int{3, 35, 0})
+		caps.CTE = true
+		// 999 before 3.32
+		if !atLeast(v, [3]int{3, 32, 0}) {
+			caps.MaxParams = 999
+		}
+	case d == sqlserverDialect:
+		caps.CTE = true
+	case d == oracleDialect:
+		// RETURNING .. INTO binds sql.Out args
+		caps.Returning = true
+		caps.CTE = true
+	case d == tidbDialect:
+		// tidb reports its mysql version, it has no SKIP LOCKED
+		caps.CTE = true
+	case d == duckdbDialect:
+		caps.Returning = true
+		caps.CTE = true
+	case d == snowflakeDialect, d == bigqueryDialect, d == spannerDialect, d == trinoDialect,
+		d == verticaDialect, d == hanaDialect:
+		caps.CTE = true
+	default:
+		caps.UpsertAlias = atLeast(v, [3]int{8, 0, 19})
+		caps.SkipLocked = atLeast(v, [3]int{8, 0, 1})
+		caps.CTE = atLeast(v, [3]int{8, 0, 1})
+	}
+
+	return caps
+}
+
+// parseVersion reads the first major.minor.patch of version, ignoring
+// prefixes like CockroachDB CCL v and suffixes like -log or (Debian
+// 16.1-1). Versions of the mariadb 10 series may start with the 5.5.5-
+// prefix of old replication clients.
+func parseVersion(version string) [3]int {
+	version = strings.TrimPrefix(version, "5.5.5-")
+	version = strings.TrimLeftFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	var v [3]int
+	for i := range v {
+		j := 0
+		for j < len(version) && version[j] >= '0' && version[j] <= '9' {
+			j++
+		}
+
+		v[i], _ = strconv.Atoi(version[:j])
+		if j == len(version) || version[j] != '.' {
+			break
+		}
+
+		version = version[j+1:]
+	}
+
+	return v
+}
+
+func atLeast(v, min [3]int) bool {
+	for i := range v {
+		if v[i] != min[i] {
+			return v[i] > min[i]
+		}
+	}
+
+	return true
+}
+
+// supported rejects the statements using a feature the detected server
+// lacks, before they reach it.
+func (sqlpp *DB) supported(query string) error {
+	caps := sqlpp.config().capabilities
+	if caps == nil || caps.Returning || !containsFold(query, "RETURNING") {
+		return nil
+	}
+
+	for _, token := range tokens(query, sqlpp.dialect.backslashStrings()) {
+		if strings.EqualFold(token, "RETURNING") {
+			return fmt.Errorf("%w: RETURNING on %s", ErrUnsupported, caps.Version)
+		}
+	}
+
+	return nil
+}
+
+func containsFold(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return true
+		}
+	}
+
+	return false
+}
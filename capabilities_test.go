@@ -0,0 +1,105 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_DetectCapabilities(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+
+	assert.Equal(t, "", m.Capabilities().Version)
+	assert.False(t, m.Capabilities().Returning)
+	assert.True(t, p.Capabilities().Returning)
+	assert.True(t, m.Capabilities().AtLeast("9.0"))
+
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("5.7.42-log"))
+	caps, err := m.DetectCapabilities(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "5.7.42-log", caps.Version)
+	assert.False(t, caps.UpsertAlias)
+	assert.False(t, caps.SkipLocked)
+	assert.False(t, caps.CTE)
+	assert.True(t, caps.AtLeast("5.7"))
+	assert.False(t, caps.AtLeast("8.0"))
+	assert.Equal(t, caps, m.Capabilities())
+
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("5.6.51"))
+	_, err = NewMySQL(db).DetectCapabilities(ctx)
+	assert.True(t, errors.Is(err, ErrUnsupportedServer))
+
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("10.6.12-MariaDB-1:10.6.12+maria~ubu2004"))
+	caps, err = NewMySQL(db).DetectCapabilities(ctx)
+	assert.Nil(t, err)
+	assert.True(t, caps.MariaDB)
+	assert.True(t, caps.Returning)
+	assert.True(t, caps.SkipLocked)
+
+	mock.ExpectQuery("SELECT current_setting('server_version')").
+		WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("16.1 (Debian 16.1-1.pgdg120+1)"))
+	caps, err = p.DetectCapabilities(ctx)
+	assert.Nil(t, err)
+	assert.True(t, caps.Returning)
+	assert.True(t, caps.AtLeast("12"))
+	assert.False(t, caps.AtLeast("16.2"))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_supported(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithServerVersion("8.0.34"))
+	assert.True(t, s.Capabilities().UpsertAlias)
+
+	_, err = s.Exec("delete from foo where id = ? returning id", 1)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	mock.ExpectPrepare("select 'returning' from returning_log").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("select 'returning' from returning_log")
+	assert.Nil(t, err)
+
+	mock.ExpectPrepare("INSERT INTO `locks` (name, owner, expires) VALUES (?,?,?) AS new ON DUPLICATE KEY UPDATE " +
+		"owner = IF(expires < ?, new.owner, owner), expires = IF(expires < ?, new.expires, expires)").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("SELECT owner FROM `locks` WHERE name = ?").
+		ExpectQuery().WithArgs("jobs").WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("other"))
+
+	ok, err := NewTableLock(s, "locks", "jobs", time.Minute).TryLock(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_parseVersion(t *testing.T) {
+	cases := []struct {
+		version string
+		want    [3]int
+	}{
+		{"8.0.34", [3]int{8, 0, 34}},
+		{"5.7.42-log", [3]int{5, 7, 42}},
+		{"5.5.5-10.6.12-MariaDB", [3]int{10, 6, 12}},
+		{"16.1 (Debian 16.1-1.pgdg120+1)", [3]int{16, 1, 0}},
+		{"12", [3]int{12, 0, 0}},
+		{"", [3]int{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.version, func(t *testing.T) {
+			assert.Equal(t, c.want, parseVersion(c.version))
+		})
+	}
+}
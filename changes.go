@@ -0,0 +1,98 @@
+package sqlpp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// ChangeEvent describes a successful INSERT, UPDATE or DELETE.
+type ChangeEvent struct {
+	Table string
+	// Operation is INSERT, UPDATE or DELETE, REPLACE counts as INSERT.
+	Operation string
+	// RowsAffected is -1 for statements run by Query or QueryRow.
+	RowsAffected int64
+	// Keys has a value per changed row when derivable: the rows a RETURNING
+	// statement run by Query or QueryRow returned, a slice of the columns
+	// for QueryRow with many dests, or the LastInsertId of a single row
+	// inserted on mysql.
+	Keys []interface{}
+}
+
+// WithChangeEvents calls fn after every successful INSERT, UPDATE and
+// DELETE, for invalidating caches or updating search indexes. fn runs on
+// the goroutine of the statement.
+func WithChangeEvents(fn func(ctx context.Context, e *ChangeEvent)) Option {
+	return func(sqlpp *DB) {
+		WithHook(func(ctx context.Context, e *QueryEvent) {
+			if e.Err != nil {
+				return
+			}
+
+			operation := statementType(e.Query)
+			if operation == "" {
+				return
+			}
+
+			change := &ChangeEvent{Table: changedTable(e.Query, operation, sqlpp.dialect.backslashStrings()), Operation: operation, RowsAffected: -1}
+			if e.Result != nil {
+				change.RowsAffected, _ = e.Result.RowsAffected()
+				if sqlpp.dialect.lastInsertID && operation == "INSERT" && change.RowsAffected == 1 {
+					if id, err := e.Result.LastInsertId(); err == nil && id > 0 {
+						change.Keys = []interface{}{id}
+					}
+				}
+			} else if e.Rows > 0 {
+				change.Keys = e.returned
+			} else if len(e.returned) > 0 {
+				change.Keys = []interface{}{row(e.returned)}
+			}
+
+			fn(ctx, change)
+		})(sqlpp)
+	}
+}
+
+// changedTable returns the table after the keyword of operation, e.g.
+// DELETE FROM t.
+func changedTable(query, operation string, backslash bool) string {
+	tokens := tokens(query, backslash)
+	for i, token := range tokens {
+		if !strings.EqualFold(token, operation) && !(operation == "INSERT" && strings.EqualFold(token, "REPLACE")) {
+			continue
+		}
+
+		for _, next := range tokens[i+1:] {
+			switch strings.ToUpper(next) {
+			case "INTO", "FROM", "ONLY", "IGNORE", "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "QUICK":
+				continue
+			}
+
+			if isIdentByte(next[0]) {
+				return next
+			}
+
+			break
+		}
+	}
+
+	return ""
+}
+
+// row returns the values QueryRow scanned into dest, a slice of them for
+// many dests.
+func row(dest []interface{}) interface{} {
+	values := make([]interface{}, len(dest))
+	for i, d := range dest {
+		if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && !v.IsNil() {
+			values[i] = v.Elem().Interface()
+		}
+	}
+
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	return values
+}
@@ -0,0 +1,63 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithChangeEvents(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New()
+	pDb, pMock, pErr := sqlmock.New()
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	var changes []*ChangeEvent
+	record := WithChangeEvents(func(ctx context.Context, e *ChangeEvent) {
+		changes = append(changes, e)
+	})
+
+	m := NewMySQL(mDb, record)
+	p := NewPostgreSQL(pDb, record)
+
+	mMock.ExpectPrepare("^insert into foo").ExpectExec().WillReturnResult(sqlmock.NewResult(7, 1))
+	mMock.ExpectPrepare("^update `foo`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+	mMock.ExpectPrepare("^select").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	pMock.ExpectPrepare("^delete from only foo").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	pMock.ExpectPrepare("^insert into s.bar").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "v"}).AddRow(3, "a"))
+	pMock.ExpectPrepare("^with").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := m.Exec("insert into foo (i) values (?)", 1)
+	assert.Nil(t, err)
+	_, err = m.Exec("update `foo` set i = 2 where i > ?", 0)
+	assert.Nil(t, err)
+	var i int
+	assert.Nil(t, m.QueryRow("select i from foo", nil, &i))
+
+	ids, err := p.Query("delete from only foo where i < ? returning id", []interface{}{3}, func(rows *sql.Rows) (interface{}, error) {
+		var id int
+		return id, rows.Scan(&id)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, ids)
+
+	var id int
+	var v string
+	assert.Nil(t, p.QueryRow("insert into s.bar (v) values (?) returning id, v", []interface{}{"a"}, &id, &v))
+	_, err = p.Exec("with x as (select 1) update baz set i = 1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []*ChangeEvent{
+		{Table: "foo", Operation: "INSERT", RowsAffected: 1, Keys: []interface{}{int64(7)}},
+		{Table: "foo", Operation: "UPDATE", RowsAffected: 3},
+		{Table: "foo", Operation: "DELETE", RowsAffected: -1, Keys: []interface{}{1, 2}},
+		{Table: "s.bar", Operation: "INSERT", RowsAffected: -1, Keys: []interface{}{[]interface{}{3, "a"}}},
+		{Table: "baz", Operation: "UPDATE", RowsAffected: 1},
+	}, changes)
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
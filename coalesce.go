@@ -0,0 +1,49 @@
+package sqlpp
+
+import "context"
+
+type flight struct {
+	done    chan struct{}
+	results []interface{}
+	err     error
+}
+
+// Coalesce makes Select calls run with ctx share the results of an
+// identical Select, same query and args, already running instead of
+// querying again. Coalesced calls must scan the rows the same way. Each
+// gets its own results slice, but pointers in it point to the same
+// values. They get the error of the shared query too, including the
+// cancellation of its caller's ctx.
+func Coalesce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, coalesceKey, true)
+}
+
+// coalesced calls q, or waits for the q running for the same query and
+// args if ctx is a Coalesce context.
+func (sqlpp *DB) coalesced(ctx context.Context, query string, args []interface{}, q func() ([]interface{}, error)) ([]interface{}, error) {
+	if coalesce, _ := ctx.Value(coalesceKey).(bool); !coalesce {
+		return q()
+	} else if err := sqlpp.allow(query); err != nil {
+		return nil, err
+	}
+
+	key := sqlpp.CacheKey(query, args...)
+	f := &flight{done: make(chan struct{})}
+	if running, ok := sqlpp.flights.LoadOrStore(key, f); ok {
+		f = running.(*flight)
+		select {
+		case <-f.done:
+			return append([]interface{}(nil), f.results...), f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	defer func() {
+		sqlpp.flights.Delete(key)
+		close(f.done)
+	}()
+
+	f.results, f.err = q()
+	return append([]interface{}(nil), f.results...), f.err
+}
@@ -0,0 +1,73 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Coalesce(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	query := "select id from users where team = ?"
+	scan := func(rows *sql.Rows) (interface{}, error) {
+		var id int
+		err := rows.Scan(&id)
+		return id, err
+	}
+
+	prepared := mock.ExpectPrepare(query)
+	prepared.ExpectQuery().WithArgs(1).WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	prepared.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	ctx := Coalesce(context.Background())
+	leader := make(chan []interface{})
+	go func() {
+		results, err := s.SelectContext(ctx, scan, query, 1)
+		assert.Nil(t, err)
+		leader <- results
+	}()
+
+	for {
+		if _, ok := s.flights.Load(s.CacheKey(query, 1)); ok {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := s.SelectContext(ctx, scan, query, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, []interface{}{1, 2}, results)
+			results[0] = 0
+		}()
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.SelectContext(canceled, scan, query, 1)
+	assert.Equal(t, context.Canceled, err)
+
+	wg.Wait()
+	assert.Equal(t, []interface{}{1, 2}, <-leader)
+
+	// not coalesced once the shared query is done
+	results, err := s.SelectContext(ctx, scan, query, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{3}, results)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
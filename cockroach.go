@@ -0,0 +1,74 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const defaultRetryBackoff = 10 * time.Millisecond
+
+// retryable errors of postgres and cockroach: serialization failure and
+// deadlock
+var postgresRetryableStates = map[string]bool{"40001": true, "40P01": true}
+
+// WithRetry re-runs the statements run on the db, outside transactions,
+// failing with an error the database asks to retry, up to attempts more
+// times, waiting backoff doubled after each. Cockroach needs it for its
+// serialization errors, NewCockroachDB retries 3 times by default. Queries
+// are retried until they return their rows, not while reading them.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.retries = attempts
+		sqlpp.cfg.retryBackoff = backoff
+	}
+}
+
+// IsRetryable reports whether err is a conflict the database asks to
+// retry, like a postgres or cockroach serialization failure or a tidb
+// write conflict, for retrying transactions, which WithRetry doesn't.
+func IsRetryable(err error) bool {
+	return isPostgresRetryable(err) || isTiDBRetryable(err)
+}
+
+// retry calls run until it succeeds, fails with an error the dialect
+// doesn't retry, or the retries are used up.
+func (sqlpp *DB) retry(ctx context.Context, run func() error) error {
+	cfg := sqlpp.config()
+	err := run()
+	backoff := cfg.retryBackoff
+	for i := 0; i < cfg.retries && sqlpp.dialect.retryable(err); i++ {
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+
+		backoff *= 2
+		err = run()
+	}
+
+	return err
+}
+
+// isPostgresRetryable matches the serialization failures and deadlocks
+// by the sqlstate of the error, or by their message for drivers without
+// one. Cockroach reports them as restart transaction errors.
+func isPostgresRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return postgresRetryableStates[state.SQLState()]
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "SQLSTATE 40001") || strings.Contains(msg, "SQLSTATE 40P01") ||
+		strings.Contains(msg, "could not serialize access") || strings.Contains(msg, "deadlock detected") ||
+		strings.Contains(msg, "restart transaction")
+}
@@ -0,0 +1,122 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string {
+	return "failed"
+}
+
+func (e sqlStateError) SQLState() string {
+	return string(e)
+}
+
+func Test_isPostgresRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			sqlStateError("40001"),
+			true,
+		},
+		{
+			fmt.Errorf("wrapped: %w", sqlStateError("40P01")),
+			true,
+		},
+		{
+			sqlStateError("23505"),
+			false,
+		},
+		{
+			errors.New("pq: restart transaction: TransactionRetryWithProtoRefreshError: WriteTooOldError"),
+			true,
+		},
+		{
+			errors.New("ERROR: could not serialize access due to concurrent update (SQLSTATE 40001)"),
+			true,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%v", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isPostgresRetryable(c.err))
+		})
+	}
+}
+
+func TestNewCockroachDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewCockroachDB(db, WithRetry(2, time.Millisecond))
+	query, _ := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = $1 and b in ($2,$3)", query)
+
+	retry := errors.New("pq: restart transaction: TransactionRetryWithProtoRefreshError")
+	stmt := mock.ExpectPrepare("update foo set a = $1")
+	stmt.ExpectExec().WithArgs(1).WillReturnError(retry)
+	stmt.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	// gives up after the retries
+	stmt.ExpectExec().WithArgs(2).WillReturnError(retry)
+	stmt.ExpectExec().WithArgs(2).WillReturnError(retry)
+	stmt.ExpectExec().WithArgs(2).WillReturnError(retry)
+	_, err = s.Exec("update foo set a = ?", 2)
+	assert.Equal(t, retry, err)
+
+	// other errors aren't retried
+	stmt.ExpectExec().WithArgs(3).WillReturnError(sqlStateError("23505"))
+	_, err = s.Exec("update foo set a = ?", 3)
+	assert.Equal(t, sqlStateError("23505"), err)
+
+	mock.ExpectQuery("SELECT version()").
+		WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("CockroachDB CCL v23.1.11 (x86_64-pc-linux-gnu)"))
+	caps, err := s.DetectCapabilities(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, caps.SkipLocked)
+	assert.True(t, caps.AtLeast("23.1"))
+
+	// no advisory locks, identity inserts or wal lsn
+	ctx := context.Background()
+	_, err = s.NewLock("foo").TryLock(ctx)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.True(t, errors.Is(s.NewLock("foo").Lock(ctx), ErrNotSupported))
+	assert.True(t, errors.Is(s.InsertFixtures(ctx, Fixture{Table: "foo"}), ErrNotSupported))
+	assert.True(t, errors.Is(s.ResetTables(ctx, "foo"), ErrNotSupported))
+	_, err = s.ConsistencyToken(ctx)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.True(t, errors.Is(s.WaitForToken(ctx, "0/0"), ErrNotSupported))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	// postgres doesn't retry without WithRetry
+	pDb, pMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	pMock.ExpectPrepare("update foo set a = $1").ExpectExec().WithArgs(1).WillReturnError(retry)
+	_, err = NewPostgreSQL(pDb).Exec("update foo set a = ?", 1)
+	assert.Equal(t, retry, err)
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
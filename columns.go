@@ -0,0 +1,166 @@
+package sqlpp
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structFields caches the columns of struct types
+var structFields sync.Map
+
+type structField struct {
+	column string
+	index  []int
+	// tagged db:"name,pk"
+	pk bool
+	// tagged pii:"true", its value binds as PII
+	pii bool
+	// tagged encrypt:"true", its value is stored encrypted
+	encrypt bool
+}
+
+// Columns returns the quoted, comma separated columns of struct T for a
+// select list, see ColumnsOf.
+func Columns[T any](db *DB) string {
+	var v T
+	return db.ColumnsOf(v)
+}
+
+// ColumnsOf returns the quoted, comma separated columns of the struct v,
+// or v points to, in field order. A column is named by the db tag of its
+// field, or the lower cased field name, and fields tagged db:"-" are
+// skipped. Embedded structs add their columns in place. A ",pk" after the
+// name marks the primary key for UpdateVersioned, Delete and Insert, a
+// pii:"true" tag binds the field's value as PII, and an encrypt:"true" tag
+// stores it encrypted with the WithCipher of the db, see FieldsOf.
+func (sqlpp *DB) ColumnsOf(v interface{}) string {
+	fields := fieldsOf(reflect.TypeOf(v))
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = sqlpp.QuoteIdent(f.column)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// Fields returns pointers to the fields of the struct v points to, in the
+// order of ColumnsOf, to Scan a row into.
+func Fields(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v).Elem()
+	fields := fieldsOf(rv.Type())
+	dest := make([]interface{}, len(fields))
+	for i, f := range fields {
+		dest[i] = rv.FieldByIndex(f.index).Addr().Interface()
+	}
+
+	return dest
+}
+
+// FieldsOf is Fields of a row of table, decrypting the fields tagged
+// encrypt:"true" with the WithCipher of the db.
+func (sqlpp *DB) FieldsOf(table string, v interface{}) []interface{} {
+	dest := Fields(v)
+	rv := reflect.ValueOf(v).Elem()
+	for i, f := range fieldsOf(rv.Type()) {
+		if f.encrypt {
+			dest[i] = sqlpp.decryptedDest(table, f.column, dest[i])
+		}
+	}
+
+	return dest
+}
+
+// isKey reports whether f is a primary key column, the id column if none
+// of its struct is tagged pk.
+func (f structField) isKey(hasPK bool) bool {
+	return f.pk || !hasPK && f.column == "id"
+}
+
+// arg returns the value of f in the struct rv as a query arg.
+func (f structField) arg(rv reflect.Value) interface{} {
+	value := rv.FieldByIndex(f.index).Interface()
+	if f.pii {
+		return PII(value)
+	}
+
+	return value
+}
+
+// fieldArg is arg of a row of table, encrypting the field if it's tagged
+// encrypt:"true".
+func (sqlpp *DB) fieldArg(table string, f structField, rv reflect.Value) interface{} {
+	if !f.encrypt {
+		return f.arg(rv)
+	}
+
+	value := sqlpp.encryptedArg(table, f.column, rv.FieldByIndex(f.index).Interface())
+	if f.pii {
+		return PII(value)
+	}
+
+	return value
+}
+
+func hasPK(fields []structField) bool {
+	for _, f := range fields {
+		if f.pk {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldsOf(t reflect.Type) []structField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	fields := appendFields(nil, t, nil)
+	structFields.Store(t, fields)
+	return fields
+}
+
+func appendFields(fields []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			fields = appendFields(fields, f.Type, fieldIndex)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i != -1 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		fields = append(fields, structField{
+			column:  name,
+			index:   fieldIndex,
+			pk:      opts == "pk",
+			pii:     f.Tag.Get("pii") == "true",
+			encrypt: f.Tag.Get("encrypt") == "true",
+		})
+	}
+
+	return fields
+}
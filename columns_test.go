@@ -0,0 +1,43 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type columnsBase struct {
+	ID int64 `db:"id"`
+}
+
+type columnsUser struct {
+	columnsBase
+	Name     string `db:"name"`
+	Email    string
+	Password string `db:"-"`
+	internal int
+}
+
+func TestColumns(t *testing.T) {
+	assert.Equal(t, "`id`, `name`, `email`", Columns[columnsUser](NewMySQL(nil)))
+	assert.Equal(t, `"id", "name", "email"`, NewPostgreSQL(nil).ColumnsOf(&columnsUser{}))
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	mock.ExpectPrepare(`^select "id", "name", "email" from users where id = \$1$`).
+		ExpectQuery().WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "alice", "a@b.c"))
+
+	user, err := GetRow(s, func(rows *sql.Rows) (columnsUser, error) {
+		var u columnsUser
+		return u, rows.Scan(Fields(&u)...)
+	}, "select "+Columns[columnsUser](s)+" from users where id = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, columnsUser{columnsBase: columnsBase{ID: 1}, Name: "alice", Email: "a@b.c"}, user)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
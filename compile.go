@@ -0,0 +1,138 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+)
+
+// CompiledQuery is a query transformed once, holding its own stmt so
+// running it skips the transform and stmt cache lookups. Queries with (?)
+// still expand per call since their shape depends on the args.
+type CompiledQuery struct {
+	db *DB
+
+	query       string
+	transformed string
+	dynamic     bool
+
+	// *compiledStmt
+	cached atomic.Value
+}
+
+type compiledStmt struct {
+	stmt *sql.Stmt
+	err  error
+}
+
+func (sqlpp *DB) Compile(query string) *CompiledQuery {
+	cq := &CompiledQuery{
+		db: sqlpp,
+
+		query:   query,
+		dynamic: strings.Contains(query, "(?)"),
+	}
+
+	if !cq.dynamic {
+		cq.transformed, _ = sqlpp.transform(query, nil)
+	}
+
+	cq.cached.Store(&compiledStmt{})
+	return cq
+}
+
+func (cq *CompiledQuery) stmt(ctx context.Context, query string) (*sql.Stmt, bool, error) {
+	if c := cq.cached.Load().(*compiledStmt); c.stmt != nil || c.err != nil {
+		return c.stmt, false, c.err
+	}
+
+	stmt, prepared, err := cq.db.stmt(ctx, query)
+	if err == nil || cq.db.dialect.prepareNotSupported(err) {
+		cq.cached.Store(&compiledStmt{stmt, err})
+	}
+
+	return stmt, prepared, err
+}
+
+func (cq *CompiledQuery) invalidate(query string, stmt *sql.Stmt) {
+	cq.cached.Store(&compiledStmt{})
+	cq.db.invalidate(query, stmt)
+}
+
+func (cq *CompiledQuery) run(ctx context.Context, e *QueryEvent, fn runFunc) error {
+	if cq.dynamic {
+		return cq.db.run(ctx, e, fn)
+	}
+
+	return cq.db.retry(ctx, func() error {
+		return cq.db.runWith(ctx, e, cq, fn)
+	})
+}
+
+func (cq *CompiledQuery) Exec(args ...interface{}) (sql.Result, error) {
+	return cq.ExecContext(context.Background(), args...)
+}
+func (cq *CompiledQuery) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	ctx, e, err := cq.db.begin(ctx, cq.query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err = cq.run(ctx, e, cq.db.execer(ctx, cq.db.DB, &result))
+	e.Result = result
+	return result, cq.db.end(ctx, e, err)
+}
+
+// Row returns the first row of the query for Scan.
+func (cq *CompiledQuery) Row(args ...interface{}) *Row {
+	return cq.RowContext(context.Background(), args...)
+}
+func (cq *CompiledQuery) RowContext(ctx context.Context, args ...interface{}) *Row {
+	return &Row{func(dest []interface{}) error {
+		return cq.QueryRowContext(ctx, args, dest...)
+	}}
+}
+
+// Select returns the rows of the query parsed by scan.
+func (cq *CompiledQuery) Select(scan Scanner, args ...interface{}) ([]interface{}, error) {
+	return cq.SelectContext(context.Background(), scan, args...)
+}
+func (cq *CompiledQuery) SelectContext(ctx context.Context, scan Scanner, args ...interface{}) ([]interface{}, error) {
+	return cq.QueryContext(ctx, args, scan)
+}
+
+// Deprecated: use Row.
+func (cq *CompiledQuery) QueryRow(args []interface{}, dest ...interface{}) error {
+	return cq.QueryRowContext(context.Background(), args, dest...)
+}
+
+// Deprecated: use RowContext.
+func (cq *CompiledQuery) QueryRowContext(ctx context.Context, args []interface{}, dest ...interface{}) error {
+	ctx, e, err := cq.db.begin(ctx, cq.query, args)
+	if err != nil {
+		return err
+	}
+
+	err = cq.run(ctx, e, cq.db.rowScanner(ctx, cq.db.DB, dest))
+	e.returned = dest
+	return cq.db.end(ctx, e, err)
+}
+
+// Deprecated: use Select.
+func (cq *CompiledQuery) Query(args []interface{}, scan Scanner) ([]interface{}, error) {
+	return cq.QueryContext(context.Background(), args, scan)
+}
+
+// Deprecated: use SelectContext.
+func (cq *CompiledQuery) QueryContext(ctx context.Context, args []interface{}, scan Scanner) ([]interface{}, error) {
+	return cq.db.cached(ctx, cq.query, args, func() ([]interface{}, error) {
+		ctx, e, err := cq.db.begin(ctx, cq.query, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return cq.db.query(ctx, e, cq.db.DB, scan, cq.run)
+	})
+}
@@ -0,0 +1,99 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompiledQuery(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New()
+	pDb, pMock, pErr := sqlmock.New()
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	for _, mock := range []sqlmock.Sqlmock{mMock, pMock} {
+		p := mock.ExpectPrepare("^update foo set i = (.+) where j = (.+)$")
+		p.ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		p.ExpectExec().WithArgs(3, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+		p.ExpectQuery().WithArgs(5, 6).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(7))
+
+		mock.ExpectPrepare("^select (.+) from foo where i in (.+)$").
+			ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	}
+
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	for _, s := range []*DB{sm, sp} {
+		cq := s.Compile("update foo set i = ? where j = ?")
+		_, err := cq.Exec(1, 2)
+		assert.Nil(t, err)
+		_, err = cq.Exec(3, 4)
+		assert.Nil(t, err)
+
+		var i int
+		assert.Nil(t, cq.QueryRow(s.Args(5, 6), &i))
+		assert.Equal(t, 7, i)
+
+		results, err := s.Compile("select * from foo where i in (?)").Query(s.Args([]int{1, 2}), scanner)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{1, 2}, results)
+	}
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
+
+func TestCompiledQuery_invalidate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	cq := s.Compile("select * from foo")
+
+	mock.ExpectPrepare("^select (.+) from foo$").WillBeClosed().
+		ExpectExec().WillReturnError(errors.New("Error 1615: Prepared statement needs to be re-prepared"))
+	p := mock.ExpectPrepare("^select (.+) from foo$")
+	p.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	p.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = cq.Exec()
+	assert.Nil(t, err)
+	_, err = cq.Exec()
+	assert.Nil(t, err)
+
+	cached, _ := s.stmts.Load("select * from foo")
+	assert.Equal(t, cached, cq.cached.Load().(*compiledStmt).stmt)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCompiledQuery_checks(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewCockroachDB(db, WithRetry(1, time.Millisecond))
+	cq := s.Compile("update t set a = 1")
+
+	retry := sqlStateError("40001")
+	stmt := mock.ExpectPrepare("update t set a = 1")
+	stmt.ExpectExec().WillReturnError(retry)
+	stmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = cq.Exec()
+	assert.Nil(t, err)
+
+	_, err = s.Clone(WithPolicy(ReadOnly())).Compile("update t set a = 1").Exec()
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
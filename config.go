@@ -0,0 +1,103 @@
+package sqlpp
+
+import "time"
+
+// config holds the settings SetOption can change while queries run.
+type config struct {
+	// client-side interpolation instead of server-side prepare, or only
+	// server-side prepare
+	interpolate bool
+	strict      bool
+	// queries bind their args without a cached stmt
+	direct bool
+
+	// bound parameter size limits
+	maxArgSize  int
+	maxArgsSize int
+
+	// max size of WriteBlob
+	maxBlobSize int64
+
+	// initial capacity of query results
+	rowsCapacity int
+
+	// max concurrent queries of QueryParallel
+	parallelism int
+
+	// timeout of queries run without a deadline, and the portion of the
+	// caller's deadline kept from queries
+	queryTimeout    time.Duration
+	deadlineReserve float64
+
+	// opt-in query result cache, its keys prefixed by the namespace
+	resultCache    ResultCache
+	cacheNamespace string
+
+	// max cached stmts
+	maxStmts int64
+
+	hooks []Hook
+
+	// statement restrictions
+	policies []Policy
+
+	// deleted_at column of soft deleted rows
+	softDelete string
+
+	// AsOf reads FOR SYSTEM_TIME instead of from history tables
+	systemVersioning bool
+
+	// ids of Insert
+	ids IDGenerator
+
+	// encrypts the struct fields tagged encrypt:"true"
+	cipher *Cipher
+
+	// ExecResult reads a ConsistencyToken
+	consistencyTokens bool
+
+	// nested slice args fill the (?) of their outer slice
+	flattenNested bool
+
+	// slice args fill their (?) as one array in UNNEST
+	unnest bool
+
+	// retries of statements failing with a retryable error
+	retries      int
+	retryBackoff time.Duration
+
+	// server features, nil until detected
+	capabilities *Capabilities
+
+	// concurrency limit, and the priority of queries without one
+	limiter  *limiter
+	priority Priority
+}
+
+func (sqlpp *DB) config() *config {
+	return sqlpp.current.Load().(*config)
+}
+
+func (c *config) clone() *config {
+	clone := *c
+	clone.hooks = append([]Hook(nil), c.hooks...)
+	clone.policies = append([]Policy(nil), c.policies...)
+	return &clone
+}
+
+// SetOption applies opts to the db while it runs, queries started after it
+// returns use the new settings, e.g. WithResultCache(nil) turns the result
+// cache off. WithAsyncWorkers is ignored as the worker pool is already
+// running.
+func (sqlpp *DB) SetOption(opts ...Option) {
+	sqlpp.configMu.Lock()
+	defer sqlpp.configMu.Unlock()
+
+	// options write to the cfg of the db they get
+	scratch := &DB{DB: sqlpp.DB, dialect: sqlpp.dialect, cfg: sqlpp.config().clone()}
+	for _, opt := range opts {
+		opt(scratch)
+	}
+
+	sqlpp.current.Store(scratch.cfg)
+}
@@ -0,0 +1,49 @@
+package sqlpp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_SetOption(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+
+	cache := NewLRUCache(0)
+	s := NewMySQL(db, WithResultCache(cache), WithRowsCapacity(4))
+	before := s.config()
+
+	for i := 0; i < 20; i++ {
+		mock.ExpectExec("^update foo set i = 1$").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.ExecContext(Interpolate(context.Background()), "update foo set i = ?", 1)
+			assert.Nil(t, err)
+		}()
+	}
+
+	s.SetOption(WithResultCache(nil), WithDefaultQueryTimeout(time.Second), WithInterpolation())
+	wg.Wait()
+
+	cfg := s.config()
+	assert.Nil(t, cfg.resultCache)
+	assert.Equal(t, time.Second, cfg.queryTimeout)
+	assert.True(t, cfg.interpolate)
+	assert.Equal(t, 4, cfg.rowsCapacity)
+
+	// running queries keep the settings they started with
+	assert.Equal(t, cache, before.resultCache)
+	assert.Equal(t, time.Duration(0), before.queryTimeout)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,154 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+)
+
+// Conn runs queries on a single connection of the pool, for work relying
+// on session state like temp tables, session variables, LAST_INSERT_ID or
+// advisory locks. Its stmts are prepared on the connection and closed with
+// it. Query doesn't read through the result cache as results may depend on
+// the session.
+type Conn struct {
+	db   *DB
+	conn *sql.Conn
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// WithConn calls fn with a connection taken from the pool, returning it
+// after fn returns.
+func (sqlpp *DB) WithConn(ctx context.Context, fn func(c *Conn) error) error {
+	conn, err := sqlpp.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	c := &Conn{db: sqlpp, conn: conn, stmts: map[string]*sql.Stmt{}}
+	defer c.close()
+
+	return fn(c)
+}
+
+// Raw returns the connection for calls sqlpp doesn't cover.
+func (c *Conn) Raw() *sql.Conn {
+	return c.conn
+}
+
+func (c *Conn) close() {
+	c.mu.Lock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmts = nil
+	c.mu.Unlock()
+
+	c.conn.Close()
+}
+
+func (c *Conn) stmt(ctx context.Context, query string) (*sql.Stmt, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, false, nil
+	}
+
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, true, err
+	}
+
+	c.stmts[query] = stmt
+	return stmt, true, nil
+}
+
+func (c *Conn) invalidate(query string, stmt *sql.Stmt) {
+	c.mu.Lock()
+	if c.stmts[query] == stmt {
+		delete(c.stmts, query)
+	}
+	c.mu.Unlock()
+
+	stmt.Close()
+}
+
+func (c *Conn) run(ctx context.Context, e *QueryEvent, fn runFunc) error {
+	return c.db.runWith(ctx, e, c, fn)
+}
+
+func (c *Conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+func (c *Conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, e, err := c.db.begin(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err = c.run(ctx, e, c.db.execer(ctx, c.conn, &result))
+	e.Result = result
+	return result, c.db.end(ctx, e, err)
+}
+
+func (c *Conn) Row(query string, args ...interface{}) *Row {
+	return c.RowContext(context.Background(), query, args...)
+}
+func (c *Conn) RowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{func(dest []interface{}) error {
+		return c.QueryRowContext(ctx, query, args, dest...)
+	}}
+}
+
+func (c *Conn) Select(scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	return c.SelectContext(context.Background(), scan, query, args...)
+}
+func (c *Conn) SelectContext(ctx context.Context, scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	return c.QueryContext(ctx, query, args, scan)
+}
+
+// Deprecated: use Row.
+func (c *Conn) QueryRow(query string, args []interface{}, dest ...interface{}) error {
+	return c.QueryRowContext(context.Background(), query, args, dest...)
+}
+
+// Deprecated: use RowContext.
+func (c *Conn) QueryRowContext(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
+	ctx, e, err := c.db.begin(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	err = c.run(ctx, e, c.db.rowScanner(ctx, c.conn, dest))
+	e.returned = dest
+	return c.db.end(ctx, e, err)
+}
+
+// Deprecated: use Select.
+func (c *Conn) Query(query string, args []interface{}, scan Scanner) ([]interface{}, error) {
+	return c.QueryContext(context.Background(), query, args, scan)
+}
+
+// Deprecated: use SelectContext.
+func (c *Conn) QueryContext(ctx context.Context, query string, args []interface{}, scan Scanner) ([]interface{}, error) {
+	ctx, e, err := c.db.begin(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.db.query(ctx, e, c.conn, scan, c.run)
+}
+
+// discardConn closes conn without returning it to the pool, for sessions
+// left holding state like a lock that failed to release.
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
@@ -0,0 +1,58 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_WithConn(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	prepared := mock.ExpectPrepare(`^insert into tmp set i = \?$`)
+	prepared.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	prepared.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectPrepare(`^select i from tmp where i in \(\?,\?\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery(`^select last_insert_id\(\)$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	prepared.WillBeClosed()
+
+	ctx := context.Background()
+	err = s.WithConn(ctx, func(c *Conn) error {
+		for i := 1; i <= 2; i++ {
+			if _, err := c.Exec("insert into tmp set i = ?", i); err != nil {
+				return err
+			}
+		}
+
+		results, err := c.Select(func(rows *sql.Rows) (interface{}, error) {
+			var i int
+			return i, rows.Scan(&i)
+		}, "select i from tmp where i in (?)", []int{1, 2})
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []interface{}{1, 2}, results)
+
+		var id int
+		if err := c.RowContext(Interpolate(ctx), "select last_insert_id()").Scan(&id); err != nil {
+			return err
+		}
+		assert.Equal(t, 2, id)
+		assert.NotNil(t, c.Raw())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), s.CacheStats().Stmts, "conn stmts stay out of the pool cache")
+
+	errFn := errors.New("fn")
+	assert.Equal(t, errFn, s.WithConn(ctx, func(c *Conn) error { return errFn }))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
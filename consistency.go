@@ -0,0 +1,96 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTokenTimeout = errors.New("sqlpp: replica not caught up to token")
+)
+
+// tokenPollInterval is how often WaitForToken checks a postgres replica.
+var tokenPollInterval = 10 * time.Millisecond
+
+// WithConsistencyTokens makes ExecResult set the Token of its Result, for
+// a read on a replica to WaitForToken.
+func WithConsistencyTokens() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.consistencyTokens = true
+	}
+}
+
+// ConsistencyToken returns a token covering the writes committed on the
+// db so far, the executed gtid set on mysql and the wal lsn on postgres.
+func (sqlpp *DB) ConsistencyToken(ctx context.Context) (string, error) {
+	if err := sqlpp.helperSQL(); err != nil {
+		return "", err
+	} else if err := sqlpp.helperFeature(sqlpp.dialect.walLSN); err != nil {
+		return "", err
+	}
+
+	query := "SELECT @@GLOBAL.gtid_executed"
+	if sqlpp.dialect.flavor == postgresFlavor {
+		query = "SELECT pg_current_wal_lsn()::text"
+	}
+
+	var token string
+	err := sqlpp.RowContext(ctx, query).Scan(&token)
+	return token, err
+}
+
+// WaitForToken waits until the replica db has applied the writes of token,
+// failing with ErrTokenTimeout if ctx is done first. A postgres primary
+// has them already.
+func (sqlpp *DB) WaitForToken(ctx context.Context, token string) error {
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	} else if err := sqlpp.helperFeature(sqlpp.dialect.walLSN); err != nil {
+		return err
+	}
+
+	if sqlpp.dialect.flavor == postgresFlavor {
+		return sqlpp.pollToken(ctx, token)
+	}
+
+	// without a timeout mysql waits forever, the single ? is spaced apart
+	// as (?) expands a slice
+	query, args := "SELECT WAIT_FOR_EXECUTED_GTID_SET( ? )", []interface{}{token}
+	if deadline, ok := ctx.Deadline(); ok {
+		query, args = "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", append(args, time.Until(deadline).Seconds())
+	}
+
+	var timedOut int
+	if err := sqlpp.RowContext(ctx, query, args...).Scan(&timedOut); err != nil {
+		return tokenTimeout(ctx, err)
+	} else if timedOut != 0 {
+		return ErrTokenTimeout
+	}
+
+	return nil
+}
+
+func (sqlpp *DB) pollToken(ctx context.Context, token string) error {
+	for {
+		var applied bool
+		err := sqlpp.RowContext(ctx, "SELECT COALESCE(pg_last_wal_replay_lsn() >= ?::pg_lsn, TRUE)", token).Scan(&applied)
+		if err != nil || applied {
+			return tokenTimeout(ctx, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ErrTokenTimeout
+		case <-time.After(tokenPollInterval):
+		}
+	}
+}
+
+func tokenTimeout(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ErrTokenTimeout
+	}
+
+	return err
+}
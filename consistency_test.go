@@ -0,0 +1,68 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_ConsistencyToken(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db, WithConsistencyTokens())
+	p := NewPostgreSQL(db, WithConsistencyTokens())
+	gtid := "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"
+
+	mock.ExpectPrepare("update users set name = ? where id = ?").
+		ExpectExec().WithArgs("a", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("SELECT @@GLOBAL.gtid_executed").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"gtid"}).AddRow(gtid))
+	mock.ExpectPrepare("update users set name = $1 where id = $2").
+		ExpectExec().WithArgs("b", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("SELECT pg_current_wal_lsn()::text").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/16B3748"))
+
+	result, err := m.ExecResultContext(ctx, "update users set name = ? where id = ?", "a", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, Result{RowsAffected: 1, Token: gtid}, result)
+
+	result, err = p.ExecResultContext(ctx, "update users set name = ? where id = ?", "b", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "0/16B3748", result.Token)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_WaitForToken(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+	gtid := "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"
+
+	mock.ExpectPrepare("SELECT WAIT_FOR_EXECUTED_GTID_SET( ? )").ExpectQuery().WithArgs(gtid).
+		WillReturnRows(sqlmock.NewRows([]string{"wait"}).AddRow(0))
+	mock.ExpectPrepare("SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)").ExpectQuery().WithArgs(gtid, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"wait"}).AddRow(1))
+
+	assert.Nil(t, m.WaitForToken(context.Background(), gtid))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Equal(t, ErrTokenTimeout, m.WaitForToken(ctx, gtid))
+
+	poll := "SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, TRUE)"
+	mock.ExpectPrepare(poll).ExpectQuery().WithArgs("0/16B3748").WillReturnRows(sqlmock.NewRows([]string{"applied"}).AddRow(false))
+	mock.ExpectQuery(poll).WithArgs("0/16B3748").WillReturnRows(sqlmock.NewRows([]string{"applied"}).AddRow(true))
+	mock.ExpectQuery(poll).WithArgs("0/16B3748").WillReturnRows(sqlmock.NewRows([]string{"applied"}).AddRow(false))
+
+	assert.Nil(t, p.WaitForToken(context.Background(), "0/16B3748"))
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, ErrTokenTimeout, p.WaitForToken(ctx, "0/16B3748"))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
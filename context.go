@@ -0,0 +1,47 @@
+package sqlpp
+
+import "context"
+
+type contextKey int
+
+const (
+	interpolateKey contextKey = iota
+	capacityKey
+	cacheKey
+	tenantKey
+	actorKey
+	priorityKey
+	deletedKey
+	asOfKey
+	nPlusOneKey
+	coalesceKey
+)
+
+// Interpolate makes queries run with ctx interpolate their arguments
+// client-side instead of using a server-side prepared statement.
+func Interpolate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, interpolateKey, true)
+}
+
+func (sqlpp *DB) interpolates(ctx context.Context) bool {
+	if sqlpp.config().interpolate {
+		return true
+	}
+
+	interpolate, _ := ctx.Value(interpolateKey).(bool)
+	return interpolate
+}
+
+// ExpectRows hints that queries run with ctx return about n rows, so Query
+// allocates its results once. Past n the results grow like append.
+func ExpectRows(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, capacityKey, n)
+}
+
+func (sqlpp *DB) capacity(ctx context.Context) int {
+	if n, ok := ctx.Value(capacityKey).(int); ok && n > 0 {
+		return n
+	}
+
+	return sqlpp.config().rowsCapacity
+}
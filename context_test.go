@@ -0,0 +1,30 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_capacity(t *testing.T) {
+	d := NewMySQL(nil)
+	o := NewMySQL(nil, WithRowsCapacity(8))
+	ctx := context.Background()
+
+	assert.Equal(t, 0, d.capacity(ctx))
+	assert.Equal(t, 8, o.capacity(ctx))
+	assert.Equal(t, 32, d.capacity(ExpectRows(ctx, 32)))
+	assert.Equal(t, 32, o.capacity(ExpectRows(ctx, 32)))
+	assert.Equal(t, 8, o.capacity(ExpectRows(ctx, -1)))
+}
+
+func TestDB_interpolates(t *testing.T) {
+	d := NewMySQL(nil)
+	o := NewMySQL(nil, WithInterpolation())
+	ctx := context.Background()
+
+	assert.False(t, d.interpolates(ctx))
+	assert.True(t, o.interpolates(ctx))
+	assert.True(t, d.interpolates(Interpolate(ctx)))
+}
@@ -0,0 +1,478 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownDialect = errors.New("sqlpp: unknown dialect")
+)
+
+// Dialect is what sqlpp needs to know about a database to run queries on
+// it. Implementing StmtInvalidated(error) bool as well re-prepares and
+// retries once the stmts failing with the errors it matches, and
+// Retryable(error) bool is what WithRetry retries. Literals interpolate
+// as they do on mysql.
+type Dialect interface {
+	// Placeholder returns the prefix of numbered placeholders, e.g. $ for
+	// $1, $2, or an empty string to keep the ? placeholders.
+	Placeholder() string
+	// QuoteIdent quotes a table or column name, schema.table is quoted by
+	// parts.
+	QuoteIdent(ident string) string
+	// PrepareNotSupported reports whether err is the db refusing to
+	// prepare a query it can run directly.
+	PrepareNotSupported(err error) bool
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]*dialect{}
+)
+
+func init() {
+	for _, d := range []*dialect{mysqlDialect, postgresDialect, sqliteDialect, sqlserverDialect, oracleDialect, cockroachDialect,
+		snowflakeDialect, bigqueryDialect, spannerDialect, redshiftDialect, duckdbDialect,
+		trinoDialect, tidbDialect, verticaDialect, hanaDialect} {
+		dialects[d.name] = d
+	}
+
+	dialects["presto"] = trinoDialect
+}
+
+// RegisterDialect makes d available to New as name, replacing the one
+// registered as name before. It's meant to be called at init.
+func RegisterDialect(name string, d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	dialects[name] = dialectOf(name, d)
+}
+
+// LookupDialect returns the dialect registered as name, e.g. for
+// registering a built-in one under the name of another driver.
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	d, ok := dialects[name]
+	return d, ok
+}
+
+// New wraps db with the dialect registered as name. mysql, postgres,
+// sqlite, sqlserver, oracle, cockroachdb, snowflake, bigquery, spanner,
+// redshift, duckdb, trino, also as presto, tidb, vertica and hana are built
+// in.
+func New(db *sql.DB, name string, opts ...Option) (*DB, error) {
+	dialectsMu.RLock()
+	d, ok := dialects[name]
+	dialectsMu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDialect
+	}
+
+	return new(db, d, opts), nil
+}
+
+// dialectOf returns the dialect of d, keeping the literals and limits of
+// a built-in one registered under another name.
+func dialectOf(name string, d Dialect) *dialect {
+	if builtin, ok := d.(*dialect); ok {
+		return builtin
+	}
+
+	custom := &dialect{
+		name:                name,
+		numbered:            d.Placeholder(),
+		lastInsertID:        true,
+		backslashEscapes:    true,
+		bytesFormat:         "X'%s'",
+		maxParams:           65535,
+		version:             "SELECT VERSION()",
+		quoteIdent:          d.QuoteIdent,
+		prepareNotSupported: d.PrepareNotSupported,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+
+	if s, ok := d.(interface{ StmtInvalidated(error) bool }); ok {
+		custom.stmtInvalidated = s.StmtInvalidated
+	}
+
+	if r, ok := d.(interface{ Retryable(error) bool }); ok {
+		custom.retryable = r.Retryable
+	}
+
+	return custom
+}
+
+// dialect is what the query path needs to know about a database: how it
+// numbers placeholders, quotes names and literals, and which driver errors
+// it reacts to.
+type dialect struct {
+	name string
+
+	// prefix of numbered placeholders, e.g. $ for $1 or @p for @p1, empty
+	// for ?
+	numbered string
+	// string literals may treat backslash as an escape, on mysql by
+	// default and on postgres without standard_conforming_strings
+	backslashEscapes bool
+	// quotes escape as \' instead of '', on bigquery, spanner and redshift
+	backslashQuotes bool
+	// interpolated literals: bytes by a hex format like X'%s', booleans as
+	// 1 and 0 without TRUE and FALSE, and the prefixes of string and
+	// timestamp literals like N'' and TIMESTAMP ''
+	bytesFormat  string
+	bitBooleans  bool
+	stringPrefix string
+	timePrefix   string
+	// postgres literals: escape strings like E'\n' for backslashes, and
+	// timestamps keeping their zone
+	escapeStrings bool
+	zonedTimes    bool
+	// capabilities of a recent server: INSERT ... RETURNING and SELECT ...
+	// FOR UPDATE SKIP LOCKED
+	returning  bool
+	skipLocked bool
+	// postgres sql of the helpers: session advisory locks, OVERRIDING
+	// SYSTEM VALUE inserts moving the serial sequence past their ids,
+	// TRUNCATE ... RESTART IDENTITY CASCADE and the wal lsn
+	advisoryLocks    bool
+	identityOverride bool
+	truncateRestart  bool
+	walLSN           bool
+	// the driver's result has a LastInsertId
+	lastInsertID bool
+	// sql of the helpers building queries, like the locks and queues, none
+	// for the dialects they don't support
+	flavor flavor
+	// placeholders in a statement, and elements in an IN list, larger
+	// slices split to several lists
+	maxParams int
+	maxInList int
+	// slices expanding over maxParams fail before the query is built
+	checkParams bool
+	// query reading the server version
+	version string
+
+	quoteIdent func(ident string) string
+	// the query can't be prepared and runs directly instead
+	prepareNotSupported func(err error) bool
+	// queries run directly by default, without a cached stmt, or always
+	// as the driver can't prepare
+	direct    bool
+	noPrepare bool
+	// the stmt was invalidated by a schema change and needs a re-prepare
+	stmtInvalidated func(err error) bool
+	// a pointer to a slice binds as an array filling a (?)
+	arrayBinds bool
+	// args bind as sql.Named values named as their placeholders
+	namedArgs bool
+	// the statement failed on a conflict and succeeds run again, retried
+	// this many times by default
+	retryable func(err error) bool
+	retries   int
+}
+
+var (
+	mysqlDialect = &dialect{
+		name:                "mysql",
+		backslashEscapes:    true,
+		bytesFormat:         "X'%s'",
+		maxParams:           65535,
+		version:             "SELECT VERSION()",
+		skipLocked:          true,
+		flavor:              mysqlFlavor,
+		lastInsertID:        true,
+		quoteIdent:          mysqlQuoteIdent,
+		prepareNotSupported: isMysqlPrepareNotSupported,
+		stmtInvalidated:     isMysqlNeedsReprepare,
+		retryable:           never,
+	}
+
+	tidbDialect = &dialect{
+		name:                "tidb",
+		backslashEscapes:    true,
+		bytesFormat:         "X'%s'",
+		maxParams:           65535,
+		version:             "SELECT VERSION()",
+		flavor:              mysqlFlavor,
+		lastInsertID:        true,
+		quoteIdent:          mysqlQuoteIdent,
+		prepareNotSupported: isTiDBPrepareNotSupported,
+		stmtInvalidated:     isMysqlNeedsReprepare,
+		retryable:           isTiDBRetryable,
+		retries:             3,
+	}
+
+	postgresDialect = &dialect{
+		name:                "postgres",
+		numbered:            "$",
+		backslashEscapes:    true,
+		bytesFormat:         "decode('%s','hex')",
+		escapeStrings:       true,
+		zonedTimes:          true,
+		returning:           true,
+		skipLocked:          true,
+		advisoryLocks:       true,
+		identityOverride:    true,
+		truncateRestart:     true,
+		walLSN:              true,
+		maxParams:           65535,
+		version:             "SELECT current_setting('server_version')",
+		flavor:              postgresFlavor,
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: isMysqlPrepareNotSupported,
+		stmtInvalidated:     isPostgresCachedPlanChanged,
+		retryable:           isPostgresRetryable,
+	}
+
+	cockroachDialect = &dialect{
+		name:                "cockroachdb",
+		numbered:            "$",
+		backslashEscapes:    true,
+		bytesFormat:         "decode('%s','hex')",
+		escapeStrings:       true,
+		zonedTimes:          true,
+		returning:           true,
+		skipLocked:          true,
+		maxParams:           65535,
+		version:             "SELECT version()",
+		flavor:              postgresFlavor,
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		stmtInvalidated:     isPostgresCachedPlanChanged,
+		retryable:           isPostgresRetryable,
+		retries:             3,
+	}
+
+	sqliteDialect = &dialect{
+		name:                "sqlite",
+		bytesFormat:         "X'%s'",
+		maxParams:           32766,
+		version:             "SELECT sqlite_version()",
+		lastInsertID:        true,
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		stmtInvalidated:     isSQLiteSchemaChanged,
+		retryable:           never,
+	}
+
+	sqlserverDialect = &dialect{
+		name:                "sqlserver",
+		numbered:            "@p",
+		bytesFormat:         "0x%s",
+		bitBooleans:         true,
+		stringPrefix:        "N",
+		maxParams:           2100,
+		version:             "SELECT CAST(SERVERPROPERTY('ProductVersion') AS nvarchar(128))",
+		quoteIdent:          sqlserverQuoteIdent,
+		prepareNotSupported: isSQLServerPrepareNotSupported,
+		stmtInvalidated:     isSQLServerStmtNotFound,
+		retryable:           never,
+	}
+
+	oracleDialect = &dialect{
+		name:                "oracle",
+		numbered:            ":",
+		bytesFormat:         "HEXTORAW('%s')",
+		bitBooleans:         true,
+		stringPrefix:        "N",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           65535,
+		maxInList:           1000,
+		version:             "SELECT version FROM product_component_version WHERE product LIKE 'Oracle%' AND ROWNUM = 1",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		stmtInvalidated:     isOraclePackageStateDiscarded,
+		retryable:           never,
+	}
+
+	snowflakeDialect = &dialect{
+		name:                "snowflake",
+		backslashEscapes:    true,
+		bytesFormat:         "X'%s'",
+		maxParams:           65535,
+		version:             "SELECT CURRENT_VERSION()",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: isSnowflakePrepareNotSupported,
+		stmtInvalidated:     never,
+		arrayBinds:          true,
+		retryable:           never,
+	}
+
+	bigqueryDialect = &dialect{
+		name:                "bigquery",
+		numbered:            "@p",
+		backslashEscapes:    true,
+		backslashQuotes:     true,
+		bytesFormat:         "FROM_HEX('%s')",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           10000,
+		version:             "SELECT 'bigquery'", // no version to read
+		quoteIdent:          bigqueryQuoteIdent,
+		prepareNotSupported: never,
+		stmtInvalidated:     never,
+		namedArgs:           true,
+		retryable:           never,
+	}
+
+	redshiftDialect = &dialect{
+		name:                "redshift",
+		numbered:            "$",
+		backslashEscapes:    true,
+		backslashQuotes:     true,
+		bytesFormat:         "FROM_HEX('%s')",
+		zonedTimes:          true,
+		maxParams:           32767,
+		maxInList:           1000,
+		version:             "SELECT version()",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		direct:              true,
+		stmtInvalidated:     isPostgresCachedPlanChanged,
+		retryable:           isPostgresRetryable,
+	}
+
+	duckdbDialect = &dialect{
+		name:                "duckdb",
+		bytesFormat:         "from_hex('%s')",
+		maxParams:           65535,
+		version:             "SELECT version()",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: isDuckDBPrepareNotSupported,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+
+	trinoDialect = &dialect{
+		name:                "trino",
+		bytesFormat:         "X'%s'",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           65535,
+		version:             "SELECT version()",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		noPrepare:           true,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+
+	verticaDialect = &dialect{
+		name:                "vertica",
+		bytesFormat:         "HEX_TO_BINARY('%s')",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           65535,
+		version:             "SELECT version()",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: isVerticaPrepareNotSupported,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+
+	hanaDialect = &dialect{
+		name:                "hana",
+		bytesFormat:         "X'%s'",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           32767,
+		checkParams:         true,
+		version:             "SELECT VERSION FROM SYS.M_DATABASE",
+		quoteIdent:          postgresQuoteIdent,
+		prepareNotSupported: never,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+
+	spannerDialect = &dialect{
+		name:                "spanner",
+		numbered:            "@param",
+		backslashEscapes:    true,
+		backslashQuotes:     true,
+		bytesFormat:         "FROM_HEX('%s')",
+		timePrefix:          "TIMESTAMP ",
+		maxParams:           950,
+		version:             "SELECT 'spanner'", // no version to read
+		quoteIdent:          bigqueryQuoteIdent,
+		prepareNotSupported: isSpannerPrepareNotSupported,
+		stmtInvalidated:     never,
+		retryable:           never,
+	}
+)
+
+// flavor is the sql the helpers build, mysql for mysql and tidb, postgres
+// for postgres and cockroach, which lacks advisory locks, identity
+// inserts, TRUNCATE ... RESTART IDENTITY and the wal lsn. Redshift has
+// none, lacking ON CONFLICT, identity inserts, sequences and ctid.
+type flavor int
+
+const (
+	noFlavor flavor = iota
+	mysqlFlavor
+	postgresFlavor
+)
+
+// helperSQL fails with ErrNotSupported on the dialects the helpers
+// building mysql or postgres sql don't support.
+func (sqlpp *DB) helperSQL() error {
+	if sqlpp.dialect.flavor == noFlavor {
+		return fmt.Errorf("%w: %s", ErrNotSupported, sqlpp.dialect.name)
+	}
+
+	return nil
+}
+
+// helperFeature fails with ErrNotSupported on the postgres dialects
+// lacking the feature the helper's postgres sql needs.
+func (sqlpp *DB) helperFeature(supported bool) error {
+	if sqlpp.dialect.flavor == postgresFlavor && !supported {
+		return fmt.Errorf("%w: %s", ErrNotSupported, sqlpp.dialect.name)
+	}
+
+	return nil
+}
+
+func never(error) bool {
+	return false
+}
+
+func (d *dialect) Placeholder() string {
+	return d.numbered
+}
+
+func (d *dialect) QuoteIdent(ident string) string {
+	return d.quoteIdent(ident)
+}
+
+func (d *dialect) PrepareNotSupported(err error) bool {
+	return d.prepareNotSupported(err)
+}
+
+func (d *dialect) StmtInvalidated(err error) bool {
+	return d.stmtInvalidated(err)
+}
+
+func (d *dialect) Retryable(err error) bool {
+	return d.retryable(err)
+}
+
+// backslashStrings reports whether a backslash escapes a quote in a
+// string literal, on postgres only in escape strings like E'\n'.
+func (d *dialect) backslashStrings() bool {
+	return d.backslashQuotes || d.backslashEscapes && !d.escapeStrings
+}
+
+// QuoteIdent quotes a table or column name, quoting each part of a
+// qualified name like schema.table separately.
+func (sqlpp *DB) QuoteIdent(ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		parts[i] = sqlpp.dialect.quoteIdent(part)
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,214 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type colonDialect struct{}
+
+func (colonDialect) Placeholder() string {
+	return ":p"
+}
+
+func (colonDialect) QuoteIdent(ident string) string {
+	return "<" + ident + ">"
+}
+
+func (colonDialect) PrepareNotSupported(err error) bool {
+	return err != nil && err.Error() == "no prepare"
+}
+
+func (colonDialect) StmtInvalidated(err error) bool {
+	return err != nil && err.Error() == "stale"
+}
+
+func TestNew(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	_, err = New(db, "unknown")
+	assert.Equal(t, ErrUnknownDialect, err)
+
+	s, err := New(db, "postgres")
+	assert.Nil(t, err)
+	assert.True(t, s.PostgreSQL())
+	assert.Equal(t, "postgres", s.Dialect())
+
+	s, err = New(db, "cockroachdb")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, s.config().retries)
+
+	pg, ok := LookupDialect("postgres")
+	assert.True(t, ok)
+	RegisterDialect("pgx", pg)
+	s, err = New(db, "pgx")
+	assert.Nil(t, err)
+	assert.True(t, s.PostgreSQL())
+
+	RegisterDialect("colon", colonDialect{})
+	s, err = New(db, "colon")
+	assert.Nil(t, err)
+	assert.Equal(t, "colon", s.Dialect())
+	assert.Equal(t, "<foo>.<bar>", s.QuoteIdent("foo.bar"))
+
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = :p1 and b in (:p2,:p3)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	literal, err := s.interpolation("select ?, ?", []interface{}{1, "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, "select 1, 'a'", literal)
+
+	mock.ExpectPrepare("create view foo as select :p1").WillReturnError(errors.New("no prepare"))
+	mock.ExpectExec("create view foo as select :p1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("create view foo as select ?", 1)
+	assert.Nil(t, err)
+
+	mock.ExpectPrepare("update foo set a = :p1").WillBeClosed().
+		ExpectExec().WithArgs(1).WillReturnError(errors.New("stale"))
+	mock.ExpectPrepare("update foo set a = :p1").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestNewRedshift(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewRedshift(db)
+	d := *redshiftDialect
+	d.maxInList = 2
+	s.dialect = &d
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3, 4})
+	assert.Equal(t, "select * from foo where a = $1 and (b in ($2,$3) OR b in ($4))", query)
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, args)
+
+	literal, err := s.interpolation("select ?", []interface{}{`it's \`})
+	assert.Nil(t, err)
+	assert.Equal(t, `select 'it\'s \\'`, literal)
+
+	assert.False(t, s.PostgreSQL())
+	assert.False(t, s.Capabilities().Returning)
+	assert.False(t, s.Capabilities().SkipLocked)
+
+	ctx := context.Background()
+	_, err = s.NewLock("foo").TryLock(ctx)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.True(t, errors.Is(s.InsertFixtures(ctx, Fixture{Table: "foo"}), ErrNotSupported))
+	assert.True(t, errors.Is(s.ResetTables(ctx, "foo"), ErrNotSupported))
+	_, err = s.ConsistencyToken(ctx)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	_, err = s.PurgeOldRows(ctx, "foo", "created < 1", 10, 0)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+
+	// runs directly without preparing
+	mock.ExpectExec("update foo set a = $1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	s.SetOption(WithDirect(false))
+	mock.ExpectPrepare("update foo set a = $1").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestNewTrino(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s, err := New(db, "presto", WithDirect(false))
+	assert.Nil(t, err)
+	assert.Equal(t, "trino", s.Dialect())
+
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = ? and b in (?,?)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	// never prepares, not even with WithDirect(false)
+	mock.ExpectQuery("select a from foo where b in (?,?)").WithArgs(2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	var a int
+	err = s.QueryRow("select a from foo where b in (?)", []interface{}{[]int{2, 3}}, &a)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, a)
+
+	stmt, err := s.Prepare("update foo set a = ?")
+	assert.Nil(t, err)
+	mock.ExpectExec("update foo set a = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = stmt.Exec(1)
+	assert.Nil(t, err)
+	assert.Nil(t, stmt.Close())
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestNewHANA(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewHANA(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = ? and b in (?,?)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	// fails before building the query
+	_, err = s.Exec("delete from foo where a = ? and b in (?) and c = ?", 1, make([]int, 32767), 2)
+	assert.True(t, errors.Is(err, ErrTooManyParams))
+	assert.Equal(t, &TooManyParamsError{Index: 1, Params: 32769, Limit: 32767}, err)
+	assert.Equal(t, "sqlpp: too many placeholders: slice argument 1 expands the query to 32769, over the 32767 limit", err.Error())
+
+	mock.ExpectPrepare("delete from foo where b in (?,?)").
+		ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = s.Exec("delete from foo where b in (?)", []int{1, 2})
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_helperSQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	for _, s := range []*DB{NewSQLite(db), NewSQLServer(db), NewOracle(db), NewSnowflakeDB(db), NewBigQuery(db),
+		NewSpanner(db), NewDuckDB(db), NewTrino(db), NewVertica(db), NewHANA(db)} {
+		_, err := s.NamedLock(ctx, "foo", 0)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		_, err = s.NewLock("foo").TryLock(ctx)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		_, err = NewTableLock(s, "locks", "foo", time.Second).TryLock(ctx)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		assert.True(t, errors.Is(s.ResetTables(ctx, "foo"), ErrNotSupported), s.Dialect())
+		assert.True(t, errors.Is(s.InsertFixtures(ctx, Fixture{Table: "foo"}), ErrNotSupported), s.Dialect())
+		if s.Dialect() != "sqlserver" {
+			assert.True(t, errors.Is(s.CallProc("foo", nil, nil), ErrNotSupported), s.Dialect())
+		}
+		_, err = s.NextID(ctx, "foo")
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		_, err = s.ExecIdempotent(ctx, "key", "delete from foo")
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		_, err = s.PurgeOldRows(ctx, "foo", "created < 1", 10, 0)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		_, err = s.ConsistencyToken(ctx)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+		assert.True(t, errors.Is(NewOutbox(s, "outbox").Create(ctx), ErrNotSupported), s.Dialect())
+		assert.True(t, errors.Is(NewAuditTrail(s, "audit").Create(ctx), ErrNotSupported), s.Dialect())
+		assert.True(t, errors.Is(NewView(s, "foo", "select 1").Refresh(ctx), ErrNotSupported), s.Dialect())
+		_, err = s.WriteBlob(ctx, "foo", "data", nil, 0, "id = ?", 1)
+		assert.True(t, errors.Is(err, ErrNotSupported), s.Dialect())
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
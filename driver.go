@@ -0,0 +1,390 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+var (
+	wrappedMu      sync.Mutex
+	wrappedDrivers = map[string]bool{}
+)
+
+// WrapMySQLDriver registers a driver applying the sqlpp transform to the
+// queries of the driver registered as name, returning the name to pass
+// sql.Open. Code using database/sql directly, like ORMs, gets the (?)
+// expansion of slice args this way.
+func WrapMySQLDriver(name string) (string, error) {
+	return wrapDriver(name, mysqlDialect)
+}
+
+// WrapPostgreSQLDriver is WrapMySQLDriver numbering the placeholders for
+// postgres as well.
+func WrapPostgreSQLDriver(name string) (string, error) {
+	return wrapDriver(name, postgresDialect)
+}
+
+// WrapSQLiteDriver is WrapMySQLDriver for sqlite.
+func WrapSQLiteDriver(name string) (string, error) {
+	return wrapDriver(name, sqliteDialect)
+}
+
+// WrapSQLServerDriver is WrapPostgreSQLDriver numbering the placeholders
+// as @p1, @p2 for sql server.
+func WrapSQLServerDriver(name string) (string, error) {
+	return wrapDriver(name, sqlserverDialect)
+}
+
+// WrapOracleDriver is WrapPostgreSQLDriver numbering the placeholders as
+// :1, :2 and splitting long IN lists for oracle.
+func WrapOracleDriver(name string) (string, error) {
+	return wrapDriver(name, oracleDialect)
+}
+
+// WrapSnowflakeDriver is WrapMySQLDriver for snowflake.
+func WrapSnowflakeDriver(name string) (string, error) {
+	return wrapDriver(name, snowflakeDialect)
+}
+
+// WrapBigQueryDriver is WrapSQLServerDriver binding the args as named
+// values for bigquery.
+func WrapBigQueryDriver(name string) (string, error) {
+	return wrapDriver(name, bigqueryDialect)
+}
+
+// WrapSpannerDriver is WrapSQLServerDriver numbering the placeholders as
+// @param1, @param2 for spanner.
+func WrapSpannerDriver(name string) (string, error) {
+	return wrapDriver(name, spannerDialect)
+}
+
+// WrapRedshiftDriver is WrapPostgreSQLDriver splitting long IN lists for
+// redshift.
+func WrapRedshiftDriver(name string) (string, error) {
+	return wrapDriver(name, redshiftDialect)
+}
+
+// WrapDuckDBDriver is WrapMySQLDriver for duckdb.
+func WrapDuckDBDriver(name string) (string, error) {
+	return wrapDriver(name, duckdbDialect)
+}
+
+// WrapTrinoDriver is WrapMySQLDriver for trino and presto.
+func WrapTrinoDriver(name string) (string, error) {
+	return wrapDriver(name, trinoDialect)
+}
+
+// WrapTiDBDriver is WrapMySQLDriver for tidb.
+func WrapTiDBDriver(name string) (string, error) {
+	return wrapDriver(name, tidbDialect)
+}
+
+// WrapVerticaDriver is WrapMySQLDriver for vertica.
+func WrapVerticaDriver(name string) (string, error) {
+	return wrapDriver(name, verticaDialect)
+}
+
+// WrapHANADriver is WrapMySQLDriver for sap hana.
+func WrapHANADriver(name string) (string, error) {
+	return wrapDriver(name, hanaDialect)
+}
+
+func wrapDriver(name string, d *dialect) (string, error) {
+	wrapped := "sqlpp-" + d.name + "-" + name
+
+	wrappedMu.Lock()
+	defer wrappedMu.Unlock()
+
+	if wrappedDrivers[wrapped] {
+		return wrapped, nil
+	}
+
+	// sql.Open only looks the driver up, it doesn't connect
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return "", err
+	}
+
+	drv := db.Driver()
+	db.Close()
+
+	sql.Register(wrapped, &wrappedDriver{Driver: drv, sqlpp: new(nil, d, nil)})
+	wrappedDrivers[wrapped] = true
+	return wrapped, nil
+}
+
+type wrappedDriver struct {
+	driver.Driver
+
+	sqlpp *DB
+}
+
+func (d *wrappedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedConn{Conn: conn, sqlpp: d.sqlpp}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	dc, ok := d.Driver.(driver.DriverContext)
+	if !ok {
+		return &dsnConnector{dsn: dsn, driver: d}, nil
+	}
+
+	c, err := dc.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedConnector{Connector: c, driver: d}, nil
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver *wrappedDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type wrappedConnector struct {
+	driver.Connector
+
+	driver *wrappedDriver
+}
+
+func (c *wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedConn{Conn: conn, sqlpp: c.driver.sqlpp}, nil
+}
+
+func (c *wrappedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// wrappedConn transforms the queries run on the conn. Prepared stmts can't
+// expand (?) without the args, a (?) in them binds a single value.
+type wrappedConn struct {
+	driver.Conn
+
+	sqlpp *DB
+}
+
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query = c.sqlpp.build(query, -1, nil)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx falls back to Begin for drivers without BeginTx, failing the
+// options Begin can't apply as database/sql does.
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault {
+		return nil, fmt.Errorf("%w: non-default isolation level", ErrNotSupported)
+	}
+	if opts.ReadOnly {
+		return nil, fmt.Errorf("%w: read-only transactions", ErrNotSupported)
+	}
+
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue encodes the args of registered types, then keeps the
+// slice args for the transform, converting the others as the driver does.
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	nv.Value = encoded(nv.Value)
+	if expands(nv.Value) {
+		return nil
+	}
+
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query, args, err := c.transform(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		if result, err := e.ExecContext(ctx, query, args); err != driver.ErrSkip {
+			return result, err
+		}
+	}
+
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	if s, ok := stmt.(driver.StmtExecContext); ok {
+		return s.ExecContext(ctx, args)
+	}
+
+	return stmt.Exec(values(args))
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, args, err := c.transform(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		if rows, err := q.QueryContext(ctx, query, args); err != driver.ErrSkip {
+			return rows, err
+		}
+	}
+
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows driver.Rows
+	if s, ok := stmt.(driver.StmtQueryContext); ok {
+		rows, err = s.QueryContext(ctx, args)
+	} else {
+		rows, err = stmt.Query(values(args))
+	}
+
+	if err != nil {
+		stmt.Close()
+		return nil, err
+	}
+
+	return &stmtRows{Rows: rows, stmt: stmt}, nil
+}
+
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *wrappedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+// prepare prepares the already transformed query on the wrapped conn.
+func (c *wrappedConn) prepare(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+// transform expands the slice args and converts their elements. Named args
+// aren't placeholders sqlpp knows, queries with them run as is.
+func (c *wrappedConn) transform(query string, args []driver.NamedValue) (string, []driver.NamedValue, error) {
+	vals := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return query, args, nil
+		}
+
+		vals[i] = arg.Value
+	}
+
+	if err := c.sqlpp.checkSlices(query, vals); err != nil {
+		return "", nil, err
+	}
+
+	if err := c.sqlpp.checkParams(query, vals); err != nil {
+		return "", nil, err
+	}
+
+	query, vals = c.sqlpp.transform(query, vals)
+	named := make([]driver.NamedValue, len(vals))
+	for i, v := range vals {
+		nv := driver.NamedValue{Ordinal: i + 1, Value: v}
+		if arg, ok := v.(sql.NamedArg); ok {
+			nv.Name, nv.Value, v = arg.Name, arg.Value, arg.Value
+		}
+
+		err := driver.ErrSkip
+		if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+			err = checker.CheckNamedValue(&nv)
+		}
+
+		if err == driver.ErrSkip {
+			nv.Value, err = driver.DefaultParameterConverter.ConvertValue(v)
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		named[i] = nv
+	}
+
+	return query, named, nil
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+
+	return vals
+}
+
+// stmtRows closes the stmt prepared for a query with its rows.
+type stmtRows struct {
+	driver.Rows
+
+	stmt driver.Stmt
+}
+
+func (r *stmtRows) Close() error {
+	err := r.Rows.Close()
+	r.stmt.Close()
+	return err
+}
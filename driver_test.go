@@ -0,0 +1,97 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapDriver(t *testing.T) {
+	dsn := fmt.Sprint("wrap-postgres-", time.Now().UnixNano())
+	_, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.Nil(t, err)
+
+	name, err := WrapPostgreSQLDriver("sqlmock")
+	assert.Nil(t, err)
+	again, err := WrapPostgreSQLDriver("sqlmock")
+	assert.Nil(t, err)
+	assert.Equal(t, name, again)
+
+	db, err := sql.Open(name, dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`^update foo set j = \$1 where i in \(\$2,\$3\)$`).
+		WithArgs("j", 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`^select i from foo where i in \(\$1,\$2\) and b = \$3$`).
+		WithArgs("a", "b", []byte("c")).
+		WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mock.ExpectPrepare(`^select i from foo where i = \$1$`)
+
+	_, err = db.Exec("update foo set j = ? where i in (?)", "j", []int{1, 2})
+	assert.Nil(t, err)
+
+	var i int
+	assert.Nil(t, db.QueryRow("select i from foo where i in (?) and b = ?", []string{"a", "b"}, []byte("c")).Scan(&i))
+	assert.Equal(t, 1, i)
+
+	_, err = db.Prepare("select i from foo where i = ?")
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapDriver_mysql(t *testing.T) {
+	dsn := fmt.Sprint("wrap-mysql-", time.Now().UnixNano())
+	_, mock, err := sqlmock.NewWithDSN(dsn)
+	assert.Nil(t, err)
+
+	name, err := WrapMySQLDriver("sqlmock")
+	assert.Nil(t, err)
+
+	db, err := sql.Open(name, dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`^delete from foo where i in \(\?,\?,\?\)$`).
+		WithArgs(1, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	_, err = db.Exec("delete from foo where i in (?)", []int64{1, 2, 3})
+	assert.Nil(t, err)
+
+	_, err = WrapMySQLDriver("unknown")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+type beginConn struct{ driver.Conn }
+
+func (beginConn) Begin() (driver.Tx, error) { return nil, nil }
+
+func TestWrappedConn_BeginTx(t *testing.T) {
+	c := &wrappedConn{Conn: beginConn{}}
+
+	tests := []struct {
+		opts driver.TxOptions
+		err  error
+	}{
+		{driver.TxOptions{}, nil},
+		{driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)}, ErrNotSupported},
+		{driver.TxOptions{ReadOnly: true}, ErrNotSupported},
+	}
+
+	for _, tt := range tests {
+		_, err := c.BeginTx(context.Background(), tt.opts)
+		assert.True(t, errors.Is(err, tt.err), "%+v: %v", tt.opts, err)
+	}
+}
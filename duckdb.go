@@ -0,0 +1,17 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// the driver prepares one statement, a script fails on prepare
+	duckdbErrMultipleStatements = "Cannot prepare multiple statements at once"
+	// statements like ATTACH and some PRAGMAs have no prepared form
+	duckdbErrNotImplemented = "Not implemented Error"
+)
+
+func isDuckDBPrepareNotSupported(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), duckdbErrMultipleStatements) ||
+		strings.Contains(err.Error(), duckdbErrNotImplemented))
+}
@@ -0,0 +1,67 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isDuckDBPrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("Invalid Input Error: Cannot prepare multiple statements at once!"),
+			true,
+		},
+		{
+			errors.New("Not implemented Error: ATTACH is not supported in prepared statements"),
+			true,
+		},
+		{
+			errors.New("Parser Error: syntax error at or near \"selec\""),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isDuckDBPrepareNotSupported(c.err))
+		})
+	}
+}
+
+func TestNewDuckDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewDuckDB(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = ? and b in (?,?)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	literal, err := s.interpolation("select ?, ?", []interface{}{[]byte{0xca, 0xfe}, `it's \`})
+	assert.Nil(t, err)
+	assert.Equal(t, `select from_hex('cafe'), 'it''s \'`, literal)
+
+	mock.ExpectPrepare("attach 'foo.db'").
+		WillReturnError(errors.New("Not implemented Error: ATTACH is not supported in prepared statements"))
+	mock.ExpectExec("attach 'foo.db'").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("attach 'foo.db'")
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,93 @@
+package sqlpp
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Encoder converts an arg of a registered type to a value the driver binds.
+type Encoder func(v interface{}) (driver.Value, error)
+
+var (
+	encoders     sync.Map
+	encoderCount int32
+)
+
+// RegisterEncoder makes queries bind the args of type t, and the elements
+// of type t of slice args, as encode returns them, so domain types like
+// enums or money can be passed as args without implementing
+// driver.Valuer. Encoders run before slice expansion, so a slice type with
+// an encoder binds as one value. It's meant to be called at init.
+func RegisterEncoder(t reflect.Type, encode Encoder) {
+	if _, loaded := encoders.LoadOrStore(t, encode); loaded {
+		encoders.Store(t, encode)
+		return
+	}
+
+	atomic.AddInt32(&encoderCount, 1)
+}
+
+// encodeArgs returns args with the registered types encoded, args itself
+// if none is, copying it so the caller's args stay as they are.
+func encodeArgs(args []interface{}) []interface{} {
+	if atomic.LoadInt32(&encoderCount) == 0 {
+		return args
+	}
+
+	var encodedArgs []interface{}
+	for i, arg := range args {
+		v, ok := encode(arg)
+		if encodedArgs == nil {
+			if !ok {
+				continue
+			}
+
+			encodedArgs = append(make([]interface{}, 0, len(args)), args[:i]...)
+		}
+
+		encodedArgs = append(encodedArgs, v)
+	}
+
+	if encodedArgs == nil {
+		return args
+	}
+
+	return encodedArgs
+}
+
+// encoded returns arg encoded by the encoder of its type.
+func encoded(arg interface{}) interface{} {
+	v, _ := encode(arg)
+	return v
+}
+
+// encode encodes arg if its type has an encoder, reporting whether it has.
+// A failed encode becomes a Valuer returning its error, so it fails the
+// query on bind.
+func encode(arg interface{}) (interface{}, bool) {
+	if atomic.LoadInt32(&encoderCount) == 0 {
+		return arg, false
+	}
+
+	encoder, ok := encoders.Load(reflect.TypeOf(arg))
+	if !ok {
+		return arg, false
+	}
+
+	v, err := encoder.(Encoder)(arg)
+	if err != nil {
+		return failedValue{err}, true
+	}
+
+	return v, true
+}
+
+type failedValue struct {
+	err error
+}
+
+func (v failedValue) Value() (driver.Value, error) {
+	return nil, v.err
+}
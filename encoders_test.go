@@ -0,0 +1,67 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMoney struct {
+	cents int64
+}
+
+type testTags []string
+
+type testBroken struct{}
+
+func init() {
+	RegisterEncoder(reflect.TypeOf(testMoney{}), func(v interface{}) (driver.Value, error) {
+		m := v.(testMoney)
+		return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100), nil
+	})
+	RegisterEncoder(reflect.TypeOf(testTags{}), func(v interface{}) (driver.Value, error) {
+		return strings.Join(v.(testTags), ","), nil
+	})
+	RegisterEncoder(reflect.TypeOf(testBroken{}), func(v interface{}) (driver.Value, error) {
+		return nil, errors.New("broken")
+	})
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	ctx := context.Background()
+
+	mock.ExpectPrepare("update orders set total = $1, tags = $2 where id = $3").
+		ExpectExec().WithArgs("12.34", "a,b", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("select id from orders where total in ($1,$2)").
+		ExpectQuery().WithArgs("1.00", "2.50").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectPrepare("update orders set total = $1").
+		ExpectExec().WillReturnError(errors.New("unexpected"))
+
+	_, err = s.ExecContext(ctx, "update orders set total = ?, tags = ? where id = ?", testMoney{1234}, testTags{"a", "b"}, 1)
+	assert.Nil(t, err)
+
+	var id int
+	err = s.RowContext(ctx, "select id from orders where total in (?)", []testMoney{{100}, {250}}).Scan(&id)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, id)
+
+	_, err = s.ExecContext(ctx, "update orders set total = ?", testBroken{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "broken")
+	}
+
+	query, err := s.interpolation("update orders set total = ?", []interface{}{testMoney{5}})
+	assert.Nil(t, err)
+	assert.Equal(t, "update orders set total = '0.05'", query)
+}
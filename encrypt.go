@@ -0,0 +1,259 @@
+package sqlpp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrCiphertext = errors.New("sqlpp: malformed ciphertext")
+	ErrNoCipher   = errors.New("sqlpp: no cipher for encrypted field")
+)
+
+// KeyProvider supplies the AES keys of a Cipher, 16, 24 or 32 bytes long.
+// New values are encrypted with the current key, the id stored with them
+// finds the key to decrypt, so keys can be rotated without rewriting rows.
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider of keys held in memory.
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("sqlpp: unknown encryption key %q", id)
+	}
+
+	return key, nil
+}
+
+// Cipher encrypts column values with AES-GCM. Wrap args with Encrypt and
+// scan dests with Decrypt, e.g.
+//
+//	c := sqlpp.NewCipher(keys).Column("users", "ssn")
+//	db.Exec("insert into users (ssn) values (?)", c.EncryptString(ssn))
+//	db.Row("select ssn from users where id = ?", id).Scan(c.DecryptString(&ssn))
+//
+// With WithCipher, struct fields tagged encrypt:"true" are encrypted by
+// Insert and UpdateVersioned and decrypted by the dests of FieldsOf.
+type Cipher struct {
+	keys KeyProvider
+	// authenticated with the values, binding them to their column
+	aad []byte
+}
+
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{keys: keys}
+}
+
+// WithCipher encrypts the struct fields tagged encrypt:"true" with c, bound
+// to their table and column.
+func WithCipher(c *Cipher) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.cipher = c
+	}
+}
+
+// Column returns the cipher of the values of column in table, which fail to
+// decrypt with ErrCiphertext when copied to another column.
+func (c *Cipher) Column(table, column string) *Cipher {
+	aad := make([]byte, 0, len(table)+1+len(column))
+	aad = append(append(append(aad, table...), 0), column...)
+	return &Cipher{keys: c.keys, aad: aad}
+}
+
+// encrypted is the value stored: a version byte, the key id length and
+// id, then the gcm nonce and sealed plaintext.
+const encryptedVersion = 1
+
+func (c *Cipher) Encrypt(plain []byte) driver.Valuer {
+	return encryptValuer{c, plain}
+}
+
+func (c *Cipher) EncryptString(plain string) driver.Valuer {
+	return encryptValuer{c, []byte(plain)}
+}
+
+// Decrypt scans an encrypted column into dest, NULL as a nil slice.
+func (c *Cipher) Decrypt(dest *[]byte) sql.Scanner {
+	return decryptScanner{c, func(plain []byte) { *dest = plain }}
+}
+
+// DecryptString scans an encrypted column into dest, NULL as "".
+func (c *Cipher) DecryptString(dest *string) sql.Scanner {
+	return decryptScanner{c, func(plain []byte) { *dest = string(plain) }}
+}
+
+func (c *Cipher) seal(plain []byte) ([]byte, error) {
+	id, key, err := c.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	} else if len(id) > 255 {
+		return nil, fmt.Errorf("sqlpp: encryption key id %q is too long", id)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{encryptedVersion, byte(len(id))}, id...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// the header is authenticated so the key id can't be swapped
+	out := make([]byte, 0, len(header)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(append(out, header...), nonce...)
+	return gcm.Seal(out, nonce, plain, c.additional(header)), nil
+}
+
+func (c *Cipher) open(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != encryptedVersion || len(data) < 2+int(data[1]) {
+		return nil, ErrCiphertext
+	}
+
+	header := data[:2+int(data[1])]
+	key, err := c.keys.Key(string(header[2:]))
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(header):]
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrCiphertext
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], c.additional(header))
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+
+	return plain, nil
+}
+
+// additional returns the data authenticated with a value, its header then
+// the column of the cipher.
+func (c *Cipher) additional(header []byte) []byte {
+	if len(c.aad) == 0 {
+		return header
+	}
+
+	return append(header[:len(header):len(header)], c.aad...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+type encryptValuer struct {
+	c     *Cipher
+	plain []byte
+}
+
+func (v encryptValuer) Value() (driver.Value, error) {
+	if v.plain == nil {
+		return nil, nil
+	}
+
+	return v.c.seal(v.plain)
+}
+
+type decryptScanner struct {
+	c   *Cipher
+	set func([]byte)
+}
+
+func (s decryptScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		s.set(nil)
+		return nil
+	case []byte:
+		plain, err := s.c.open(v)
+		if err != nil {
+			return err
+		}
+
+		s.set(plain)
+		return nil
+	case string:
+		return s.Scan([]byte(v))
+	}
+
+	return fmt.Errorf("sqlpp: cannot decrypt %T", src)
+}
+
+// encryptedArg returns value, a string or []byte field, encrypted as an arg
+// of column in table.
+func (sqlpp *DB) encryptedArg(table, column string, value interface{}) interface{} {
+	c := sqlpp.config().cipher
+	if c == nil {
+		return failedValue{ErrNoCipher}
+	}
+
+	c = c.Column(table, column)
+	switch v := value.(type) {
+	case string:
+		return c.EncryptString(v)
+	case []byte:
+		return c.Encrypt(v)
+	}
+
+	return failedValue{fmt.Errorf("sqlpp: cannot encrypt %T", value)}
+}
+
+// decryptedDest returns a dest decrypting column of table into dest, a
+// *string or *[]byte.
+func (sqlpp *DB) decryptedDest(table, column string, dest interface{}) sql.Scanner {
+	c := sqlpp.config().cipher
+	if c == nil {
+		return failedScanner{ErrNoCipher}
+	}
+
+	c = c.Column(table, column)
+	switch d := dest.(type) {
+	case *string:
+		return c.DecryptString(d)
+	case *[]byte:
+		return c.Decrypt(d)
+	}
+
+	return failedScanner{fmt.Errorf("sqlpp: cannot decrypt into %T", dest)}
+}
+
+type failedScanner struct {
+	err error
+}
+
+func (s failedScanner) Scan(interface{}) error {
+	return s.err
+}
@@ -0,0 +1,130 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type anyArg struct {
+	value *driver.Value
+}
+
+func (a anyArg) Match(v driver.Value) bool {
+	*a.value = v
+	return true
+}
+
+func TestCipher(t *testing.T) {
+	keys := StaticKeys{Current: "k1", Keys: map[string][]byte{
+		"k1": []byte("0123456789abcdef"),
+		"k2": []byte("0123456789abcdef0123456789abcdef"),
+	}}
+	c := NewCipher(keys)
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	s := NewMySQL(db)
+
+	var stored driver.Value
+	mock.ExpectPrepare("^insert into users").
+		ExpectExec().WithArgs(anyArg{&stored}, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = s.Exec("insert into users (ssn, note) values (?, ?)", c.EncryptString("123-45-6789"), c.Encrypt(nil))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(stored.([]byte)), "123-45-6789")
+
+	mock.ExpectPrepare("^select ssn, note from users").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"ssn", "note"}).AddRow(stored, nil))
+
+	var ssn string
+	note := []byte("x")
+	assert.Nil(t, s.QueryRow("select ssn, note from users", nil, c.DecryptString(&ssn), c.Decrypt(&note)))
+	assert.Equal(t, "123-45-6789", ssn)
+	assert.Nil(t, note)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	// rotated keys still decrypt old values
+	keys.Current = "k2"
+	rotated := NewCipher(keys)
+	v, err := rotated.EncryptString("foo").Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "k2", string(v.([]byte)[2:4]))
+
+	var plain string
+	assert.Nil(t, rotated.DecryptString(&plain).Scan(stored))
+	assert.Equal(t, "123-45-6789", plain)
+	assert.Nil(t, c.DecryptString(&plain).Scan(v))
+	assert.Equal(t, "foo", plain)
+
+	tampered := append([]byte(nil), v.([]byte)...)
+	tampered[len(tampered)-1] ^= 1
+	assert.Equal(t, ErrCiphertext, c.DecryptString(&plain).Scan(tampered))
+	assert.Equal(t, ErrCiphertext, c.DecryptString(&plain).Scan([]byte{2}))
+	assert.EqualError(t, c.DecryptString(&plain).Scan(1), "sqlpp: cannot decrypt int")
+
+	_, err = NewCipher(StaticKeys{Current: "k3"}).EncryptString("foo").Value()
+	assert.EqualError(t, err, `sqlpp: unknown encryption key "k3"`)
+}
+
+func TestCipher_Column(t *testing.T) {
+	c := NewCipher(StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": []byte("0123456789abcdef")}})
+
+	v, err := c.Column("users", "ssn").EncryptString("123-45-6789").Value()
+	assert.Nil(t, err)
+
+	var plain string
+	assert.Nil(t, c.Column("users", "ssn").DecryptString(&plain).Scan(v))
+	assert.Equal(t, "123-45-6789", plain)
+
+	// values are bound to their column
+	assert.Equal(t, ErrCiphertext, c.Column("users", "note").DecryptString(&plain).Scan(v))
+	assert.Equal(t, ErrCiphertext, c.Column("user", "sssn").DecryptString(&plain).Scan(v))
+	assert.Equal(t, ErrCiphertext, c.DecryptString(&plain).Scan(v))
+}
+
+type secretDoc struct {
+	ID   int64  `db:"id"`
+	SSN  string `db:"ssn" encrypt:"true"`
+	Note []byte `db:"note" encrypt:"true"`
+}
+
+func TestDB_encryptedFields(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	c := NewCipher(StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": []byte("0123456789abcdef")}})
+	s := NewMySQL(db, WithCipher(c))
+
+	var ssn, note driver.Value
+	mock.ExpectPrepare("INSERT INTO `users` (`id`, `ssn`, `note`) VALUES (?,?,?)").
+		ExpectExec().WithArgs(1, anyArg{&ssn}, anyArg{&note}).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = s.Insert("users", &secretDoc{ID: 1, SSN: "123-45-6789"})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(ssn.([]byte)), "123-45-6789")
+	assert.Nil(t, note)
+
+	mock.ExpectPrepare("SELECT `id`, `ssn`, `note` FROM `users` WHERE (id = ?)").
+		ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "ssn", "note"}).AddRow(1, ssn, nil))
+
+	docs, err := Find[secretDoc](s, "users", "id = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []secretDoc{{ID: 1, SSN: "123-45-6789"}}, docs)
+
+	// bound to the table they were written to
+	var doc secretDoc
+	dest := s.FieldsOf("admins", &doc)
+	assert.Equal(t, ErrCiphertext, dest[1].(sql.Scanner).Scan(ssn))
+
+	// fields can't be written in the clear without a cipher
+	mock.ExpectPrepare("INSERT INTO `users` (`id`, `ssn`, `note`) VALUES (?,?,?)")
+	_, err = NewMySQL(db).Insert("users", &secretDoc{ID: 2, SSN: "x"})
+	assert.True(t, errors.Is(err, ErrNoCipher))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
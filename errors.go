@@ -0,0 +1,61 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNotFound is sql.ErrNoRows, returned by Row and GetRow without a row.
+	ErrNotFound = sql.ErrNoRows
+
+	ErrPrepareNotSupported = errors.New("sqlpp: prepare not supported")
+	ErrTooManyParams       = errors.New("sqlpp: too many placeholders")
+	ErrArgCountMismatch    = errors.New("sqlpp: placeholder and argument count mismatch")
+	ErrBusy                = errors.New("sqlpp: database is locked")
+)
+
+// driverError keeps the message of a driver error while matching one of
+// the sentinels with errors.Is.
+type driverError struct {
+	sentinel error
+	err      error
+}
+
+func (e *driverError) Error() string {
+	return e.err.Error()
+}
+
+func (e *driverError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *driverError) Unwrap() error {
+	return e.err
+}
+
+// classify wraps the driver errors that have a sentinel.
+func (sqlpp *DB) classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sentinel error
+	switch msg := err.Error(); {
+	case sqlpp.dialect.prepareNotSupported(err):
+		sentinel = ErrPrepareNotSupported
+	case strings.HasPrefix(msg, "Error 1390:"), strings.Contains(msg, "only supports 65535 parameters"),
+		strings.Contains(msg, "limited to 65535 parameters"), strings.Contains(msg, sqliteErrTooManyParams):
+		sentinel = ErrTooManyParams
+	case strings.HasPrefix(msg, "sql: expected ") && strings.Contains(msg, " arguments, got "),
+		strings.HasPrefix(msg, "Error 1210:"), strings.Contains(msg, "parameters but the statement requires"):
+		sentinel = ErrArgCountMismatch
+	case isSQLiteBusy(err):
+		sentinel = ErrBusy
+	default:
+		return err
+	}
+
+	return &driverError{sentinel: sentinel, err: err}
+}
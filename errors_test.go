@@ -0,0 +1,68 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_classify(t *testing.T) {
+	s := NewMySQL(nil)
+	cases := []struct {
+		err      error
+		sentinel error
+	}{
+		{errPrepareNotSupported, ErrPrepareNotSupported},
+		{errors.New("Error 1390: Prepared statement contains too many placeholders"), ErrTooManyParams},
+		{errors.New("pq: got 65536 parameters but PostgreSQL only supports 65535 parameters"), ErrTooManyParams},
+		{errors.New("extended protocol limited to 65535 parameters"), ErrTooManyParams},
+		{errors.New("sql: expected 2 arguments, got 1"), ErrArgCountMismatch},
+		{errors.New("Error 1210: Incorrect arguments to mysqld_stmt_execute"), ErrArgCountMismatch},
+		{errors.New("pq: got 1 parameters but the statement requires 2"), ErrArgCountMismatch},
+	}
+
+	for _, c := range cases {
+		t.Run(c.err.Error(), func(t *testing.T) {
+			err := s.classify(c.err)
+			assert.True(t, errors.Is(err, c.sentinel))
+			assert.True(t, errors.Is(err, c.err))
+			assert.Equal(t, c.err.Error(), err.Error())
+		})
+	}
+
+	other := errors.New("other")
+	assert.Equal(t, other, s.classify(other))
+	assert.Nil(t, s.classify(nil))
+
+	// by the dialect's prepare errors
+	unimplemented := errors.New(`spanner: code = "Unimplemented", desc = "Unsupported statement"`)
+	assert.True(t, errors.Is(NewSpanner(nil).classify(unimplemented), ErrPrepareNotSupported))
+	assert.Equal(t, unimplemented, s.classify(unimplemented))
+	assert.Equal(t, errPrepareNotSupported, NewSQLServer(nil).classify(errPrepareNotSupported))
+}
+
+func TestDB_sentinels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	mock.ExpectPrepare(`^select i from foo where i = \?$`).
+		ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}))
+
+	var i int
+	err = s.Row("select i from foo where i = ?", 1).Scan(&i)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	_, err = s.Exec("delete from foo where i in (?)", make([]int, s.maxParams()+1))
+	assert.Equal(t, ErrTooManyParams, err)
+
+	_, err = s.ExecContext(Interpolate(context.Background()), "update foo set i = ?, j = ?", 1)
+	assert.True(t, errors.Is(err, ErrArgCountMismatch))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,27 @@
+package sqlpp
+
+import (
+	"context"
+	"io"
+)
+
+// ExportCSV streams the rows of query to w as csv, a header of the columns
+// then the rows, NULL as \N, like DumpTable.
+func (sqlpp *DB) ExportCSV(ctx context.Context, w io.Writer, query string, args ...interface{}) error {
+	return sqlpp.export(ctx, w, DumpCSV, query, args)
+}
+
+// ExportJSON streams the rows of query to w as a json array of objects
+// keyed by column, NULL as null, like DumpTable.
+func (sqlpp *DB) ExportJSON(ctx context.Context, w io.Writer, query string, args ...interface{}) error {
+	return sqlpp.export(ctx, w, DumpJSON, query, args)
+}
+
+func (sqlpp *DB) export(ctx context.Context, w io.Writer, format DumpFormat, query string, args []interface{}) error {
+	rows, err := sqlpp.RowsContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return sqlpp.writeRows(rows, w, format)
+}
@@ -0,0 +1,41 @@
+package sqlpp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Export(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "note"}).
+			AddRow(int64(1), []byte(`a "quoted", name`), nil).
+			AddRow(int64(2), "b\nc", "x")
+	}
+
+	mock.ExpectPrepare(`^select id, name, note from foo where id in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(rows())
+	mock.ExpectQuery(`^select id, name, note from foo where id in \(\$1,\$2\)$`).
+		WithArgs(1, 2).WillReturnRows(rows())
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	assert.Nil(t, s.ExportCSV(ctx, &buf, "select id, name, note from foo where id in (?)", []int{1, 2}))
+	assert.Equal(t, "id,name,note\n1,\"a \"\"quoted\"\", name\",\\N\n2,\"b\nc\",x\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, s.ExportJSON(ctx, &buf, "select id, name, note from foo where id in (?)", []int{1, 2}))
+	assert.Equal(t, "[\n  {\"id\":1,\"name\":\"a \\\"quoted\\\", name\",\"note\":null},\n  {\"id\":2,\"name\":\"b\\nc\",\"note\":\"x\"}\n]\n", buf.String())
+
+	mock.ExpectPrepare("^select bad$").WillReturnError(sqlmock.ErrCancelled)
+	assert.NotNil(t, s.ExportJSON(ctx, &buf, "select bad"))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,257 @@
+package sqlpp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture holds seed rows of a table, keyed by column.
+type Fixture struct {
+	Table string                   `json:"table" yaml:"table"`
+	Rows  []map[string]interface{} `json:"rows" yaml:"rows"`
+}
+
+// LoadFixtures reads json files from fsys, or yaml ones ending in .yaml or
+// .yml, each an array of fixtures, and inserts them with InsertFixtures.
+// Tables are inserted after the ones their foreign keys reference, in the
+// order of the files otherwise.
+func (sqlpp *DB) LoadFixtures(ctx context.Context, fsys fs.FS, paths ...string) error {
+	fixtures := []Fixture{}
+	for _, path := range paths {
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		var f []Fixture
+		if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+			err = yaml.Unmarshal(b, &f)
+		} else {
+			d := json.NewDecoder(bytes.NewReader(b))
+			d.UseNumber()
+			err = d.Decode(&f)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fixtures = append(fixtures, f...)
+	}
+
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	}
+
+	refs, err := sqlpp.foreignKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	return sqlpp.InsertFixtures(ctx, orderFixtures(fixtures, refs)...)
+}
+
+// foreignKeys returns the tables each table references by its foreign keys.
+func (sqlpp *DB) foreignKeys(ctx context.Context) (map[string][]string, error) {
+	query := "SELECT TABLE_NAME, REFERENCED_TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE" +
+		" WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL"
+	if sqlpp.dialect.flavor == postgresFlavor {
+		query = "SELECT conrelid::regclass::text, confrelid::regclass::text FROM pg_constraint WHERE contype = 'f'"
+	}
+
+	refs := map[string][]string{}
+	_, err := sqlpp.SelectContext(ctx, func(rows *sql.Rows) (interface{}, error) {
+		var table, referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, err
+		}
+
+		refs[table] = append(refs[table], referenced)
+		return nil, nil
+	}, query)
+	return refs, err
+}
+
+// orderFixtures orders the tables of fixtures after the ones they
+// reference, keeping their order otherwise. A reference cycle is broken
+// at its first table.
+func orderFixtures(fixtures []Fixture, refs map[string][]string) []Fixture {
+	var tables []string
+	byTable := map[string][]Fixture{}
+	for _, f := range fixtures {
+		if _, ok := byTable[f.Table]; !ok {
+			tables = append(tables, f.Table)
+		}
+
+		byTable[f.Table] = append(byTable[f.Table], f)
+	}
+
+	ordered := make([]Fixture, 0, len(fixtures))
+	placed := map[string]bool{}
+	for len(placed) < len(tables) {
+		next := ""
+		for _, table := range tables {
+			if !placed[table] && next == "" {
+				next = table // the first left, if all are on a cycle
+			}
+
+			if !placed[table] && referencesPlaced(table, refs[table], byTable, placed) {
+				next = table
+				break
+			}
+		}
+
+		placed[next] = true
+		ordered = append(ordered, byTable[next]...)
+	}
+
+	return ordered
+}
+
+func referencesPlaced(table string, referenced []string, byTable map[string][]Fixture, placed map[string]bool) bool {
+	for _, r := range referenced {
+		if _, ok := byTable[r]; ok && r != table && !placed[r] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InsertFixtures inserts fixtures in order in a single transaction. On
+// postgres explicit identity values are allowed and the id sequence of
+// each table is moved past the inserted ids.
+func (sqlpp *DB) InsertFixtures(ctx context.Context, fixtures ...Fixture) error {
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	} else if err := sqlpp.helperFeature(sqlpp.dialect.identityOverride); err != nil {
+		return err
+	}
+
+	return sqlpp.WithTx(ctx, nil, func(tx *Tx) error {
+		for _, f := range fixtures {
+			rows := f.Rows
+			err := sqlpp.insertRows(ctx, tx, f.Table, func() (map[string]interface{}, error) {
+				if len(rows) == 0 {
+					return nil, io.EOF
+				}
+
+				row := rows[0]
+				rows = rows[1:]
+				return row, nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// insertRows inserts the rows next returns into table until io.EOF.
+func (sqlpp *DB) insertRows(ctx context.Context, tx *Tx, table string, next func() (map[string]interface{}, error)) error {
+	quoted := sqlpp.QuoteIdent(table)
+	ids := false
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			args[i] = row[column]
+			columns[i] = sqlpp.QuoteIdent(column)
+		}
+
+		if _, ok := row["id"]; ok {
+			ids = true
+		}
+
+		query := "INSERT INTO " + quoted + " (" + strings.Join(columns, ",") + ")"
+		if sqlpp.dialect.flavor == postgresFlavor {
+			query += " OVERRIDING SYSTEM VALUE"
+		}
+
+		if _, err := tx.ExecContext(ctx, query+" VALUES (?)", args); err != nil {
+			return err
+		}
+	}
+
+	// mysql moves auto increments past explicit ids on its own
+	if sqlpp.dialect.flavor == postgresFlavor && ids {
+		query := "SELECT setval(pg_get_serial_sequence(?, 'id'), MAX(id)) FROM " + quoted
+		if _, err := tx.ExecContext(ctx, query, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetTables empties tables and restarts their identities. Foreign keys
+// are cascaded on postgres and ignored during the truncate on mysql.
+func (sqlpp *DB) ResetTables(ctx context.Context, tables ...string) error {
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	} else if err := sqlpp.helperFeature(sqlpp.dialect.truncateRestart); err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = sqlpp.QuoteIdent(table)
+	}
+
+	if sqlpp.dialect.flavor == postgresFlavor {
+		_, err := sqlpp.ExecContext(ctx, "TRUNCATE "+strings.Join(quoted, ",")+" RESTART IDENTITY CASCADE")
+		return err
+	}
+
+	// foreign key checks are per session
+	return sqlpp.WithConn(ctx, func(c *Conn) error {
+		if _, err := c.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return err
+		}
+
+		var err error
+		for _, table := range quoted {
+			if _, err = c.ExecContext(ctx, "TRUNCATE TABLE "+table); err != nil {
+				break
+			}
+		}
+
+		// restored even when ctx is done, a session left without the
+		// checks is discarded rather than returned to the pool
+		if _, e := c.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1"); e != nil {
+			discardConn(c.Raw())
+			if err == nil {
+				err = e
+			}
+		}
+
+		return err
+	})
+}
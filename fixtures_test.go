@@ -0,0 +1,127 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_LoadFixtures(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	mRefs := "SELECT TABLE_NAME, REFERENCED_TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE" +
+		" WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL"
+	mMock.ExpectPrepare(mRefs).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"table", "referenced"}).AddRow("posts", "users"))
+	mMock.ExpectBegin()
+	mMock.ExpectPrepare("INSERT INTO `users` (`id`,`name`) VALUES (?,?)").
+		ExpectExec().WithArgs("1", "foo").WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectPrepare("INSERT INTO `users` (`email`,`id`,`name`) VALUES (?,?,?)").
+		ExpectExec().WithArgs(nil, "2", "bar").WillReturnResult(sqlmock.NewResult(2, 1))
+	mMock.ExpectPrepare("INSERT INTO `posts` (`title`,`user_id`) VALUES (?,?)").
+		ExpectExec().WithArgs("baz", "1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectCommit()
+
+	// yaml, posts listed first go after the users they reference
+	mMock.ExpectQuery(mRefs).
+		WillReturnRows(sqlmock.NewRows([]string{"table", "referenced"}).AddRow("posts", "users"))
+	mMock.ExpectBegin()
+	mMock.ExpectPrepare("INSERT INTO `users` (`id`,`name`) VALUES (?,?)").
+		ExpectExec().WithArgs(1, "foo").WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectPrepare("INSERT INTO `posts` (`title`,`user_id`) VALUES (?,?)").
+		ExpectExec().WithArgs("baz", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectCommit()
+
+	pMock.ExpectPrepare("SELECT conrelid::regclass::text, confrelid::regclass::text FROM pg_constraint WHERE contype = 'f'").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"table", "referenced"}))
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(`INSERT INTO "users" ("id","name") OVERRIDING SYSTEM VALUE VALUES ($1,$2)`).
+		ExpectExec().WithArgs("1", "foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectPrepare(`INSERT INTO "users" ("email","id","name") OVERRIDING SYSTEM VALUE VALUES ($1,$2,$3)`).
+		ExpectExec().WithArgs(nil, "2", "bar").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectPrepare(`SELECT setval(pg_get_serial_sequence($1, 'id'), MAX(id)) FROM "users"`).
+		ExpectExec().WithArgs("users").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectPrepare(`INSERT INTO "posts" ("title","user_id") OVERRIDING SYSTEM VALUE VALUES ($1,$2)`).
+		ExpectExec().WithArgs("baz", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectCommit()
+
+	assert.Nil(t, sm.LoadFixtures(context.Background(), os.DirFS("testdata"), "fixtures.json"))
+	assert.Nil(t, sm.LoadFixtures(context.Background(), os.DirFS("testdata"), "fixtures.yaml"))
+	assert.Nil(t, sp.LoadFixtures(context.Background(), os.DirFS("testdata"), "fixtures.json"))
+	assert.NotNil(t, sm.LoadFixtures(context.Background(), os.DirFS("testdata"), "missing.json"))
+
+	// policies apply to the inserts
+	sr := NewMySQL(mDb, WithPolicy(ReadOnly()))
+	mMock.ExpectBegin()
+	mMock.ExpectRollback()
+	err := sr.InsertFixtures(context.Background(), Fixture{Table: "users", Rows: []map[string]interface{}{{"id": 1}}})
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
+
+func Test_orderFixtures(t *testing.T) {
+	a, b, c, a2 := Fixture{Table: "a"}, Fixture{Table: "b"}, Fixture{Table: "c"}, Fixture{Table: "a", Rows: []map[string]interface{}{{}}}
+	refs := map[string][]string{"a": {"b", "a", "other"}, "b": {"c"}}
+	assert.Equal(t, []Fixture{c, b, a, a2}, orderFixtures([]Fixture{a, b, a2, c}, refs))
+
+	// a cycle is broken at its first table
+	refs["c"] = []string{"a"}
+	assert.Equal(t, []Fixture{a, a2, c, b}, orderFixtures([]Fixture{a, b, a2, c}, refs))
+}
+
+func TestDB_ResetTables(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 0").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("TRUNCATE TABLE `users`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("TRUNCATE TABLE `posts`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 1").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 0").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("TRUNCATE TABLE `koo`").ExpectExec().WillReturnError(errors.New("error"))
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 1").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+
+	pMock.ExpectPrepare(`TRUNCATE "users","posts" RESTART IDENTITY CASCADE`).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, sm.ResetTables(context.Background(), "users", "posts"))
+	assert.Equal(t, errors.New("error"), sm.ResetTables(context.Background(), "koo", "loo"))
+	assert.Nil(t, sp.ResetTables(context.Background(), "users", "posts"))
+	assert.Nil(t, sp.ResetTables(context.Background()))
+
+	// the checks are restored after ctx is done, and the session is
+	// discarded if they can't be
+	ctx, cancel := context.WithCancel(context.Background())
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 0").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("TRUNCATE TABLE `users`").ExpectExec().WillDelayFor(50 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("SET FOREIGN_KEY_CHECKS = 1").ExpectExec().WillReturnError(errors.New("restore"))
+	mMock.ExpectClose()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	assert.Equal(t, context.Canceled, sm.ResetTables(ctx, "users"))
+	assert.Equal(t, 0, mDb.Stats().OpenConnections)
+
+	assert.True(t, errors.Is(sp.Clone(WithPolicy(NoDDL())).ResetTables(context.Background(), "users"), ErrRestricted))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
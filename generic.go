@@ -0,0 +1,98 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ScannerOf is a Scanner returning T, for the generic Select, GetRow and
+// QueryEach.
+type ScannerOf[T any] func(*sql.Rows) (T, error)
+
+func (scan ScannerOf[T]) untyped() Scanner {
+	if scan == nil {
+		return nil
+	}
+
+	return func(rows *sql.Rows) (interface{}, error) {
+		return scan(rows)
+	}
+}
+
+// Select is DB.Select returning the rows as []T.
+func Select[T any](db *DB, scan ScannerOf[T], query string, args ...interface{}) ([]T, error) {
+	return SelectContext(context.Background(), db, scan, query, args...)
+}
+func SelectContext[T any](ctx context.Context, db *DB, scan ScannerOf[T], query string, args ...interface{}) ([]T, error) {
+	results, err := db.SelectContext(ctx, scan.untyped(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := make([]T, len(results))
+	for i, result := range results {
+		typed[i] = result.(T)
+	}
+
+	return typed, nil
+}
+
+// GetRow is DB.GetRow returning the row as T.
+func GetRow[T any](db *DB, scan ScannerOf[T], query string, args ...interface{}) (T, error) {
+	return GetRowContext(context.Background(), db, scan, query, args...)
+}
+func GetRowContext[T any](ctx context.Context, db *DB, scan ScannerOf[T], query string, args ...interface{}) (T, error) {
+	var zero T
+	result, err := db.GetRowContext(ctx, scan.untyped(), query, args...)
+	if err != nil {
+		return zero, err
+	}
+
+	return result.(T), nil
+}
+
+// QueryEach calls fn with every row of query parsed by scan without
+// collecting them, stopping at the first error of scan or fn.
+func QueryEach[T any](db *DB, scan ScannerOf[T], fn func(T) error, query string, args ...interface{}) error {
+	return QueryEachContext(context.Background(), db, scan, fn, query, args...)
+}
+func QueryEachContext[T any](ctx context.Context, db *DB, scan ScannerOf[T], fn func(T) error, query string, args ...interface{}) error {
+	ctx, e, err := db.begin(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	var rows *sql.Rows
+	if err := db.run(ctx, e, db.querier(ctx, db.DB, &rows)); err != nil {
+		return db.end(ctx, e, err)
+	}
+
+	return db.end(ctx, e, each(rows, scan, fn, &e.Rows))
+}
+
+// each calls fn with the rows parsed by scan, counting them in n, and
+// closes rows.
+func each[T any](rows *sql.Rows, scan ScannerOf[T], fn func(T) error, n *int) error {
+	if rows == nil {
+		return ErrNilRows
+	}
+	defer rows.Close()
+
+	if scan == nil {
+		return ErrNilScanner
+	}
+
+	for rows.Next() {
+		result, err := scan(rows)
+		if err != nil {
+			return err
+		}
+
+		*n++
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,57 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneric(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	scanInt := ScannerOf[int](func(rows *sql.Rows) (int, error) {
+		var i int
+		return i, rows.Scan(&i)
+	})
+
+	prepared := mock.ExpectPrepare(`^select i from foo where i in \(\$1,\$2,\$3\)$`)
+	prepared.ExpectQuery().WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2).AddRow(3))
+	prepared.ExpectQuery().WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2).AddRow(3))
+	prepared.ExpectQuery().WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2).AddRow(3))
+	prepared.ExpectQuery().WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"i"}))
+
+	ints, err := Select(s, scanInt, "select i from foo where i in (?)", []int{1, 2, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ints)
+
+	var seen []int
+	errStop := errors.New("stop")
+	err = QueryEach(s, scanInt, func(i int) error {
+		seen = append(seen, i)
+		if i == 2 {
+			return errStop
+		}
+
+		return nil
+	}, "select i from foo where i in (?)", []int{1, 2, 3})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []int{1, 2}, seen)
+
+	i, err := GetRow(s, scanInt, "select i from foo where i in (?)", []int{1, 2, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, i)
+
+	_, err = GetRow(s, scanInt, "select i from foo where i in (?)", []int{1, 2, 3})
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	mock.ExpectPrepare(`^select 1$`).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	_, err = Select[int](s, nil, "select 1")
+	assert.Equal(t, ErrNilScanner, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
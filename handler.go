@@ -0,0 +1,47 @@
+package sqlpp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type handlerReport struct {
+	Ping     string         `json:"ping"`
+	Stats    Stats          `json:"stats"`
+	Profiles []QueryProfile `json:"profiles,omitempty"`
+}
+
+// Handler returns an http.Handler reporting the ping status, pool and
+// stmt cache stats as json, and the top fingerprints of profiler if it's
+// not nil, 10 or the top query param many. It responds 503 when the ping
+// fails, so it can serve health checks too.
+func (sqlpp *DB) Handler(profiler *Profiler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		status := http.StatusOK
+		report := handlerReport{Ping: "ok"}
+		if err := sqlpp.PingContext(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			report.Ping = err.Error()
+		}
+
+		report.Stats = sqlpp.SampleStats()
+		if profiler != nil {
+			top, err := strconv.Atoi(r.URL.Query().Get("top"))
+			if err != nil || top <= 0 {
+				top = 10
+			}
+
+			report.Profiles = profiler.Top(top)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
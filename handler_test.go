@@ -0,0 +1,50 @@
+package sqlpp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Handler(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	p := NewProfiler(0)
+	for _, query := range []string{"select 1", "select 2", "select * from foo"} {
+		p.Hook(context.Background(), &QueryEvent{Query: query, Duration: time.Millisecond})
+	}
+
+	mock.ExpectPing()
+	mock.ExpectPing().WillReturnError(errors.New("down"))
+
+	w := httptest.NewRecorder()
+	s.Handler(p).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/sqlpp?top=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var report map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "ok", report["ping"])
+	assert.Len(t, report["profiles"], 1)
+	assert.Contains(t, report["stats"], "OpenConnections")
+	assert.Contains(t, report["stats"], "Cache")
+
+	w = httptest.NewRecorder()
+	s.Handler(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/sqlpp", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	report = nil
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "down", report["ping"])
+	assert.NotContains(t, report, "profiles")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,84 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// QueryEvent describes a query that ran on the db. Query and Args are as
+// the caller passed them, Statement is the transformed query sent to the db.
+type QueryEvent struct {
+	Query     string
+	Args      []interface{}
+	Statement string
+
+	Start    time.Time
+	Duration time.Duration
+
+	// Prepares is the number of stmts the query prepared, 0 if it used a
+	// cached one or ran without one.
+	Prepares int
+
+	// Result is set by Exec, Rows is the number of rows Query returned.
+	Result sql.Result
+	Rows   int
+	Err    error
+
+	// the scanned rows of Query or the dest of QueryRow, for change events
+	returned []interface{}
+
+	// the caller's ctx, and untracking the query from Shutdown
+	parent context.Context
+	done   func()
+}
+
+// Hook is called after every Exec, QueryRow and Query that reached the db,
+// results read from the result cache don't fire hooks. Hooks run on the
+// goroutine of the query and must not keep e.Args.
+type Hook func(ctx context.Context, e *QueryEvent)
+
+// WithHook adds hooks called after each query.
+func WithHook(hooks ...Hook) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.hooks = append(sqlpp.cfg.hooks, hooks...)
+	}
+}
+
+// begin starts the event of a query, returning the ctx to run it with.
+// Every begin without an error must be followed by an end.
+func (sqlpp *DB) begin(ctx context.Context, query string, args []interface{}) (context.Context, *QueryEvent, error) {
+	parent := ctx
+	ctx, done, err := sqlpp.track(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	if l := sqlpp.config().limiter; l != nil {
+		if err := l.acquire(ctx, sqlpp.priority(ctx)); err != nil {
+			err = timeoutError(parent, ctx, err)
+			done()
+			return ctx, nil, err
+		}
+
+		untrack := done
+		done = func() {
+			l.release()
+			untrack()
+		}
+	}
+
+	return ctx, &QueryEvent{Query: query, Args: args, Start: time.Now(), parent: parent, done: done}, nil
+}
+
+func (sqlpp *DB) end(ctx context.Context, e *QueryEvent, err error) error {
+	err = sqlpp.classify(timeoutError(e.parent, ctx, err))
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	for _, hook := range sqlpp.config().hooks {
+		hook(ctx, e)
+	}
+
+	e.done()
+	return err
+}
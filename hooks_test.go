@@ -0,0 +1,65 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var events []QueryEvent
+	s := NewPostgreSQL(db, WithHook(func(ctx context.Context, e *QueryEvent) {
+		events = append(events, *e)
+	}), WithResultCache(NewLRUCache(0)))
+
+	mock.ExpectPrepare(`^update foo set i = \$1$`).
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectPrepare(`^select i from foo where i in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	mock.ExpectPrepare(`^select i from foo where j = \$1$`).
+		ExpectQuery().WithArgs(3).WillReturnError(errors.New("boom"))
+
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	_, err = s.Exec("update foo set i = ?", 1)
+	assert.Nil(t, err)
+
+	ctx := Cache(context.Background(), time.Minute)
+	for i := 0; i < 2; i++ {
+		_, err = s.QueryContext(ctx, "select i from foo where i in (?)", s.Args([]int{1, 2}), scanner)
+		assert.Nil(t, err)
+	}
+
+	var i int
+	assert.EqualError(t, s.Compile("select i from foo where j = ?").QueryRow(s.Args(3), &i), "boom")
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, "update foo set i = ?", events[0].Query)
+	assert.Equal(t, "update foo set i = $1", events[0].Statement)
+	affected, _ := events[0].Result.RowsAffected()
+	assert.Equal(t, int64(3), affected)
+
+	assert.Equal(t, "select i from foo where i in (?)", events[1].Query)
+	assert.Equal(t, "select i from foo where i in ($1,$2)", events[1].Statement)
+	assert.Equal(t, []interface{}{[]int{1, 2}}, events[1].Args)
+	assert.Equal(t, 2, events[1].Rows)
+
+	assert.Equal(t, "select i from foo where j = $1", events[2].Statement)
+	assert.EqualError(t, events[2].Err, "boom")
+	for _, e := range events {
+		assert.False(t, e.Start.IsZero())
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
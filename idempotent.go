@@ -0,0 +1,106 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	errNoLastInsertID = errors.New("sqlpp: no last insert id recorded")
+)
+
+// idempotentResult is the result recorded for an idempotency key.
+type idempotentResult struct {
+	lastInsertID sql.NullInt64
+	rowsAffected sql.NullInt64
+}
+
+func (r idempotentResult) LastInsertId() (int64, error) {
+	if !r.lastInsertID.Valid {
+		return 0, errNoLastInsertID
+	}
+
+	return r.lastInsertID.Int64, nil
+}
+
+func (r idempotentResult) RowsAffected() (int64, error) {
+	return r.rowsAffected.Int64, nil
+}
+
+// ExecIdempotent runs query once per key. The key is recorded in the
+// sqlpp_idempotency table, see CreateIdempotencyTable, in the transaction
+// of query, so retries of a write that committed don't run it again and
+// return the result of the first run instead. Its statements run through
+// the policies and hooks of the db. Concurrent runs with the
+// same key wait for the first to commit or roll back. Old keys can be
+// removed by their created column, unix microseconds, with PurgeOldRows.
+func (sqlpp *DB) ExecIdempotent(ctx context.Context, key, query string, args ...interface{}) (sql.Result, error) {
+	if err := sqlpp.helperSQL(); err != nil {
+		return nil, err
+	}
+
+	claim := "INSERT IGNORE INTO sqlpp_idempotency (idempotency_key, created) VALUES (?,?)"
+	if sqlpp.dialect.flavor == postgresFlavor {
+		claim = "INSERT INTO sqlpp_idempotency (idempotency_key, created) VALUES (?,?) ON CONFLICT DO NOTHING"
+	}
+
+	var result sql.Result
+	err := sqlpp.WithTx(ctx, nil, func(tx *Tx) error {
+		claimed, err := tx.ExecContext(ctx, claim, key, time.Now().UnixMicro())
+		if err != nil {
+			return err
+		}
+
+		if n, err := claimed.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			result, err = idempotentResultOf(ctx, tx, key)
+			return err
+		}
+
+		if result, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+
+		var recorded idempotentResult
+		if sqlpp.dialect.lastInsertID {
+			if id, err := result.LastInsertId(); err == nil {
+				recorded.lastInsertID = sql.NullInt64{Int64: id, Valid: true}
+			}
+		}
+
+		if n, err := result.RowsAffected(); err == nil {
+			recorded.rowsAffected = sql.NullInt64{Int64: n, Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE sqlpp_idempotency SET last_insert_id = ?, rows_affected = ? WHERE idempotency_key = ?",
+			recorded.lastInsertID, recorded.rowsAffected, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func idempotentResultOf(ctx context.Context, tx *Tx, key string) (sql.Result, error) {
+	var recorded idempotentResult
+	err := tx.RowContext(ctx, "SELECT last_insert_id, rows_affected FROM sqlpp_idempotency WHERE idempotency_key = ?", key).
+		Scan(&recorded.lastInsertID, &recorded.rowsAffected)
+	if err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// CreateIdempotencyTable creates the sqlpp_idempotency table of
+// ExecIdempotent if it doesn't exist.
+func (sqlpp *DB) CreateIdempotencyTable(ctx context.Context) error {
+	_, err := sqlpp.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS sqlpp_idempotency (idempotency_key VARCHAR(255) PRIMARY KEY, "+
+		"last_insert_id BIGINT, rows_affected BIGINT, created BIGINT NOT NULL)")
+	return err
+}
@@ -0,0 +1,76 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_ExecIdempotent(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	var queries []string
+	m := NewMySQL(db, WithHook(func(ctx context.Context, e *QueryEvent) {
+		queries = append(queries, e.Query)
+	}))
+	p := NewPostgreSQL(db)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT IGNORE INTO sqlpp_idempotency (idempotency_key, created) VALUES (?,?)").ExpectExec().
+		WithArgs("k1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("insert into orders (user_id, total) values (?, ?)").ExpectExec().
+		WithArgs(5, 10).WillReturnResult(sqlmock.NewResult(42, 1))
+	mock.ExpectPrepare("UPDATE sqlpp_idempotency SET last_insert_id = ?, rows_affected = ? WHERE idempotency_key = ?").ExpectExec().
+		WithArgs(42, 1, "k1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT IGNORE INTO sqlpp_idempotency (idempotency_key, created) VALUES (?,?)").ExpectExec().
+		WithArgs("k1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("SELECT last_insert_id, rows_affected FROM sqlpp_idempotency WHERE idempotency_key = ?").ExpectQuery().
+		WithArgs("k1").WillReturnRows(sqlmock.NewRows([]string{"last_insert_id", "rows_affected"}).AddRow(42, 1))
+	mock.ExpectCommit()
+
+	for i := 0; i < 2; i++ {
+		result, err := m.ExecIdempotent(ctx, "k1", "insert into orders (user_id, total) values (?, ?)", 5, 10)
+		assert.Nil(t, err)
+
+		id, err := result.LastInsertId()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), id)
+
+		n, err := result.RowsAffected()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), n)
+	}
+	assert.Len(t, queries, 5)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO sqlpp_idempotency (idempotency_key, created) VALUES ($1,$2) ON CONFLICT DO NOTHING").ExpectExec().
+		WithArgs("k2", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("SELECT last_insert_id, rows_affected FROM sqlpp_idempotency WHERE idempotency_key = $1").ExpectQuery().
+		WithArgs("k2").WillReturnRows(sqlmock.NewRows([]string{"last_insert_id", "rows_affected"}).AddRow(nil, 3))
+	mock.ExpectCommit()
+
+	result, err := p.ExecIdempotent(ctx, "k2", "update orders set paid = true where id in (?)", []int{1, 2, 3})
+	assert.Nil(t, err)
+
+	_, err = result.LastInsertId()
+	assert.Equal(t, errNoLastInsertID, err)
+	n, err := result.RowsAffected()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+
+	// policies apply
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_, err = m.Clone(WithPolicy(ReadOnly())).ExecIdempotent(ctx, "k3", "DELETE FROM users")
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
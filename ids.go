@@ -0,0 +1,240 @@
+package sqlpp
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClockBackwards = errors.New("sqlpp: clock moved backwards")
+)
+
+// IDGenerator returns the next id of the named sequence.
+type IDGenerator interface {
+	NextID(ctx context.Context, name string) (int64, error)
+}
+
+// WithIDGenerator makes Insert fill the zero integer key fields of structs
+// with the next id of g named by the table, and their empty string key
+// fields with a UUIDv7. A postgres DB takes the id from the sequence of the
+// key column.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.ids = g
+	}
+}
+
+// NextID returns the next value of the named sequence. Postgres uses the
+// sequence, mysql a row of the sqlpp_ids table, see CreateIDTable, that
+// starts at 1.
+func (sqlpp *DB) NextID(ctx context.Context, name string) (int64, error) {
+	if err := sqlpp.helperSQL(); err != nil {
+		return 0, err
+	}
+
+	if sqlpp.dialect.flavor == postgresFlavor {
+		var id int64
+		err := sqlpp.RowContext(ctx, "SELECT nextval(?::regclass)", name).Scan(&id)
+		return id, err
+	}
+
+	// mysql returns the value set by LAST_INSERT_ID(expr) as the insert id
+	result, err := sqlpp.ExecContext(ctx, "INSERT INTO sqlpp_ids (name, id) VALUES (?, LAST_INSERT_ID(1))"+
+		" ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id + 1)", name)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// columnIDGenerator returns the next id of a key column, for generators
+// whose sequences belong to columns rather than tables.
+type columnIDGenerator interface {
+	nextColumnID(ctx context.Context, table, column string) (int64, error)
+}
+
+// nextColumnID takes the id from the serial or identity sequence of column
+// on postgres, falling back to the default table_column_seq name, and from
+// the table sequence otherwise.
+func (sqlpp *DB) nextColumnID(ctx context.Context, table, column string) (int64, error) {
+	if sqlpp.dialect.flavor != postgresFlavor {
+		return sqlpp.NextID(ctx, table)
+	}
+
+	var id int64
+	err := sqlpp.RowContext(ctx, "SELECT nextval(COALESCE(pg_get_serial_sequence(?, ?), ?)::regclass)",
+		table, column, table+"_"+column+"_seq").Scan(&id)
+	return id, err
+}
+
+// CreateIDTable creates the sqlpp_ids table of NextID on mysql if it doesn't
+// exist.
+func (sqlpp *DB) CreateIDTable(ctx context.Context) error {
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	}
+
+	if sqlpp.dialect.flavor == postgresFlavor {
+		return nil
+	}
+
+	_, err := sqlpp.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS sqlpp_ids (name VARCHAR(255) PRIMARY KEY, id BIGINT NOT NULL)")
+	return err
+}
+
+// snowflakeEpoch is the start of Snowflake timestamps, 2020-01-01 UTC.
+const snowflakeEpoch = 1577836800000
+
+// Snowflake generates ids ordered by time without a db round trip: 41 bits
+// of milliseconds, 10 bits of node and a 12 bit counter. Every process
+// needs its own node. It ignores the sequence name.
+type Snowflake struct {
+	node int64
+
+	mu       sync.Mutex
+	last     int64
+	sequence int64
+}
+
+// NewSnowflake returns a Snowflake of node, using its low 10 bits.
+func NewSnowflake(node int64) *Snowflake {
+	return &Snowflake{node: node & 0x3ff}
+}
+
+func (s *Snowflake) NextID(ctx context.Context, name string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli() - snowflakeEpoch
+	if now < s.last {
+		return 0, ErrClockBackwards
+	}
+
+	if now == s.last {
+		s.sequence = (s.sequence + 1) & 0xfff
+		// out of ids in this millisecond
+		for s.sequence == 0 && now <= s.last {
+			time.Sleep(100 * time.Microsecond)
+			now = time.Now().UnixMilli() - snowflakeEpoch
+		}
+	} else {
+		s.sequence = 0
+	}
+
+	s.last = now
+	return now<<22 | s.node<<12 | s.sequence, nil
+}
+
+// UUIDv7 returns a random UUID ordered by its millisecond timestamp. It
+// panics if the system has no randomness to read.
+func UUIDv7() string {
+	u, err := newUUIDv7()
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+func newUUIDv7() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return "", err
+	}
+
+	ms := time.Now().UnixMilli()
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+}
+
+// Insert inserts the struct v, or v points to, into table, with the
+// ColumnsOf v. Key fields are filled with WithIDGenerator ids first when
+// v is a pointer.
+func (sqlpp *DB) Insert(table string, v interface{}) (sql.Result, error) {
+	return sqlpp.InsertContext(context.Background(), table, v)
+}
+func (sqlpp *DB) InsertContext(ctx context.Context, table string, v interface{}) (sql.Result, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields := fieldsOf(rv.Type())
+	pk := hasPK(fields)
+
+	columns := make([]string, len(fields))
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		value := rv.FieldByIndex(f.index)
+		if f.isKey(pk) && value.CanSet() && value.IsZero() {
+			if err := sqlpp.fillID(ctx, table, f.column, value); err != nil {
+				return nil, err
+			}
+		}
+
+		columns[i] = sqlpp.QuoteIdent(f.column)
+		values[i] = sqlpp.fieldArg(table, f, rv)
+	}
+
+	return sqlpp.ExecContext(ctx, "INSERT INTO "+sqlpp.QuoteIdent(table)+" ("+strings.Join(columns, ", ")+") VALUES (?)", values)
+}
+
+func (sqlpp *DB) fillID(ctx context.Context, table, column string, value reflect.Value) error {
+	ids := sqlpp.config().ids
+	if ids == nil {
+		return nil
+	}
+
+	nextID := func() (int64, error) {
+		if g, ok := ids.(columnIDGenerator); ok {
+			return g.nextColumnID(ctx, table, column)
+		}
+
+		return ids.NextID(ctx, table)
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		id, err := nextID()
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(id)
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		id, err := nextID()
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(uint64(id))
+	case reflect.String:
+		id, err := newUUIDv7()
+		if err != nil {
+			return err
+		}
+
+		value.SetString(id)
+	}
+
+	return nil
+}
+
+var (
+	_ IDGenerator = (*DB)(nil)
+	_ IDGenerator = (*Snowflake)(nil)
+
+	_ columnIDGenerator = (*DB)(nil)
+)
@@ -0,0 +1,117 @@
+package sqlpp
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type idDoc struct {
+	ID    int64  `db:"id"`
+	Title string `db:"title"`
+}
+
+type uuidDoc struct {
+	Key  string `db:"key,pk"`
+	Body []byte `db:"body"`
+}
+
+func TestDB_NextID(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+
+	mock.ExpectPrepare("INSERT INTO sqlpp_ids (name, id) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id + 1)").
+		ExpectExec().WithArgs("docs").WillReturnResult(sqlmock.NewResult(7, 2))
+	mock.ExpectPrepare("SELECT nextval($1::regclass)").
+		ExpectQuery().WithArgs("docs_id_seq").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(8))
+
+	id, err := m.NextID(ctx, "docs")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), id)
+
+	id, err = p.NextID(ctx, "docs_id_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), id)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSnowflake(t *testing.T) {
+	s := NewSnowflake(5)
+	last := int64(0)
+	for i := 0; i < 10000; i++ {
+		id, err := s.NextID(context.Background(), "")
+		assert.Nil(t, err)
+		assert.Greater(t, id, last)
+		assert.Equal(t, int64(5), id>>12&0x3ff)
+		last = id
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	a, b := UUIDv7(), UUIDv7()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), a)
+	assert.NotEqual(t, a, b)
+	assert.LessOrEqual(t, a[:13], b[:13])
+}
+
+func TestDB_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db, WithIDGenerator(NewMySQL(db)))
+
+	mock.ExpectPrepare("INSERT INTO `docs` (`id`, `title`) VALUES (?,?)").
+		ExpectExec().WithArgs(0, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO sqlpp_ids (name, id) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id + 1)").
+		ExpectExec().WithArgs("docs").WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectPrepare(`INSERT INTO "docs" ("id", "title") VALUES ($1,$2)`).
+		ExpectExec().WithArgs(9, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`INSERT INTO "blobs" ("key", "body") VALUES ($1,$2)`).
+		ExpectExec().WithArgs(sqlmock.AnyArg(), []byte("c")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = m.InsertContext(ctx, "docs", &idDoc{Title: "a"})
+	assert.Nil(t, err)
+
+	doc := &idDoc{Title: "b"}
+	_, err = p.Insert("docs", doc)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), doc.ID)
+
+	blob := &uuidDoc{Body: []byte("c")}
+	_, err = p.InsertContext(ctx, "blobs", blob)
+	assert.Nil(t, err)
+	assert.Len(t, blob.Key, 36)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Insert_postgres(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	p := NewPostgreSQL(db)
+	p = p.Clone(WithIDGenerator(p))
+
+	// the sequence of the key column, not the table
+	mock.ExpectPrepare("SELECT nextval(COALESCE(pg_get_serial_sequence($1, $2), $3)::regclass)").
+		ExpectQuery().WithArgs("docs", "id", "docs_id_seq").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(4))
+	mock.ExpectPrepare(`INSERT INTO "docs" ("id", "title") VALUES ($1,$2)`).
+		ExpectExec().WithArgs(4, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	doc := &idDoc{Title: "a"}
+	_, err = p.Insert("docs", doc)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), doc.ID)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
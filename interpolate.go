@@ -0,0 +1,211 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// Deprecated: use ErrArgCountMismatch.
+	ErrInterpolateArgCount = ErrArgCountMismatch
+)
+
+type InterpolateError struct {
+	Index int
+	Arg   interface{}
+	Err   error
+}
+
+func (e *InterpolateError) Error() string {
+	return fmt.Sprintf("sqlpp: cannot interpolate argument %d (%T): %v", e.Index, e.Arg, e.Err)
+}
+
+func (e *InterpolateError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errInterpolateType    = errors.New("unsupported type")
+	errInterpolateNaN     = errors.New("non-finite float")
+	errInterpolateNul     = errors.New("string contains nul byte")
+	errInterpolateNotUTF8 = errors.New("string is not valid utf-8")
+)
+
+func (sqlpp *DB) interpolation(query string, args []interface{}) (string, error) {
+	query, args = sqlpp.transform(query, args)
+	return sqlpp.literals(query, args)
+}
+
+// literals replaces the placeholders of the transformed query with args.
+func (sqlpp *DB) literals(query string, args []interface{}) (string, error) {
+	var b strings.Builder
+	next := 0
+	numbered := sqlpp.dialect.numbered
+	for i := 0; i < len(query); i++ {
+		index := -1
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			// placeholders don't go in strings and quoted names, e.g.
+			// '10:30' on oracle
+			j := skipQuoted(query, i, c, c == '\'' && sqlpp.dialect.backslashStrings())
+			b.WriteString(query[i:j])
+			i = j - 1
+			continue
+
+		case c == '?' && numbered == "":
+			index = next
+			next++
+
+		case numbered != "" && strings.HasPrefix(query[i:], numbered):
+			j := i + len(numbered)
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+
+			if j > i+len(numbered) {
+				n, _ := strconv.Atoi(query[i+len(numbered) : j])
+				index = n - 1
+				i = j - 1
+				if n > next {
+					next = n
+				}
+			}
+		}
+
+		if index == -1 {
+			b.WriteByte(query[i])
+			continue
+		}
+
+		if index >= len(args) {
+			return "", ErrInterpolateArgCount
+		}
+
+		literal, err := sqlpp.literal(args[index])
+		if err != nil {
+			return "", &InterpolateError{Index: index, Arg: args[index], Err: err}
+		}
+
+		b.WriteString(literal)
+	}
+
+	if next != len(args) {
+		return "", ErrInterpolateArgCount
+	}
+
+	return b.String(), nil
+}
+
+func (sqlpp *DB) literal(arg interface{}) (string, error) {
+	if named, ok := arg.(sql.NamedArg); ok {
+		arg = named.Value
+	}
+
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		arg = v
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case []byte:
+		if v == nil {
+			return "NULL", nil
+		}
+
+		return fmt.Sprintf(sqlpp.dialect.bytesFormat, hex.EncodeToString(v)), nil
+	case time.Time:
+		return sqlpp.dialect.timePrefix + "'" + sqlpp.timestamp(v) + "'", nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+
+		return sqlpp.literal(rv.Elem().Interface())
+	case reflect.Bool:
+		if sqlpp.dialect.bitBooleans {
+			if rv.Bool() {
+				return "1", nil
+			}
+
+			return "0", nil
+		}
+
+		if rv.Bool() {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", errInterpolateNaN
+		}
+
+		return strconv.FormatFloat(f, 'g', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return sqlpp.quote(rv.String())
+	}
+
+	return "", errInterpolateType
+}
+
+// timestamp formats t the way the db parses datetime strings, in UTC on
+// mysql as its datetime has no zone.
+func (sqlpp *DB) timestamp(t time.Time) string {
+	if sqlpp.dialect.zonedTimes {
+		return t.Format("2006-01-02 15:04:05.999999Z07:00")
+	}
+
+	return t.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
+// quote doubles quotes instead of backslash escaping them, so the literal
+// stays closed whether or not the server treats backslash as an escape.
+// Backslashes are kept as is on databases never treating them as one.
+func (sqlpp *DB) quote(s string) (string, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return "", errInterpolateNul
+	} else if !utf8.ValidString(s) {
+		return "", errInterpolateNotUTF8
+	}
+
+	if sqlpp.dialect.backslashQuotes {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return "'" + strings.ReplaceAll(s, "'", `\'`) + "'", nil
+	}
+
+	s = strings.ReplaceAll(s, "'", "''")
+	if !strings.Contains(s, `\`) || !sqlpp.dialect.backslashEscapes {
+		return sqlpp.dialect.stringPrefix + "'" + s + "'", nil
+	}
+
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	if sqlpp.dialect.escapeStrings {
+		return "E'" + s + "'", nil
+	}
+
+	return "'" + s + "'", nil
+}
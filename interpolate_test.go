@@ -0,0 +1,141 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_interpolation(t *testing.T) {
+	str := "str"
+	ts := time.Date(2021, 2, 3, 4, 5, 6, 7000, time.UTC)
+
+	cases := []struct {
+		query     string
+		args      []interface{}
+		eSqlQuery string
+		ePgQuery  string
+		err       error
+	}{
+		{
+			"select * from foo", nil,
+			"select * from foo",
+			"select * from foo",
+			nil,
+		}, {
+			"select * from foo where i = ? and j in (?)", []interface{}{1, []int{-2, 3}},
+			"select * from foo where i = 1 and j in (-2,3)",
+			"select * from foo where i = 1 and j in (-2,3)",
+			nil,
+		}, {
+			"select * from foo where i = ? and j = ? and k = ? and l = ?", []interface{}{nil, true, 1.5, uint8(2)},
+			"select * from foo where i = NULL and j = TRUE and k = 1.5 and l = 2",
+			"select * from foo where i = NULL and j = TRUE and k = 1.5 and l = 2",
+			nil,
+		}, {
+			"select * from foo where i = ? and j = ?", []interface{}{"it's", `a\b`},
+			`select * from foo where i = 'it''s' and j = 'a\\b'`,
+			`select * from foo where i = 'it''s' and j = E'a\\b'`,
+			nil,
+		}, {
+			"select * from foo where i = ? and j = ? and k = ?", []interface{}{[]byte{0xde, 0xad}, &str, ts},
+			"select * from foo where i = X'dead' and j = 'str' and k = '2021-02-03 04:05:06.000007'",
+			"select * from foo where i = decode('dead','hex') and j = 'str' and k = '2021-02-03 04:05:06.000007Z'",
+			nil,
+		}, {
+			"select * from foo where i = ?", []interface{}{"a\x00b"},
+			"", "",
+			errInterpolateNul,
+		}, {
+			"select * from foo where i = ?", []interface{}{"\xbf\x27"},
+			"", "",
+			errInterpolateNotUTF8,
+		}, {
+			"select * from foo where i = ?", []interface{}{math.NaN()},
+			"", "",
+			errInterpolateNaN,
+		}, {
+			"select * from foo where i = ?", []interface{}{struct{}{}},
+			"", "",
+			errInterpolateType,
+		}, {
+			"select * from foo where i = ? and j = ?", []interface{}{1},
+			"", "",
+			ErrInterpolateArgCount,
+		}, {
+			"select * from foo where i = ?", []interface{}{1, 2},
+			"", "",
+			ErrInterpolateArgCount,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s#%+v", c.query, c.args), func(t *testing.T) {
+			m := NewMySQL(nil)
+			p := NewPostgreSQL(nil)
+
+			mq, me := m.interpolation(c.query, c.args)
+			pq, pe := p.interpolation(c.query, c.args)
+
+			assert.True(t, errors.Is(me, c.err))
+			assert.True(t, errors.Is(pe, c.err))
+			assert.Equal(t, c.eSqlQuery, mq)
+			assert.Equal(t, c.ePgQuery, pq)
+		})
+	}
+}
+
+func TestDB_Interpolate(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New()
+	pDb, pMock, pErr := sqlmock.New()
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb, WithInterpolation())
+	sp := NewPostgreSQL(pDb)
+	ctx := Interpolate(context.Background())
+
+	mMock.ExpectExec("^update foo set i = 1 where j in \\('a','b'\\)$").WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	pMock.ExpectExec("^update foo set i = 1 where j in \\('a','b'\\)$").WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	mMock.ExpectQuery("^select i from foo where j = 'a'$").WithArgs().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	pMock.ExpectQuery("^select i from foo where j = 'a'$").WithArgs().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mMock.ExpectQuery("^select i from foo$").WithArgs().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	pMock.ExpectQuery("^select i from foo$").WithArgs().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+
+	_, em := sm.Exec("update foo set i = ? where j in (?)", 1, []string{"a", "b"})
+	_, ep := sp.ExecContext(ctx, "update foo set i = ? where j in (?)", 1, []string{"a", "b"})
+	assert.Nil(t, em)
+	assert.Nil(t, ep)
+
+	var im, ip int
+	assert.Nil(t, sm.QueryRow("select i from foo where j = ?", sm.Args("a"), &im))
+	assert.Nil(t, sp.QueryRowContext(ctx, "select i from foo where j = ?", sp.Args("a"), &ip))
+	assert.Equal(t, 1, im)
+	assert.Equal(t, 1, ip)
+
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	rm, em := sm.Query("select i from foo", nil, scanner)
+	rp, ep := sp.QueryContext(ctx, "select i from foo", nil, scanner)
+	assert.Nil(t, em)
+	assert.Nil(t, ep)
+	assert.Equal(t, []interface{}{1, 2}, rm)
+	assert.Equal(t, []interface{}{1, 2}, rp)
+
+	_, em = sm.Exec("update foo set i = ?", struct{}{})
+	assert.True(t, errors.Is(em, errInterpolateType))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
@@ -0,0 +1,162 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ArgSizeError is returned for a query whose args are over the limits of
+// WithMaxArgSize or WithMaxArgsSize. Index is of the arg after (?) slices
+// are expanded, -1 when the total is over the limit.
+type ArgSizeError struct {
+	Index int
+	Size  int
+	Limit int
+}
+
+func (e *ArgSizeError) Error() string {
+	if e.Index == -1 {
+		return fmt.Sprintf("sqlpp: arguments total %d bytes, over the %d bytes limit", e.Size, e.Limit)
+	}
+
+	return fmt.Sprintf("sqlpp: argument %d is %d bytes, over the %d bytes limit", e.Index, e.Size, e.Limit)
+}
+
+// TooManyParamsError is ErrTooManyParams of a query whose slice args
+// expand to more placeholders than the db allows, failing before the
+// query is built. Index is of the slice arg going over the limit.
+type TooManyParamsError struct {
+	Index  int
+	Params int
+	Limit  int
+}
+
+func (e *TooManyParamsError) Error() string {
+	return fmt.Sprintf("sqlpp: too many placeholders: slice argument %d expands the query to %d, over the %d limit",
+		e.Index, e.Params, e.Limit)
+}
+
+func (e *TooManyParamsError) Is(target error) bool {
+	return target == ErrTooManyParams
+}
+
+// WithMaxArgSize rejects queries with a string or []byte arg longer than n
+// bytes, Valuers are measured by their value.
+func WithMaxArgSize(n int) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.maxArgSize = n
+	}
+}
+
+// WithMaxArgsSize rejects queries whose string and []byte args total more
+// than n bytes.
+func WithMaxArgsSize(n int) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.maxArgsSize = n
+	}
+}
+
+// checkParams counts the placeholders args expand the (?) of query to,
+// on a dialect with a limit low enough for slices to go over it.
+func (sqlpp *DB) checkParams(query string, args []interface{}) error {
+	if !sqlpp.dialect.checkParams || !strings.Contains(query, "(?)") {
+		return nil
+	}
+
+	nested, max := sqlpp.config().flattenNested, sqlpp.maxParams()
+	params, index := 0, -1
+	for i, arg := range args {
+		arg = encoded(arg)
+		if !expands(arg) {
+			params++
+			continue
+		}
+
+		if params += countElems(reflect.ValueOf(arg), nested); params > max && index == -1 {
+			index = i
+		}
+	}
+
+	if index != -1 {
+		return &TooManyParamsError{Index: index, Params: params, Limit: max}
+	}
+
+	return nil
+}
+
+// countElems counts the elements appendElems appends for v.
+func countElems(v reflect.Value, nested bool) int {
+	n := 0
+	for i := 0; i < v.Len(); i++ {
+		if elem := encoded(v.Index(i).Interface()); nested && expands(elem) {
+			n += countElems(reflect.ValueOf(elem), nested)
+		} else {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (sqlpp *DB) checkArgs(args []interface{}) error {
+	cfg := sqlpp.config()
+	if cfg.maxArgSize <= 0 && cfg.maxArgsSize <= 0 {
+		return nil
+	}
+
+	total := 0
+	for i, arg := range args {
+		size := argSize(arg)
+		if cfg.maxArgSize > 0 && size > cfg.maxArgSize {
+			return &ArgSizeError{Index: i, Size: size, Limit: cfg.maxArgSize}
+		}
+
+		total += size
+	}
+
+	if cfg.maxArgsSize > 0 && total > cfg.maxArgsSize {
+		return &ArgSizeError{Index: -1, Size: total, Limit: cfg.maxArgsSize}
+	}
+
+	return nil
+}
+
+func argSize(arg interface{}) int {
+	if named, ok := arg.(sql.NamedArg); ok {
+		arg = named.Value
+	}
+
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			// the driver reports the error
+			return 0
+		}
+
+		arg = v
+	}
+
+	switch v := arg.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case nil:
+		return 0
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch {
+	case rv.Kind() == reflect.String:
+		return rv.Len()
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		return rv.Len()
+	case rv.Kind() == reflect.Ptr && !rv.IsNil():
+		return argSize(rv.Elem().Interface())
+	}
+
+	return 0
+}
@@ -0,0 +1,50 @@
+package sqlpp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_checkArgs(t *testing.T) {
+	s := NewMySQL(nil, WithMaxArgSize(4), WithMaxArgsSize(10))
+	name := "abcd"
+
+	cases := []struct {
+		args []interface{}
+		err  error
+	}{
+		{nil, nil},
+		{[]interface{}{"abcd", []byte("abcd"), 1234567890}, nil},
+		{[]interface{}{1, "abcde"}, &ArgSizeError{Index: 1, Size: 5, Limit: 4}},
+		{[]interface{}{json.RawMessage(`{"a":1}`)}, &ArgSizeError{Index: 0, Size: 7, Limit: 4}},
+		{[]interface{}{PII("abcde")}, &ArgSizeError{Index: 0, Size: 5, Limit: 4}},
+		{[]interface{}{&name, "abcd", "abcd"}, &ArgSizeError{Index: -1, Size: 12, Limit: 10}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.err, s.checkArgs(c.args), c.args)
+	}
+
+	assert.Nil(t, NewMySQL(nil).checkArgs([]interface{}{strings.Repeat("a", 1<<20)}))
+}
+
+func TestWithMaxArgSize(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db, WithMaxArgSize(3))
+	mock.ExpectPrepare("^insert into foo").ExpectExec().WithArgs("abc").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = s.Exec("insert into foo values (?)", "abc")
+	assert.Nil(t, err)
+	_, err = s.Exec("select * from foo where s in (?)", []string{"a", "bcde"})
+	assert.EqualError(t, err, "sqlpp: argument 1 is 4 bytes, over the 3 bytes limit")
+	_, err = s.Compile("insert into foo values (?)").Exec("abcd")
+	assert.EqualError(t, err, "sqlpp: argument 0 is 4 bytes, over the 3 bytes limit")
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,340 @@
+package sqlpp
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Lock is a lock shared by every process using the db.
+type Lock interface {
+	// TryLock acquires the lock if it's free, reporting whether it did. It
+	// reports true if the lock is already held by the caller.
+	TryLock(ctx context.Context) (bool, error)
+	// Lock waits for the lock until ctx is done.
+	Lock(ctx context.Context) error
+	// Unlock releases the lock, failing with ErrLockNotHeld if it was lost.
+	Unlock(ctx context.Context) error
+}
+
+// sessionLockKeepalive is how often a held session lock pings its
+// connection so it's not closed for being idle.
+var sessionLockKeepalive = time.Minute
+
+// NewLock returns the lock called name, a postgres advisory lock or a
+// mysql GET_LOCK lock. It holds a pooled connection while locked, as both
+// tie the lock to the session, and pings it until Unlock.
+func (sqlpp *DB) NewLock(name string) Lock {
+	return &sessionLock{db: sqlpp, name: name}
+}
+
+type sessionLock struct {
+	db   *DB
+	name string
+
+	mu   sync.Mutex
+	conn *sql.Conn
+	stop chan struct{}
+}
+
+func (l *sessionLock) TryLock(ctx context.Context) (bool, error) {
+	if err := l.db.helperSQL(); err != nil {
+		return false, err
+	} else if err := l.db.helperFeature(l.db.dialect.advisoryLocks); err != nil {
+		return false, err
+	}
+
+	if l.db.dialect.flavor == postgresFlavor {
+		return l.lock(ctx, "SELECT pg_try_advisory_lock($1)", advisoryKey(l.name))
+	}
+
+	return l.lock(ctx, "SELECT GET_LOCK(?, 0)", l.name)
+}
+
+func (l *sessionLock) Lock(ctx context.Context) error {
+	if err := l.db.helperSQL(); err != nil {
+		return err
+	} else if err := l.db.helperFeature(l.db.dialect.advisoryLocks); err != nil {
+		return err
+	}
+
+	query, args := "SELECT GET_LOCK(?, -1)", []interface{}{l.name}
+	if l.db.dialect.flavor == postgresFlavor {
+		query, args = "SELECT TRUE FROM pg_advisory_lock($1)", []interface{}{advisoryKey(l.name)}
+	}
+
+	acquired, err := l.lock(ctx, query, args...)
+	if err == nil && !acquired {
+		err = ErrLockTimeout
+	}
+
+	return err
+}
+
+func (l *sessionLock) lock(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired sql.NullBool
+	err = conn.QueryRowContext(ctx, query, args...).Scan(&acquired)
+	if err != nil {
+		// the lock may have been granted before the error
+		discardConn(conn)
+		return false, err
+	} else if !acquired.Bool {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	l.stop = make(chan struct{})
+	go keepalive(sessionLockKeepalive, l.stop, conn.PingContext)
+	return true, nil
+}
+
+func (l *sessionLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return ErrLockNotHeld
+	}
+
+	close(l.stop)
+	conn := l.conn
+	l.conn = nil
+
+	query, arg := "SELECT RELEASE_LOCK(?)", interface{}(l.name)
+	if l.db.dialect.flavor == postgresFlavor {
+		query, arg = "SELECT pg_advisory_unlock($1)", advisoryKey(l.name)
+	}
+
+	// a session failing to release still holds the lock, so it's discarded
+	// rather than returned to the pool
+	var released sql.NullBool
+	if err := conn.QueryRowContext(ctx, query, arg).Scan(&released); err != nil {
+		discardConn(conn)
+		return err
+	}
+
+	conn.Close()
+
+	if !released.Bool {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// advisoryKey hashes name to the bigint key of postgres advisory locks.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// TableLock is a Lock kept as a row of a table, for setups where session
+// locks are unavailable, like statement poolers. A holder that stops
+// renewing it loses the lock after its ttl, which Lost reports.
+type TableLock struct {
+	db    *DB
+	table string
+	name  string
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	owner string
+	stop  chan struct{}
+	lost  chan struct{}
+}
+
+// NewTableLock returns the lock called name in table. It's renewed every
+// third of ttl while held, and Lock retries every tenth of it.
+func NewTableLock(db *DB, table, name string, ttl time.Duration) *TableLock {
+	return &TableLock{db: db, table: table, name: name, ttl: ttl}
+}
+
+// Create creates the table of the lock if it doesn't exist.
+func (l *TableLock) Create(ctx context.Context) error {
+	_, err := l.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+l.db.QuoteIdent(l.table)+
+		" (name VARCHAR(255) PRIMARY KEY, owner VARCHAR(32) NOT NULL, expires BIGINT NOT NULL)")
+	return err
+}
+
+func (l *TableLock) TryLock(ctx context.Context) (bool, error) {
+	if err := l.db.helperSQL(); err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner != "" {
+		return true, nil
+	}
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return false, err
+	}
+
+	// take the row if it's missing or expired, then check who has it
+	now := time.Now()
+	table := l.db.QuoteIdent(l.table)
+	args := []interface{}{l.name, owner, now.Add(l.ttl).UnixMicro(), now.UnixMicro()}
+	upsert := "INSERT INTO " + table + " AS l (name, owner, expires) VALUES (?,?,?) ON CONFLICT (name) DO UPDATE " +
+		"SET owner = excluded.owner, expires = excluded.expires WHERE l.expires < ?"
+	if l.db.Capabilities().UpsertAlias {
+		upsert = "INSERT INTO " + table + " (name, owner, expires) VALUES (?,?,?) AS new ON DUPLICATE KEY UPDATE " +
+			"owner = IF(expires < ?, new.owner, owner), expires = IF(expires < ?, new.expires, expires)"
+		args = append(args, now.UnixMicro())
+	} else if l.db.dialect.flavor == mysqlFlavor {
+		upsert = "INSERT INTO " + table + " (name, owner, expires) VALUES (?,?,?) ON DUPLICATE KEY UPDATE " +
+			"owner = IF(expires < ?, VALUES(owner), owner), expires = IF(expires < ?, VALUES(expires), expires)"
+		args = append(args, now.UnixMicro())
+	}
+
+	if _, err := l.db.ExecContext(ctx, upsert, args...); err != nil {
+		return false, err
+	}
+
+	var holder string
+	if err := l.db.RowContext(ctx, "SELECT owner FROM "+table+" WHERE name = ?", l.name).Scan(&holder); err != nil {
+		return false, err
+	} else if holder != owner {
+		return false, nil
+	}
+
+	l.owner = owner
+	l.stop = make(chan struct{})
+	l.lost = make(chan struct{})
+	go keepalive(l.ttl/3, l.stop, l.renew)
+	return true, nil
+}
+
+// Lost returns a channel closed when a renewal finds the lock taken by
+// another holder, after which Unlock fails with ErrLockNotHeld. It's nil
+// before the lock is first acquired.
+func (l *TableLock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lost
+}
+
+func (l *TableLock) Lock(ctx context.Context) error {
+	for {
+		if acquired, err := l.TryLock(ctx); err != nil || acquired {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.ttl / 10):
+		}
+	}
+}
+
+func (l *TableLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner == "" {
+		return ErrLockNotHeld
+	}
+
+	close(l.stop)
+	owner := l.owner
+	l.owner = ""
+
+	result, err := l.db.ExecContext(ctx, "DELETE FROM "+l.db.QuoteIdent(l.table)+" WHERE name = ? AND owner = ?", l.name, owner)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+func (l *TableLock) renew(ctx context.Context) error {
+	l.mu.Lock()
+	owner := l.owner
+	l.mu.Unlock()
+
+	if owner == "" {
+		return nil
+	}
+
+	result, err := l.db.ExecContext(ctx, "UPDATE "+l.db.QuoteIdent(l.table)+" SET expires = ? WHERE name = ? AND owner = ?",
+		time.Now().Add(l.ttl).UnixMicro(), l.name, owner)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		return nil
+	}
+
+	// expired and taken by another holder
+	l.mu.Lock()
+	if l.owner == owner {
+		l.owner = ""
+		close(l.stop)
+		close(l.lost)
+	}
+	l.mu.Unlock()
+
+	return ErrLockNotHeld
+}
+
+// keepalive calls fn every interval until stop is closed.
+func keepalive(interval time.Duration, stop chan struct{}, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			fn(ctx)
+			cancel()
+		}
+	}
+}
+
+var newLockOwner = func() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+var (
+	_ Lock = (*sessionLock)(nil)
+	_ Lock = (*TableLock)(nil)
+)
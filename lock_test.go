@@ -0,0 +1,127 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_NewLock(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	m := NewMySQL(db).NewLock("jobs")
+	p := NewPostgreSQL(db).NewLock("jobs")
+	key := advisoryKey("jobs")
+
+	mock.ExpectQuery("SELECT GET_LOCK(?, 0)").WithArgs("jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+	mock.ExpectQuery("SELECT GET_LOCK(?, -1)").WithArgs("jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK(?)").WithArgs("jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"release"}).AddRow(1))
+
+	acquired, err := m.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.False(t, acquired)
+	assert.Nil(t, m.Lock(ctx))
+	assert.Nil(t, m.Unlock(ctx))
+	assert.Equal(t, ErrLockNotHeld, m.Unlock(ctx))
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock($1)").WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+	mock.ExpectQuery("SELECT pg_advisory_unlock($1)").WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"unlock"}).AddRow(false))
+	mock.ExpectQuery("SELECT TRUE FROM pg_advisory_lock($1)").WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+
+	acquired, err = p.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	acquired, err = p.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, ErrLockNotHeld, p.Unlock(ctx))
+	assert.Nil(t, p.Lock(ctx))
+
+	// a failed release discards the session holding the lock
+	released := errors.New("bad conn")
+	mock.ExpectQuery("SELECT GET_LOCK(?, -1)").WithArgs("jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK(?)").WithArgs("jobs").WillReturnError(released)
+	mock.ExpectClose()
+
+	assert.Nil(t, m.Lock(ctx))
+	assert.Equal(t, released, m.Unlock(ctx))
+	// only the conn of p is left
+	assert.Equal(t, 1, db.Stats().OpenConnections)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTableLock(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	owners := []string{"a", "b", "c"}
+	newOwner := newLockOwner
+	newLockOwner = func() (string, error) {
+		owner := owners[0]
+		owners = owners[1:]
+		return owner, nil
+	}
+	defer func() { newLockOwner = newOwner }()
+
+	ctx := context.Background()
+	m := NewTableLock(NewMySQL(db), "locks", "jobs", 90*time.Millisecond)
+	p := NewTableLock(NewPostgreSQL(db), "locks", "jobs", time.Minute)
+
+	mock.ExpectPrepare("INSERT INTO `locks` (name, owner, expires) VALUES (?,?,?) ON DUPLICATE KEY UPDATE "+
+		"owner = IF(expires < ?, VALUES(owner), owner), expires = IF(expires < ?, VALUES(expires), expires)").
+		ExpectExec().WithArgs("jobs", "a", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("SELECT owner FROM `locks` WHERE name = ?").
+		ExpectQuery().WithArgs("jobs").WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("a"))
+	mock.ExpectPrepare("UPDATE `locks` SET expires = ? WHERE name = ? AND owner = ?").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), "jobs", "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM `locks` WHERE name = ? AND owner = ?").
+		ExpectExec().WithArgs("jobs", "a").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, m.Lock(ctx))
+	time.Sleep(45 * time.Millisecond)
+	assert.Nil(t, m.Unlock(ctx))
+	assert.Equal(t, ErrLockNotHeld, m.Unlock(ctx))
+
+	mock.ExpectPrepare(`INSERT INTO "locks" AS l (name, owner, expires) VALUES ($1,$2,$3) ON CONFLICT (name) DO UPDATE `+
+		`SET owner = excluded.owner, expires = excluded.expires WHERE l.expires < $4`).
+		ExpectExec().WithArgs("jobs", "b", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`SELECT owner FROM "locks" WHERE name = $1`).
+		ExpectQuery().WithArgs("jobs").WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("a"))
+
+	acquired, err := p.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.False(t, acquired)
+
+	// a renewal finding the lock taken reports it lost
+	mock.ExpectExec("INSERT INTO `locks` (name, owner, expires) VALUES (?,?,?) ON DUPLICATE KEY UPDATE "+
+		"owner = IF(expires < ?, VALUES(owner), owner), expires = IF(expires < ?, VALUES(expires), expires)").
+		WithArgs("jobs", "c", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT owner FROM `locks` WHERE name = ?").
+		WithArgs("jobs").WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("c"))
+	mock.ExpectExec("UPDATE `locks` SET expires = ? WHERE name = ? AND owner = ?").
+		WithArgs(sqlmock.AnyArg(), "jobs", "c").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, m.Lock(ctx))
+	select {
+	case <-m.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock not lost")
+	}
+	assert.Equal(t, ErrLockNotHeld, m.Unlock(ctx))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
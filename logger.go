@@ -0,0 +1,49 @@
+package sqlpp
+
+import (
+	"context"
+)
+
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	}
+
+	return "error"
+}
+
+// Logger writes a log entry, keyvals alternate keys and values. The
+// sqlppzap and sqlppzerolog packages adapt zap and zerolog loggers.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// WithLogger logs the queries that reached the db, failed ones at error
+// level and the others at debug. Args aren't logged as they may hold
+// personal data.
+func WithLogger(l Logger) Option {
+	return WithHook(func(ctx context.Context, e *QueryEvent) {
+		if e.Err != nil {
+			l.Log(ctx, LogError, "sqlpp: query failed",
+				"statement", e.Statement, "duration", e.Duration, "error", e.Err)
+			return
+		}
+
+		l.Log(ctx, LogDebug, "sqlpp: query",
+			"statement", e.Statement, "duration", e.Duration, "rows", e.Rows)
+	})
+}
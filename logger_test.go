@@ -0,0 +1,48 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level   LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+type testLogger []logEntry
+
+func (l *testLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	*l = append(*l, logEntry{level, msg, keyvals})
+}
+
+func TestWithLogger(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var l testLogger
+	s := NewPostgreSQL(db, WithLogger(&l))
+
+	errBoom := errors.New("boom")
+	mock.ExpectPrepare(`^update foo set i = \$1$`).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`^select i from foo$`).ExpectQuery().WillReturnError(errBoom)
+
+	_, err = s.Exec("update foo set i = ?", "secret")
+	assert.Nil(t, err)
+	var i int
+	assert.Equal(t, errBoom, s.QueryRow("select i from foo", nil, &i))
+
+	assert.Len(t, l, 2)
+	assert.Equal(t, LogDebug, l[0].level)
+	assert.Equal(t, "update foo set i = $1", l[0].keyvals[1])
+	assert.NotContains(t, l[0].keyvals, "secret")
+	assert.Equal(t, LogError, l[1].level)
+	assert.Equal(t, errBoom, l[1].keyvals[5])
+	assert.Equal(t, "error", l[1].level.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,269 @@
+package sqlpp
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoTenant = errors.New("sqlpp: no tenant in context")
+)
+
+// WithTenant returns a ctx that DBManager.DB resolves to the tenant's handle.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+func TenantFrom(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey).(string)
+	return tenant, ok
+}
+
+type ManagerOption func(*DBManager)
+
+// WithMaxTenants limits the open tenant handles, closing the least
+// recently used one to open another.
+func WithMaxTenants(n int) ManagerOption {
+	return func(m *DBManager) {
+		m.maxTenants = n
+	}
+}
+
+// WithStmtBudget caps the cached stmts of all tenants to n, shared
+// equally by WithMaxTenants handles, or by the open ones without a limit,
+// so a busy tenant can't take them all. Each handle keeps at least one.
+func WithStmtBudget(n int) ManagerOption {
+	return func(m *DBManager) {
+		m.stmtBudget = n
+	}
+}
+
+// WithIdleTimeout closes tenant handles unused for d.
+func WithIdleTimeout(d time.Duration) ManagerOption {
+	return func(m *DBManager) {
+		m.idleTimeout = d
+	}
+}
+
+// DBManager lazily opens and caches a handle per tenant database. Handles
+// closed by eviction fail queries started on them afterwards, so callers
+// should resolve the handle per operation instead of keeping it.
+type DBManager struct {
+	driver string
+	dsn    func(tenant string) (string, error)
+	newDB  func(*sql.DB) *DB
+
+	maxTenants  int
+	stmtBudget  int
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	handles map[string]*list.Element
+	order   *list.List
+	closed  bool
+	stop    chan struct{}
+}
+
+type tenantDB struct {
+	tenant   string
+	db       *DB
+	lastUsed time.Time
+}
+
+// NewDBManager opens tenant databases with driver and the dsn of the
+// tenant, wrapping them with newDB, e.g. a func calling NewPostgreSQL.
+func NewDBManager(driver string, dsn func(tenant string) (string, error), newDB func(*sql.DB) *DB, opts ...ManagerOption) *DBManager {
+	m := &DBManager{
+		driver: driver,
+		dsn:    dsn,
+		newDB:  newDB,
+
+		handles: map[string]*list.Element{},
+		order:   list.New(),
+		stop:    make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.idleTimeout > 0 {
+		go m.closeIdle()
+	}
+
+	return m
+}
+
+// DB returns the handle of the tenant in ctx.
+func (m *DBManager) DB(ctx context.Context) (*DB, error) {
+	tenant, ok := TenantFrom(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	return m.Get(tenant)
+}
+
+// Get returns the handle of tenant, opening it if needed.
+func (m *DBManager) Get(tenant string) (*DB, error) {
+	// evicted handles close after the lock is released
+	var evicted []*DB
+	defer func() {
+		closeDBs(evicted)
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	if e, ok := m.handles[tenant]; ok {
+		t := e.Value.(*tenantDB)
+		t.lastUsed = time.Now()
+		m.order.MoveToFront(e)
+		return t.db, nil
+	}
+
+	dsn, err := m.dsn(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(m.driver, dsn)
+	if err != nil {
+		return nil, redactError(err, dsn)
+	}
+
+	for m.maxTenants > 0 && m.order.Len() >= m.maxTenants {
+		evicted = append(evicted, m.remove(m.order.Back()))
+	}
+
+	t := &tenantDB{tenant: tenant, db: m.newDB(conn), lastUsed: time.Now()}
+	m.handles[tenant] = m.order.PushFront(t)
+	m.splitBudget()
+	return t.db, nil
+}
+
+// splitBudget shares the stmt budget among the handles, evicting the
+// stmts of those over their share.
+func (m *DBManager) splitBudget() {
+	if m.stmtBudget <= 0 || m.order.Len() == 0 {
+		return
+	}
+
+	budget := m.stmtBudget / m.order.Len()
+	if m.maxTenants > 0 {
+		budget = m.stmtBudget / m.maxTenants
+	}
+
+	if budget < 1 {
+		budget = 1
+	}
+
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		db := e.Value.(*tenantDB).db
+		if db.config().maxStmts != int64(budget) {
+			db.SetOption(WithMaxStmts(budget))
+			db.evict("")
+		}
+	}
+}
+
+// Tenants returns the tenants with an open handle.
+func (m *DBManager) Tenants() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tenants := make([]string, 0, m.order.Len())
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		tenants = append(tenants, e.Value.(*tenantDB).tenant)
+	}
+
+	return tenants
+}
+
+// CacheStats returns the stmt cache counters of each open tenant handle.
+func (m *DBManager) CacheStats() map[string]CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]CacheStats, len(m.handles))
+	for tenant, e := range m.handles {
+		stats[tenant] = e.Value.(*tenantDB).db.CacheStats()
+	}
+
+	return stats
+}
+
+// Close closes every tenant handle.
+func (m *DBManager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.closed = true
+	close(m.stop)
+
+	var closing []*DB
+	for m.order.Len() > 0 {
+		closing = append(closing, m.remove(m.order.Back()))
+	}
+	m.mu.Unlock()
+
+	return closeDBs(closing)
+}
+
+// remove drops the handle of e, which the caller closes once m.mu is
+// released, as closing waits for its queries.
+func (m *DBManager) remove(e *list.Element) *DB {
+	t := m.order.Remove(e).(*tenantDB)
+	delete(m.handles, t.tenant)
+	return t.db
+}
+
+// closeDBs closes dbs, returning the first error.
+func closeDBs(dbs []*DB) error {
+	var err error
+	for _, db := range dbs {
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func (m *DBManager) closeIdle() {
+	ticker := time.NewTicker(m.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			var idle []*DB
+			m.mu.Lock()
+			for e := m.order.Back(); e != nil; {
+				prev := e.Prev()
+				if now.Sub(e.Value.(*tenantDB).lastUsed) >= m.idleTimeout {
+					idle = append(idle, m.remove(e))
+				}
+
+				e = prev
+			}
+			m.splitBudget()
+			m.mu.Unlock()
+
+			closeDBs(idle)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var managerRuns int64
+
+// mock dsns stay registered while their connection is open, so every
+// run needs its own
+func managerDSN() string {
+	return "manager_" + strconv.FormatInt(atomic.AddInt64(&managerRuns, 1), 10) + "_"
+}
+
+func TestDBManager(t *testing.T) {
+	prefix := managerDSN()
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, tenant := range []string{"a", "b", "c"} {
+		_, mock, err := sqlmock.NewWithDSN(prefix + tenant)
+		assert.Nil(t, err)
+		mocks[tenant] = mock
+	}
+
+	dsn := func(tenant string) (string, error) {
+		if tenant == "x" {
+			return "", errors.New("unknown tenant")
+		}
+
+		return prefix + tenant, nil
+	}
+
+	m := NewDBManager("sqlmock", dsn, func(db *sql.DB) *DB { return NewPostgreSQL(db) }, WithMaxTenants(2), WithStmtBudget(10))
+
+	_, err := m.DB(context.Background())
+	assert.Equal(t, ErrNoTenant, err)
+
+	_, err = m.Get("x")
+	assert.Equal(t, errors.New("unknown tenant"), err)
+
+	a, err := m.DB(WithTenant(context.Background(), "a"))
+	assert.Nil(t, err)
+	assert.True(t, a.PostgreSQL())
+	assert.Nil(t, a.Ping())
+
+	again, err := m.Get("a")
+	assert.Nil(t, err)
+	assert.Same(t, a, again)
+	assert.Equal(t, int64(5), a.config().maxStmts)
+
+	b, err := m.Get("b")
+	assert.Nil(t, err)
+	assert.Nil(t, b.Ping())
+	assert.Equal(t, []string{"b", "a"}, m.Tenants())
+
+	// a is least recently used
+	mocks["a"].ExpectClose()
+	c, err := m.Get("c")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Ping())
+	assert.Equal(t, []string{"c", "b"}, m.Tenants())
+	assert.Equal(t, map[string]CacheStats{"b": {}, "c": {}}, m.CacheStats())
+
+	mocks["b"].ExpectClose()
+	mocks["c"].ExpectClose()
+	assert.Nil(t, m.Close())
+	assert.Empty(t, m.Tenants())
+
+	_, err = m.Get("a")
+	assert.Equal(t, ErrClosed, err)
+
+	for _, mock := range mocks {
+		assert.Nil(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBManager_closeIdle(t *testing.T) {
+	prefix := managerDSN()
+	_, mock, err := sqlmock.NewWithDSN(prefix + "idle")
+	assert.Nil(t, err)
+
+	dsn := func(tenant string) (string, error) {
+		return prefix + tenant, nil
+	}
+
+	m := NewDBManager("sqlmock", dsn, func(db *sql.DB) *DB { return NewMySQL(db) }, WithIdleTimeout(10*time.Millisecond))
+	defer m.Close()
+
+	mock.ExpectClose()
+	db, err := m.Get("idle")
+	assert.Nil(t, err)
+	assert.Nil(t, db.Ping())
+
+	assert.Eventually(t, func() bool {
+		return len(m.Tenants()) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDBManager_splitBudget(t *testing.T) {
+	prefix := managerDSN()
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, tenant := range []string{"a", "b", "c"} {
+		_, mock, err := sqlmock.NewWithDSN(prefix + tenant)
+		assert.Nil(t, err)
+		mock.ExpectClose()
+		mocks[tenant] = mock
+	}
+
+	dsn := func(tenant string) (string, error) {
+		return prefix + tenant, nil
+	}
+
+	// without a tenant limit the budget is split by the open handles
+	m := NewDBManager("sqlmock", dsn, func(db *sql.DB) *DB { return NewMySQL(db) }, WithStmtBudget(10))
+	a, err := m.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), a.config().maxStmts)
+
+	b, err := m.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), a.config().maxStmts)
+	assert.Equal(t, int64(5), b.config().maxStmts)
+
+	c, err := m.Get("c")
+	assert.Nil(t, err)
+	for _, db := range []*DB{a, b, c} {
+		assert.Equal(t, int64(3), db.config().maxStmts)
+		assert.Nil(t, db.Ping())
+	}
+
+	assert.Nil(t, m.Close())
+	for _, mock := range mocks {
+		assert.Nil(t, mock.ExpectationsWereMet())
+	}
+}
@@ -0,0 +1,122 @@
+package sqlpp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// View is a postgres materialized view, or on mysql a summary table
+// rebuilt from a query, refreshed on demand or on a schedule.
+type View struct {
+	db    *DB
+	name  string
+	query string
+
+	// OnRefresh is called after every refresh with how long it took.
+	OnRefresh func(name string, d time.Duration, err error)
+
+	mu sync.Mutex
+	// refresh postgres views concurrently until it fails for a missing
+	// unique index or an unpopulated view
+	plain     bool
+	refreshed time.Time
+}
+
+// NewView returns the view called name. On mysql the rows of query replace
+// the rows of the name table on refresh, postgres ignores query.
+func NewView(db *DB, name, query string) *View {
+	return &View{db: db, name: name, query: query}
+}
+
+// Refresh refreshes the view. Postgres views are refreshed concurrently,
+// without blocking reads, when possible. Mysql tables are rebuilt in a
+// transaction, so readers see the old rows until it commits.
+func (v *View) Refresh(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	start := time.Now()
+	err := v.refresh(ctx)
+	if err == nil {
+		v.refreshed = start
+	}
+
+	if v.OnRefresh != nil {
+		v.OnRefresh(v.name, time.Since(start), err)
+	}
+
+	return err
+}
+
+func (v *View) refresh(ctx context.Context) error {
+	if err := v.db.helperSQL(); err != nil {
+		return err
+	}
+
+	name := v.db.QuoteIdent(v.name)
+	if v.db.dialect.flavor == postgresFlavor {
+		if !v.plain {
+			_, err := v.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+name)
+			if err == nil || !strings.Contains(err.Error(), "concurrently") {
+				return err
+			}
+
+			v.plain = true
+		}
+
+		_, err := v.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW "+name)
+		return err
+	}
+
+	return v.db.WithTx(ctx, nil, func(tx *Tx) error {
+		// truncate would commit the transaction
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+name); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO "+name+" "+v.query)
+		return err
+	})
+}
+
+// Refreshed returns when the last successful refresh started, zero if the
+// view wasn't refreshed yet.
+func (v *View) Refreshed() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.refreshed
+}
+
+// Stale reports whether the view wasn't refreshed in the last maxAge.
+func (v *View) Stale(maxAge time.Duration) bool {
+	return time.Since(v.Refreshed()) > maxAge
+}
+
+// RefreshIfStale refreshes the view if it's Stale.
+func (v *View) RefreshIfStale(ctx context.Context, maxAge time.Duration) error {
+	if !v.Stale(maxAge) {
+		return nil
+	}
+
+	return v.Refresh(ctx)
+}
+
+// Schedule refreshes the view now and every interval until ctx is done. Failed
+// refreshes are reported to OnRefresh and retried the next interval.
+func (v *View) Schedule(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		v.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView_Refresh(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	var refreshes []error
+	onRefresh := func(name string, d time.Duration, err error) {
+		assert.Equal(t, "daily_sales", name)
+		refreshes = append(refreshes, err)
+	}
+
+	p := NewView(NewPostgreSQL(db), "daily_sales", "")
+	p.OnRefresh = onRefresh
+	assert.True(t, p.Stale(time.Hour))
+
+	mock.ExpectPrepare(`REFRESH MATERIALIZED VIEW CONCURRENTLY "daily_sales"`).ExpectExec().
+		WillReturnError(errors.New(`cannot refresh materialized view "daily_sales" concurrently`))
+	refresh := mock.ExpectPrepare(`REFRESH MATERIALIZED VIEW "daily_sales"`)
+	refresh.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	refresh.ExpectExec().WillReturnError(errors.New("boom"))
+
+	assert.Nil(t, p.Refresh(ctx))
+	refreshed := p.Refreshed()
+	assert.False(t, p.Stale(time.Hour))
+	assert.Nil(t, p.RefreshIfStale(ctx, time.Hour))
+	assert.Equal(t, "boom", p.Refresh(ctx).Error())
+	assert.Equal(t, refreshed, p.Refreshed())
+	assert.Equal(t, []error{nil, errors.New("boom")}, refreshes)
+
+	m := NewView(NewMySQL(db), "daily_sales", "SELECT day, SUM(total) FROM orders GROUP BY day")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM `daily_sales`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectPrepare("INSERT INTO `daily_sales` SELECT day, SUM(total) FROM orders GROUP BY day").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	canceled, cancel := context.WithCancel(ctx)
+	m.OnRefresh = func(string, time.Duration, error) { cancel() }
+	assert.Equal(t, context.Canceled, m.Schedule(canceled, time.Hour))
+	assert.False(t, m.Stale(time.Hour))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,57 @@
+package sqlpp
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Metrics records sqlpp's metrics in a metrics library, mapping each name
+// to an instrument of its kind. The sqlppprom and sqlppotel modules adapt
+// prometheus and opentelemetry. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// Counter adds delta to the counter.
+	Counter(name string, labels map[string]string, delta float64)
+	// Histogram observes value, durations are in seconds.
+	Histogram(name string, labels map[string]string, value float64)
+	Gauge(name string, labels map[string]string, value float64)
+}
+
+// WithMetrics counts the queries in sqlpp_queries_total and observes their
+// sqlpp_query_duration_seconds, labeled by statement, e.g. "select", and
+// status, "ok" or "error".
+func WithMetrics(m Metrics) Option {
+	return WithHook(func(ctx context.Context, e *QueryEvent) {
+		keyword, _ := firstKeyword(e.Query)
+		status := "ok"
+		if e.Err != nil {
+			status = "error"
+		}
+
+		labels := map[string]string{"statement": strings.ToLower(keyword), "status": status}
+		m.Counter("sqlpp_queries_total", labels, 1)
+		m.Histogram("sqlpp_query_duration_seconds", labels, e.Duration.Seconds())
+	})
+}
+
+// StartMetricsReporter records the pool and stmt cache stats in m every
+// interval, like StartStatsReporter.
+func (sqlpp *DB) StartMetricsReporter(m Metrics, interval time.Duration) (stop func()) {
+	var last Stats
+	return sqlpp.StartStatsReporter(interval, func(s Stats) {
+		m.Gauge("sqlpp_connections_open", nil, float64(s.OpenConnections))
+		m.Gauge("sqlpp_connections_in_use", nil, float64(s.InUse))
+		m.Gauge("sqlpp_connections_idle", nil, float64(s.Idle))
+		m.Gauge("sqlpp_queries_in_flight", nil, float64(s.InFlight))
+		m.Gauge("sqlpp_stmts_cached", nil, float64(s.Cache.Stmts))
+
+		// counters get the increase since the last sample
+		m.Counter("sqlpp_connection_waits_total", nil, float64(s.WaitCount-last.WaitCount))
+		m.Counter("sqlpp_connection_wait_seconds_total", nil, (s.WaitDuration - last.WaitDuration).Seconds())
+		m.Counter("sqlpp_stmt_cache_hits_total", nil, float64(s.Cache.Hits-last.Cache.Hits))
+		m.Counter("sqlpp_stmt_cache_misses_total", nil, float64(s.Cache.Misses-last.Cache.Misses))
+		m.Counter("sqlpp_stmt_cache_evictions_total", nil, float64(s.Cache.Evictions-last.Cache.Evictions))
+		last = s
+	})
+}
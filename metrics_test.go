@@ -0,0 +1,101 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMetrics struct {
+	mu     sync.Mutex
+	values map[string]float64
+	counts map[string]int
+}
+
+func (m *testMetrics) record(kind, name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	key := kind + " " + name
+	for _, k := range keys {
+		key += fmt.Sprintf(" %s=%s", k, labels[k])
+	}
+
+	if m.values == nil {
+		m.values, m.counts = map[string]float64{}, map[string]int{}
+	}
+
+	m.values[key] += value
+	m.counts[key]++
+}
+
+func (m *testMetrics) Counter(name string, labels map[string]string, delta float64) {
+	m.record("counter", name, labels, delta)
+}
+
+func (m *testMetrics) Histogram(name string, labels map[string]string, value float64) {
+	m.record("histogram", name, labels, value)
+}
+
+func (m *testMetrics) Gauge(name string, labels map[string]string, value float64) {
+	m.record("gauge", name, labels, value)
+}
+
+func TestWithMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	m := &testMetrics{}
+	s := NewMySQL(db, WithMetrics(m))
+
+	mock.ExpectPrepare("^update foo").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("^select i").ExpectQuery().WillReturnError(errors.New("boom"))
+
+	_, err = s.Exec("update foo set i = 1")
+	assert.Nil(t, err)
+	var i int
+	assert.NotNil(t, s.QueryRow("select i from foo", nil, &i))
+
+	assert.Equal(t, 1.0, m.values["counter sqlpp_queries_total statement=update status=ok"])
+	assert.Equal(t, 1.0, m.values["counter sqlpp_queries_total statement=select status=error"])
+	assert.Equal(t, 1, m.counts["histogram sqlpp_query_duration_seconds statement=update status=ok"])
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_StartMetricsReporter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	m := &testMetrics{}
+
+	mock.ExpectPrepare("^select 1$")
+	_, _, err = s.stmt(context.Background(), "select 1")
+	assert.Nil(t, err)
+	_, _, err = s.stmt(context.Background(), "select 1")
+	assert.Nil(t, err)
+
+	stop := s.StartMetricsReporter(m, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, 1.0, m.values["counter sqlpp_stmt_cache_hits_total"], "counters add the increase only")
+	assert.Equal(t, 1.0, m.values["counter sqlpp_stmt_cache_misses_total"])
+	assert.Less(t, 1, m.counts["gauge sqlpp_stmts_cached"])
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
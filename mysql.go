@@ -1,13 +1,100 @@
 package sqlpp
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"strings"
+	"time"
+)
+
+var (
+	ErrLockTimeout  = errors.New("sqlpp: lock timeout")
+	ErrLockNotHeld  = errors.New("sqlpp: lock not held")
+	ErrNotSupported = errors.New("sqlpp: not supported by dialect")
 )
 
 var (
 	mysqlErrPrefixPrepareNotSupported = "Error 1295:"
+	mysqlErrPrefixNeedsReprepare      = "Error 1615:"
 )
 
 func isMysqlPrepareNotSupported(err error) bool {
 	return err != nil && strings.HasPrefix(err.Error(), mysqlErrPrefixPrepareNotSupported)
 }
+
+func isMysqlNeedsReprepare(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), mysqlErrPrefixNeedsReprepare)
+}
+
+func mysqlQuoteIdent(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+// NamedLock is a MySQL GET_LOCK lock. It holds the pooled connection that
+// acquired it until Unlock, since MySQL ties named locks to the session.
+type NamedLock struct {
+	conn *sql.Conn
+	name string
+}
+
+// NamedLock acquires the MySQL named lock, waiting up to timeout.
+// A negative timeout waits forever.
+func (sqlpp *DB) NamedLock(ctx context.Context, name string, timeout time.Duration) (*NamedLock, error) {
+	if sqlpp.dialect.flavor != mysqlFlavor {
+		return nil, ErrNotSupported
+	}
+
+	conn, err := sqlpp.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := timeout.Seconds()
+	if timeout < 0 {
+		seconds = -1
+	}
+
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, seconds).Scan(&acquired)
+	if err == nil && acquired.Int64 != 1 {
+		err = ErrLockTimeout
+	}
+
+	if errors.Is(err, ErrLockTimeout) {
+		conn.Close()
+		return nil, err
+	} else if err != nil {
+		// GET_LOCK may have been granted before the error
+		discardConn(conn)
+		return nil, err
+	}
+
+	return &NamedLock{
+		conn: conn,
+		name: name,
+	}, nil
+}
+
+func (lock *NamedLock) Name() string {
+	return lock.name
+}
+
+// Unlock releases the lock and returns its connection to the pool. The
+// connection is discarded instead if the release fails, ending the session
+// and its lock.
+func (lock *NamedLock) Unlock(ctx context.Context) error {
+	var released sql.NullInt64
+	err := lock.conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", lock.name).Scan(&released)
+	if err != nil {
+		discardConn(lock.conn)
+		return err
+	}
+
+	lock.conn.Close()
+	if released.Int64 != 1 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
@@ -1,10 +1,13 @@
 package sqlpp
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,3 +37,96 @@ func Test_isMysqlPrepareNotSupported(t *testing.T) {
 		})
 	}
 }
+
+func Test_isMysqlNeedsReprepare(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errPrepareNotSupported,
+			false,
+		},
+		{
+			errors.New("Error 1615: Prepared statement needs to be re-prepared"),
+			true,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isMysqlNeedsReprepare(c.err))
+		})
+	}
+}
+
+func TestDB_NamedLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	sm := NewMySQL(db)
+	sp := NewPostgreSQL(db)
+
+	cases := []struct {
+		acquired interface{}
+		released interface{}
+		lockErr  error
+		err      error
+	}{
+		{
+			1, 1,
+			nil, nil,
+		}, {
+			1, 0,
+			nil, ErrLockNotHeld,
+		}, {
+			0, nil,
+			ErrLockTimeout, nil,
+		}, {
+			nil, nil,
+			ErrLockTimeout, nil,
+		},
+	}
+
+	for _, c := range cases {
+		mock.ExpectQuery("^SELECT GET_LOCK\\(\\?, \\?\\)$").WithArgs("foo", float64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(c.acquired))
+		if c.lockErr == nil {
+			mock.ExpectQuery("^SELECT RELEASE_LOCK\\(\\?\\)$").WithArgs("foo").
+				WillReturnRows(sqlmock.NewRows([]string{"release"}).AddRow(c.released))
+		}
+
+		lock, err := sm.NamedLock(context.Background(), "foo", 2*time.Second)
+		assert.Equal(t, c.lockErr, err)
+		if err != nil {
+			assert.Nil(t, lock)
+			continue
+		}
+
+		assert.Equal(t, "foo", lock.Name())
+		assert.Equal(t, c.err, lock.Unlock(context.Background()))
+	}
+
+	lock, err := sp.NamedLock(context.Background(), "foo", time.Second)
+	assert.Nil(t, lock)
+	assert.Equal(t, ErrNotSupported, err)
+
+	// a failed release discards the session holding the lock
+	released := errors.New("bad conn")
+	mock.ExpectQuery("^SELECT GET_LOCK\\(\\?, \\?\\)$").WithArgs("foo", float64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectQuery("^SELECT RELEASE_LOCK\\(\\?\\)$").WithArgs("foo").WillReturnError(released)
+	mock.ExpectClose()
+
+	lock, err = sm.NamedLock(context.Background(), "foo", 2*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, released, lock.Unlock(context.Background()))
+	assert.Equal(t, 0, db.Stats().OpenConnections)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,52 @@
+package sqlpp
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+)
+
+// NPlusOneDetector warns when a query runs more than Threshold times with
+// the same DetectNPlusOne context, like a query in a loop over the rows of
+// another. Add its Hook with WithHook, for development setups.
+type NPlusOneDetector struct {
+	Threshold int
+	// Warn is called once per fingerprint and context.
+	Warn func(ctx context.Context, w *NPlusOneWarning)
+}
+
+// NPlusOneWarning is a query run more than the Threshold times, with the
+// stack of the run that crossed it.
+type NPlusOneWarning struct {
+	Fingerprint string
+	Count       int
+	Stack       []byte
+}
+
+type queryCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// DetectNPlusOne starts counting the queries run with ctx, e.g. the ctx of
+// a request, for NPlusOneDetector.
+func DetectNPlusOne(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nPlusOneKey, &queryCounts{counts: map[string]int{}})
+}
+
+func (d *NPlusOneDetector) Hook(ctx context.Context, e *QueryEvent) {
+	counts, ok := ctx.Value(nPlusOneKey).(*queryCounts)
+	if !ok {
+		return
+	}
+
+	fingerprint := Fingerprint(e.Query)
+	counts.mu.Lock()
+	counts.counts[fingerprint]++
+	n := counts.counts[fingerprint]
+	counts.mu.Unlock()
+
+	if n == d.Threshold+1 && d.Warn != nil {
+		d.Warn(ctx, &NPlusOneWarning{Fingerprint: fingerprint, Count: n, Stack: debug.Stack()})
+	}
+}
@@ -0,0 +1,44 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNPlusOneDetector(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	var warnings []*NPlusOneWarning
+	detector := &NPlusOneDetector{Threshold: 2, Warn: func(ctx context.Context, w *NPlusOneWarning) {
+		warnings = append(warnings, w)
+	}}
+	s := NewMySQL(db, WithHook(detector.Hook))
+
+	prepared := mock.ExpectPrepare("select name from users where id = ?")
+	for i := 0; i < 6; i++ {
+		prepared.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+	}
+
+	ctx := DetectNPlusOne(context.Background())
+	for i := 0; i < 4; i++ {
+		var name string
+		assert.Nil(t, s.RowContext(ctx, "select name from users where id = ?", i).Scan(&name))
+	}
+
+	// other contexts count on their own
+	for i := 0; i < 2; i++ {
+		var name string
+		assert.Nil(t, s.RowContext(context.Background(), "select name from users where id = ?", i).Scan(&name))
+	}
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "select name from users where id = ?", warnings[0].Fingerprint)
+	assert.Equal(t, 3, warnings[0].Count)
+	assert.Contains(t, string(warnings[0].Stack), "TestNPlusOneDetector")
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
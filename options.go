@@ -0,0 +1,92 @@
+package sqlpp
+
+import "time"
+
+type Option func(*DB)
+
+// WithInterpolation makes every query interpolate its arguments client-side
+// and skip server-side prepare. Only for setups where prepare is unavailable.
+func WithInterpolation() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.interpolate = true
+	}
+}
+
+// WithStrictPrepare makes every query bind its args server-side. Queries
+// mysql can't prepare fail with error 1295 instead of running directly, and
+// interpolation fails with ErrStrictPrepare.
+func WithStrictPrepare() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.strict = true
+	}
+}
+
+// WithDirect makes queries run directly on the db, binding their args
+// without preparing a cached stmt, or with false prepare them as usual on
+// a db running directly by default, like redshift. Queries on trino always
+// run directly.
+func WithDirect(direct bool) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.direct = direct
+	}
+}
+
+// WithDefaultQueryTimeout limits queries run with a ctx without a deadline
+// to d, including reading the rows of Query. Timed out queries fail with
+// ErrQueryTimeout.
+func WithDefaultQueryTimeout(d time.Duration) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.queryTimeout = d
+	}
+}
+
+// WithRowsCapacity sets the initial capacity of Query results for queries
+// without an ExpectRows hint.
+func WithRowsCapacity(n int) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.rowsCapacity = n
+	}
+}
+
+// WithParallelism limits how many queries QueryParallel runs at a time.
+func WithParallelism(n int) Option {
+	return func(sqlpp *DB) {
+		if n > 0 {
+			sqlpp.cfg.parallelism = n
+		}
+	}
+}
+
+// WithAsyncWorkers limits how many ExecAsync execs run at a time.
+func WithAsyncWorkers(n int) Option {
+	return func(sqlpp *DB) {
+		if n > 0 {
+			sqlpp.asyncSem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithResultCache enables caching Query results in cache for queries run
+// with a Cache context.
+func WithResultCache(cache ResultCache) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.resultCache = cache
+	}
+}
+
+// WithCacheNamespace prefixes the result cache keys of the db with ns, by
+// default one of its own handle, so dbs and their clones sharing a cache
+// don't read each other's results. Dbs of different processes sharing a store like redis set the
+// same ns to share theirs.
+func WithCacheNamespace(ns string) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.cacheNamespace = ns
+	}
+}
+
+// WithMaxStmts limits the cached stmts, closing others to cache a new one.
+func WithMaxStmts(n int) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.maxStmts = int64(n)
+	}
+}
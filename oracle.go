@@ -0,0 +1,83 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// the first call after a plsql package was recompiled fails with it,
+	// the retry runs on the new package state
+	oracleErrPackageStateDiscarded = "ORA-04068:"
+)
+
+func isOraclePackageStateDiscarded(err error) bool {
+	return err != nil && strings.Contains(err.Error(), oracleErrPackageStateDiscarded)
+}
+
+// inOperand finds the IN a query ending in b compares a slice with,
+// returning where its left operand starts and ends, and whether it is a
+// NOT IN. start is -1 if b doesn't end in an IN with an operand.
+func inOperand(b []byte) (start, end int, not bool) {
+	i := trimSpaceLeft(b, len(b))
+	if i < 3 || !strings.EqualFold(string(b[i-2:i]), "IN") || isIdentByte(b[i-3]) {
+		return -1, 0, false
+	}
+
+	end = trimSpaceLeft(b, i-2)
+	if end >= 4 && strings.EqualFold(string(b[end-3:end]), "NOT") && !isIdentByte(b[end-4]) {
+		not = true
+		end = trimSpaceLeft(b, end-3)
+	}
+
+	start = end
+	for start > 0 {
+		switch c := b[start-1]; {
+		case c == ')':
+			depth := 0
+			for start > 0 {
+				start--
+				if b[start] == ')' {
+					depth++
+				} else if b[start] == '(' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		case c == '"':
+			start--
+			for start > 0 && b[start-1] != '"' {
+				start--
+			}
+
+			start--
+		case isIdentByte(c) || c == '.':
+			start--
+			continue
+		default:
+			if start == end {
+				return -1, 0, false
+			}
+
+			return start, end, not
+		}
+
+		if start < 0 {
+			return -1, 0, false
+		}
+	}
+
+	if start == end {
+		return -1, 0, false
+	}
+
+	return start, end, not
+}
+
+func trimSpaceLeft(b []byte, i int) int {
+	for i > 0 && (b[i-1] == ' ' || b[i-1] == '\t' || b[i-1] == '\r' || b[i-1] == '\n') {
+		i--
+	}
+
+	return i
+}
@@ -0,0 +1,80 @@
+package sqlpp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_inOperand(t *testing.T) {
+	cases := []struct {
+		query   string
+		operand string
+		not     bool
+	}{
+		{"select * from foo where id in ", "id", false},
+		{"select * from foo where f.id IN", "f.id", false},
+		{`select * from foo where "Foo"."Id" not in `, `"Foo"."Id"`, true},
+		{"select * from foo where lower(name) in ", "lower(name)", false},
+		{"select * from foo where (a, b) in ", "(a, b)", false},
+		{"insert into foo values ", "", false},
+		{"select * from foo where login ", "", false},
+		{"in ", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			start, end, not := inOperand([]byte(c.query))
+			if c.operand == "" {
+				assert.Equal(t, -1, start)
+				return
+			}
+
+			assert.Equal(t, c.operand, c.query[start:end])
+			assert.Equal(t, c.not, not)
+		})
+	}
+}
+
+func TestNewOracle(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewOracle(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = :1 and b in (:2,:3)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	ids := make([]int, 2001)
+	query, args = s.Transform("select * from foo where f.id not in (?) and a = ?", ids, 1)
+	assert.Len(t, args, 2002)
+	assert.True(t, strings.HasPrefix(query, "select * from foo where (f.id not in (:1,"))
+	assert.Contains(t, query, ",:1000) AND f.id not in (:1001,")
+	assert.True(t, strings.HasSuffix(query, ",:2000) AND f.id not in (:2001)) and a = :2002"))
+
+	query, _ = s.Transform("select * from foo where id in (?)", ids[:1500])
+	assert.Contains(t, query, ",:1000) OR id in (:1001,")
+
+	literal, err := s.interpolation("insert into foo values (?, ?, ?, ?)",
+		[]interface{}{false, []byte{0xca, 0xfe}, "a", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, "insert into foo values (0, HEXTORAW('cafe'), N'a', TIMESTAMP '2024-01-02 03:04:05')", literal)
+
+	// numbers in strings aren't placeholders
+	literal, err = s.interpolation(`select * from foo where t = '10:30' and "a:1" = ? and s = 'it''s :1'`, []interface{}{1})
+	assert.Nil(t, err)
+	assert.Equal(t, `select * from foo where t = '10:30' and "a:1" = 1 and s = 'it''s :1'`, literal)
+
+	mock.ExpectPrepare("begin pkg.run(:1, :2); end;").WillBeClosed().
+		ExpectExec().WithArgs(1, 2).WillReturnError(errors.New("ORA-04068: existing state of packages has been discarded"))
+	mock.ExpectPrepare("begin pkg.run(:1, :2); end;").
+		ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("begin pkg.run(?, ?); end;", 1, 2)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OutboxMessage is a message written to an Outbox.
+type OutboxMessage struct {
+	ID      int64
+	Topic   string
+	Payload []byte
+	Created time.Time
+}
+
+// Outbox stores messages in a table within the transactions that produce
+// them, for Poll to publish after commit. A message is published at least
+// once, consumers must tolerate duplicates.
+type Outbox struct {
+	db    *DB
+	table string
+
+	// OnError is called with the errors of the batches Poll failed.
+	OnError func(error)
+}
+
+func NewOutbox(db *DB, table string) *Outbox {
+	return &Outbox{db: db, table: table}
+}
+
+// Create creates the table of the outbox if it doesn't exist.
+func (o *Outbox) Create(ctx context.Context) error {
+	if err := o.db.helperSQL(); err != nil {
+		return err
+	}
+
+	id, payload := "id BIGINT AUTO_INCREMENT PRIMARY KEY", "BLOB"
+	if o.db.dialect.flavor == postgresFlavor {
+		id, payload = "id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY", "BYTEA"
+	}
+
+	_, err := o.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+o.db.QuoteIdent(o.table)+" ("+id+
+		", topic VARCHAR(255) NOT NULL, payload "+payload+" NOT NULL, created BIGINT NOT NULL)")
+	return err
+}
+
+// Write adds a message to the outbox in tx, see WithTx, so it's only
+// published if tx commits.
+func (o *Outbox) Write(ctx context.Context, tx *Tx, topic string, payload []byte) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO "+o.db.QuoteIdent(o.table)+" (topic, payload, created) VALUES (?,?,?)",
+		topic, payload, time.Now().UnixMicro())
+	return err
+}
+
+// Poll publishes the messages in batches of up to size, oldest first, until
+// ctx is done. It waits interval when the outbox is empty or a batch failed.
+// A batch is removed once publish returns nil, publish gets it again if it
+// fails or the removal does. Pollers on other instances claim other batches.
+func (o *Outbox) Poll(ctx context.Context, interval time.Duration, size int, publish func(ctx context.Context, batch []OutboxMessage) error) error {
+	for {
+		n, err := o.poll(ctx, size, publish)
+		if err != nil && ctx.Err() == nil && o.OnError != nil {
+			o.OnError(err)
+		}
+
+		if err == nil && n == size {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// poll claims a batch with a row lock held until it's published and removed.
+func (o *Outbox) poll(ctx context.Context, size int, publish func(ctx context.Context, batch []OutboxMessage) error) (int, error) {
+	if err := o.db.helperSQL(); err != nil {
+		return 0, err
+	} else if !o.db.Capabilities().SkipLocked {
+		return 0, fmt.Errorf("%w: SKIP LOCKED", ErrNotSupported)
+	}
+
+	var batch []OutboxMessage
+	table := o.db.QuoteIdent(o.table)
+	err := o.db.WithTx(ctx, nil, func(tx *Tx) error {
+		var ids []int64
+		_, err := tx.SelectContext(ctx, func(rows *sql.Rows) (interface{}, error) {
+			var m OutboxMessage
+			var created int64
+			if err := rows.Scan(&m.ID, &m.Topic, &m.Payload, &created); err != nil {
+				return nil, err
+			}
+
+			m.Created = time.UnixMicro(created)
+			batch = append(batch, m)
+			ids = append(ids, m.ID)
+			return nil, nil
+		}, "SELECT id, topic, payload, created FROM "+table+" ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED", size)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		if err := publish(ctx, batch); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id IN (?)", ids)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(batch), nil
+}
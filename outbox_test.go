@@ -0,0 +1,87 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	mOutbox := NewOutbox(NewMySQL(mDb), "outbox")
+	pOutbox := NewOutbox(NewPostgreSQL(pDb), "outbox")
+	ctx := context.Background()
+
+	mMock.ExpectPrepare("CREATE TABLE IF NOT EXISTS `outbox` (id BIGINT AUTO_INCREMENT PRIMARY KEY, topic VARCHAR(255) NOT NULL, payload BLOB NOT NULL, created BIGINT NOT NULL)").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectBegin()
+	mMock.ExpectPrepare("INSERT INTO `outbox` (topic, payload, created) VALUES (?,?,?)").
+		ExpectExec().WithArgs("orders", []byte("1"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mMock.ExpectCommit()
+
+	assert.Nil(t, mOutbox.Create(ctx))
+	assert.Nil(t, mOutbox.db.WithTx(ctx, nil, func(tx *Tx) error {
+		return mOutbox.Write(ctx, tx, "orders", []byte("1"))
+	}))
+
+	created := time.Date(2021, 2, 3, 4, 5, 6, 7000, time.UTC)
+	columns := []string{"id", "topic", "payload", "created"}
+	poll := `SELECT id, topic, payload, created FROM "outbox" ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	pMock.ExpectPrepare(`CREATE TABLE IF NOT EXISTS "outbox" (id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY, topic VARCHAR(255) NOT NULL, payload BYTEA NOT NULL, created BIGINT NOT NULL)`).
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(poll).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "orders", []byte("1"), created.UnixMicro()).AddRow(2, "orders", []byte("2"), created.UnixMicro()))
+	pMock.ExpectPrepare(`DELETE FROM "outbox" WHERE id IN ($1,$2)`).ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	pMock.ExpectCommit()
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(poll).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "orders", []byte("3"), created.UnixMicro()))
+	pMock.ExpectRollback()
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(poll).ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows(columns))
+	pMock.ExpectCommit()
+
+	assert.Nil(t, pOutbox.Create(ctx))
+
+	var published [][]OutboxMessage
+	errPublish := errors.New("publish")
+	publish := func(ctx context.Context, batch []OutboxMessage) error {
+		published = append(published, batch)
+		if batch[0].ID == 3 {
+			return errPublish
+		}
+
+		return nil
+	}
+
+	var errs []error
+	pollCtx, cancel := context.WithCancel(ctx)
+	pOutbox.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+
+	done := make(chan error)
+	go func() { done <- pOutbox.Poll(pollCtx, time.Millisecond, 2, publish) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	assert.Equal(t, []OutboxMessage{
+		{ID: 1, Topic: "orders", Payload: []byte("1"), Created: time.UnixMicro(created.UnixMicro())},
+		{ID: 2, Topic: "orders", Payload: []byte("2"), Created: time.UnixMicro(created.UnixMicro())},
+	}, published[0])
+	assert.Equal(t, int64(3), published[1][0].ID)
+	assert.Equal(t, errPublish, errs[0])
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package sqlpp
+
+import (
+	"context"
+	"sync"
+)
+
+type QuerySpec struct {
+	Query string
+	Args  []interface{}
+}
+
+func (sqlpp *DB) QueryParallel(specs []QuerySpec, scan Scanner) ([][]interface{}, error) {
+	return sqlpp.QueryParallelContext(context.Background(), specs, scan)
+}
+
+// QueryParallelContext runs specs concurrently, at most the configured
+// parallelism at a time, and returns their results in the order of specs.
+// The first error cancels the queries still running.
+func (sqlpp *DB) QueryParallelContext(ctx context.Context, specs []QuerySpec, scan Scanner) ([][]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		err  error
+	)
+
+	results := make([][]interface{}, len(specs))
+	sem := make(chan struct{}, sqlpp.config().parallelism)
+
+loop:
+	for i, spec := range specs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int, spec QuerySpec) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			r, e := sqlpp.QueryContext(ctx, spec.Query, spec.Args, scan)
+			if e != nil {
+				once.Do(func() {
+					err = e
+					cancel()
+				})
+
+				return
+			}
+
+			results[i] = r
+		}(i, spec)
+	}
+
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
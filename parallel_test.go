@@ -0,0 +1,51 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_QueryParallel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+
+	s := NewMySQL(db, WithParallelism(2))
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	mock.ExpectPrepare("^select count(.+) from foo$").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mock.ExpectPrepare("^select count(.+) from bar where i in (.+)$").
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(2))
+	mock.ExpectPrepare("^select i from baz$").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(3).AddRow(4))
+
+	results, err := s.QueryParallel([]QuerySpec{
+		{"select count(*) from foo", nil},
+		{"select count(*) from bar where i in (?)", s.Args([]int{1, 2})},
+		{"select i from baz", nil},
+	}, scanner)
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{1}, {2}, {3, 4}}, results)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	mock.ExpectPrepare("^select i from koo$").WillReturnError(errors.New("error"))
+	results, err = s.QueryParallel([]QuerySpec{{"select i from koo", nil}}, scanner)
+	assert.Nil(t, results)
+	assert.Equal(t, errors.New("error"), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = s.QueryParallelContext(ctx, []QuerySpec{{"select i from koo", nil}}, scanner)
+	assert.Nil(t, results)
+	assert.Equal(t, context.Canceled, err)
+}
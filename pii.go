@@ -0,0 +1,60 @@
+package sqlpp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+)
+
+// PII marks arg as personal data, RedactArgs hides it from what hooks
+// log while the query still gets its value. Wrap the elements of a (?)
+// slice arg, not the slice. Struct fields tagged pii:"true" are marked
+// by Insert and UpdateVersioned.
+func PII(arg interface{}) driver.Valuer {
+	return piiArg{arg}
+}
+
+type piiArg struct {
+	arg interface{}
+}
+
+func (p piiArg) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(p.arg)
+}
+
+// RedactArgs returns args with PII args, and PII elements of (?) slice
+// args, replaced by "pii". Other args are kept.
+func RedactArgs(args []interface{}) []interface{} {
+	return redactArgs(args, func(piiArg) string {
+		return "pii"
+	})
+}
+
+// RedactArgsKeyed returns args with PII args replaced by an HMAC of their
+// value under key, so logs can still tell equal values apart without the
+// values being guessable from them. key must be secret and random.
+func RedactArgsKeyed(key []byte, args []interface{}) []interface{} {
+	return redactArgs(args, func(p piiArg) string {
+		mac := hmac.New(sha256.New, key)
+		fmt.Fprintf(mac, "%T:%v", p.arg, p.arg)
+		return "pii:" + hex.EncodeToString(mac.Sum(nil)[:16])
+	})
+}
+
+func redactArgs(args []interface{}, redact func(p piiArg) string) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch a := arg.(type) {
+		case piiArg:
+			arg = redact(a)
+		case []interface{}:
+			arg = redactArgs(a, redact)
+		}
+
+		redacted[i] = arg
+	}
+
+	return redacted
+}
@@ -0,0 +1,52 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPII(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var logged []interface{}
+	s := NewPostgreSQL(db, WithHook(func(ctx context.Context, e *QueryEvent) {
+		logged = RedactArgs(e.Args)
+	}))
+
+	mock.ExpectPrepare("^update users").
+		ExpectExec().WithArgs("a@example.com", int64(7), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = s.Exec("update users set email = ?, age = ? where id = ?", PII("a@example.com"), PII(7), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []interface{}{"pii", "pii", 1}, logged)
+
+	key := []byte("secret")
+	keyed := RedactArgsKeyed(key, []interface{}{PII("a@example.com"), PII(7), 1})
+	assert.Regexp(t, "^pii:[0-9a-f]{32}$", keyed[0])
+	assert.NotEqual(t, keyed[0], keyed[1])
+	assert.Equal(t, 1, keyed[2])
+	assert.Equal(t, keyed[:1], RedactArgsKeyed(key, []interface{}{PII("a@example.com")}))
+	assert.NotEqual(t, keyed[:1], RedactArgsKeyed([]byte("other"), []interface{}{PII("a@example.com")}))
+
+	q, err := s.interpolation("select ?", []interface{}{PII("x")})
+	assert.Nil(t, err)
+	assert.Equal(t, "select 'x'", q)
+
+	type user struct {
+		ID    int    `db:"id"`
+		Email string `db:"email" pii:"true"`
+	}
+
+	mock.ExpectPrepare("^INSERT INTO").
+		ExpectExec().WithArgs(1, "b@example.com").WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = s.Insert("users", user{ID: 1, Email: "b@example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{1, "pii"}}, logged)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
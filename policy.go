@@ -0,0 +1,374 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrRestricted = errors.New("sqlpp: statement not allowed")
+)
+
+// Policy returns an error wrapping ErrRestricted for a query the handle
+// must not run.
+type Policy func(query string) error
+
+// WithPolicy restricts the queries run by Exec, QueryRow and Query, and
+// the ones built on them, to the ones every policy allows. The embedded
+// *sql.DB is not restricted, so hand out the DB only behind an interface.
+func WithPolicy(policies ...Policy) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.policies = append(sqlpp.cfg.policies, policies...)
+	}
+}
+
+// Clone returns a handle sharing the connection pool and result cache of
+// sqlpp with its settings and hooks, then opts applied, e.g. WithPolicy
+// for a restricted handle. The clone keys the cache in a namespace of its
+// own, see WithCacheNamespace. Closing the clone keeps the pool open.
+func (sqlpp *DB) Clone(opts ...Option) *DB {
+	inherit := func(c *DB) {
+		c.cfg = sqlpp.config().clone()
+		c.cfg.cacheNamespace = ""
+		c.asyncSem = make(chan struct{}, cap(sqlpp.asyncSem))
+		c.clone = true
+	}
+
+	return new(sqlpp.DB, sqlpp.dialect, append([]Option{inherit}, opts...))
+}
+
+func (sqlpp *DB) allow(query string) error {
+	for _, policy := range sqlpp.config().policies {
+		if err := policy(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restricted(reason string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+reason, append([]interface{}{ErrRestricted}, args...)...)
+}
+
+// ReadOnly allows single SELECT, SHOW, EXPLAIN and DESCRIBE statements,
+// including WITH queries that don't modify data. SELECT INTO creating a
+// table or a file isn't allowed.
+func ReadOnly() Policy {
+	return func(query string) error {
+		if multiStatement(query) {
+			return restricted("multiple statements")
+		} else if hasInto(query) {
+			return restricted("INTO")
+		}
+
+		switch keyword, _ := firstKeyword(query); keyword {
+		case "SELECT", "SHOW", "DESCRIBE", "DESC":
+			return nil
+		case "EXPLAIN":
+			if !explainsWrite(query) {
+				return nil
+			}
+
+			return restricted("EXPLAIN ANALYZE")
+		case "WITH":
+			if statementType(query) == "" {
+				return nil
+			}
+		}
+
+		return restricted("read only")
+	}
+}
+
+// explainsWrite reports whether query explains a write with ANALYZE, which
+// runs the statement.
+func explainsWrite(query string) bool {
+	for _, tokens := range policyTokens(query) {
+		analyze, i := false, 1
+		if i < len(tokens) && tokens[i] == "(" {
+			for i++; i < len(tokens) && tokens[i] != ")"; i++ {
+				if strings.EqualFold(tokens[i], "ANALYZE") {
+					next := ""
+					if i+1 < len(tokens) {
+						next = strings.ToUpper(tokens[i+1])
+					}
+
+					analyze = next != "FALSE" && next != "OFF" && next != "0"
+				}
+			}
+		} else {
+			for ; i < len(tokens); i++ {
+				if keyword := strings.ToUpper(tokens[i]); keyword == "ANALYZE" {
+					analyze = true
+				} else if keyword != "VERBOSE" {
+					break
+				}
+			}
+		}
+
+		if !analyze {
+			continue
+		}
+
+		for _, token := range tokens[i:] {
+			if keyword := strings.ToUpper(token); dmlType(keyword) != "" || keyword == "MERGE" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NoDDL rejects statements changing the schema or privileges.
+func NoDDL() Policy {
+	return func(query string) error {
+		if multiStatement(query) {
+			return restricted("multiple statements")
+		}
+
+		switch keyword, _ := firstKeyword(query); keyword {
+		case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "GRANT", "REVOKE", "COMMENT":
+			return restricted("%s is ddl", keyword)
+		}
+
+		return nil
+	}
+}
+
+// TablePrefix allows statements whose tables all start with one of
+// prefixes. Tables are found after FROM, JOIN, STRAIGHT_JOIN, INTO, UPDATE,
+// TABLE and USING, a
+// schema qualified table is matched with its schema.
+func TablePrefix(prefixes ...string) Policy {
+	return func(query string) error {
+		if multiStatement(query) {
+			return restricted("multiple statements")
+		}
+
+		for _, table := range tables(query) {
+			ok := false
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(table, prefix) {
+					ok = true
+					break
+				}
+			}
+
+			if !ok {
+				return restricted("table %s", table)
+			}
+		}
+
+		return nil
+	}
+}
+
+// tokens splits query into upper cased keywords, unquoted identifiers and
+// punctuation, skipping string literals and comments. Dotted names are one
+// token. A backslash escapes a quote in a string literal if backslash, as
+// on mysql.
+func tokens(query string, backslash bool) []string {
+	var tokens []string
+	executable := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && strings.HasPrefix(query[i:], "/*!"):
+			// mysql runs the body of an executable comment, so it is
+			// tokenized past its optional version
+			for i += 3; i < len(query) && query[i] >= '0' && query[i] <= '9'; i++ {
+			}
+			executable = true
+		case c == '*' && executable && strings.HasPrefix(query[i:], "*/"):
+			i += 2
+			executable = false
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#':
+			if j := strings.IndexByte(query[i:], '\n'); j != -1 {
+				i += j + 1
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if j := strings.Index(query[i+2:], "*/"); j != -1 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+		case c == '\'':
+			i = skipQuoted(query, i, '\'', backslash)
+			tokens = append(tokens, "'")
+		case c == '"' || c == '`' || isIdentByte(c):
+			var name strings.Builder
+			for i < len(query) {
+				if q := query[i]; q == '"' || q == '`' {
+					j := skipQuoted(query, i, q, false)
+					name.WriteString(strings.ReplaceAll(query[i+1:j-1], string([]byte{q, q}), string(q)))
+					i = j
+				} else if isIdentByte(q) {
+					j := i
+					for j < len(query) && isIdentByte(query[j]) {
+						j++
+					}
+
+					name.WriteString(query[i:j])
+					i = j
+				} else {
+					break
+				}
+
+				if i < len(query) && query[i] == '.' {
+					name.WriteByte('.')
+					i++
+				} else {
+					break
+				}
+			}
+
+			tokens = append(tokens, name.String())
+		default:
+			tokens = append(tokens, query[i:i+1])
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index after the quote closing the one at i,
+// skipping the byte after a backslash if backslash.
+func skipQuoted(query string, i int, quote byte, backslash bool) int {
+	for i++; i < len(query); i++ {
+		if backslash && query[i] == '\\' {
+			i++
+		} else if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+
+			return i + 1
+		}
+	}
+
+	return len(query)
+}
+
+// policyTokens returns the tokens of query with and without backslash
+// escapes, as policies run without the dialect and a string ending in a
+// backslash ends on postgres, but not on mysql.
+func policyTokens(query string) [2][]string {
+	return [2][]string{tokens(query, true), tokens(query, false)}
+}
+
+// multiStatement reports whether query has a statement after a semicolon.
+func multiStatement(query string) bool {
+	for _, tokens := range policyTokens(query) {
+		for i, token := range tokens {
+			if token == ";" && i < len(tokens)-1 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasInto reports whether query has an INTO, writing the result of a
+// SELECT to a table or a file.
+func hasInto(query string) bool {
+	for _, tokens := range policyTokens(query) {
+		for _, token := range tokens {
+			if strings.EqualFold(token, "INTO") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tables returns the names following table keywords in query, and each
+// comma separated name of a FROM list.
+func tables(query string) []string {
+	var tables []string
+	seen := map[string]bool{}
+	for _, tokens := range policyTokens(query) {
+		for _, table := range tablesOf(tokens) {
+			if !seen[table] {
+				seen[table] = true
+				tables = append(tables, table)
+			}
+		}
+	}
+
+	return tables
+}
+
+func tablesOf(tokens []string) []string {
+	var tables []string
+	for i := 0; i < len(tokens); i++ {
+		switch keyword := strings.ToUpper(tokens[i]); keyword {
+		case "FROM", "JOIN", "STRAIGHT_JOIN", "INTO", "UPDATE", "TABLE", "USING":
+			// on duplicate key update, do update and for update have no table
+			if keyword == "UPDATE" && i > 0 {
+				if prev := strings.ToUpper(tokens[i-1]); prev == "KEY" || prev == "DO" || prev == "FOR" {
+					continue
+				}
+			}
+
+			for i+1 < len(tokens) && tokens[i+1] != "(" && tokens[i+1] != "'" && isIdentByte(tokens[i+1][0]) {
+				if isTableModifier(tokens[i+1]) {
+					i++
+					continue
+				}
+
+				tables = append(tables, tokens[i+1])
+				i++
+
+				// skip an alias to a comma continuing the list
+				j := i + 1
+				if j < len(tokens) && strings.EqualFold(tokens[j], "AS") {
+					j++
+				}
+
+				if j < len(tokens) && tokens[j] != "," && isIdentByte(tokens[j][0]) && !isClauseKeyword(tokens[j]) {
+					j++
+				}
+
+				if j >= len(tokens) || tokens[j] != "," {
+					break
+				}
+
+				i = j
+			}
+		}
+	}
+
+	return tables
+}
+
+// isTableModifier reports whether token modifies the table following it
+// rather than naming one.
+func isTableModifier(token string) bool {
+	switch strings.ToUpper(token) {
+	case "ONLY", "LATERAL", "LOW_PRIORITY", "IGNORE":
+		return true
+	}
+
+	return false
+}
+
+func isClauseKeyword(token string) bool {
+	switch strings.ToUpper(token) {
+	case "WHERE", "SET", "VALUES", "SELECT", "JOIN", "STRAIGHT_JOIN", "NATURAL", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "ON", "USING",
+		"GROUP", "ORDER", "LIMIT", "HAVING", "UNION", "RETURNING", "FOR", "WINDOW", "OFFSET", "DEFAULT":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTables(t *testing.T) {
+	cases := []struct {
+		query  string
+		tables []string
+	}{
+		{"select * from foo", []string{"foo"}},
+		{"select * from foo f join bar as b on f.id = b.id where f.s = 'from baz'", []string{"foo", "bar"}},
+		{"select * from foo, `bar` b, app.\"baz\" where 1", []string{"foo", "bar", "app.baz"}},
+		{"select * from (select * from foo) x", []string{"foo"}},
+		{"insert into foo (i) values (1) on duplicate key update i = 2", []string{"foo"}},
+		{"insert into foo (i) values (1) on conflict (i) do update set i = 2", []string{"foo"}},
+		{"select * from foo for update", []string{"foo"}},
+		{"update foo set i = 1", []string{"foo"}},
+		{"delete from foo -- from bar\nwhere i = 1", []string{"foo"}},
+		{"truncate table foo", []string{"foo"}},
+		{"select * from foo straight_join secret on 1", []string{"foo", "secret"}},
+		{"delete from foo using secret where foo.id = secret.id", []string{"foo", "secret"}},
+		{"delete from foo f using secret s, bar where 1", []string{"foo", "secret", "bar"}},
+		{"select * from foo join bar using (id)", []string{"foo", "bar"}},
+		{"select * from only foo", []string{"foo"}},
+		{"delete from only foo", []string{"foo"}},
+		{"update only foo set i = 1", []string{"foo"}},
+		{"update low_priority ignore foo set i = 1", []string{"foo"}},
+		{"select * from foo, lateral (select * from bar) x", []string{"foo", "bar"}},
+		{"select * from foo join lateral bar on 1", []string{"foo", "bar"}},
+		{"select 1 /*!50000 from secret */", []string{"secret"}},
+		{"select 1", nil},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.tables, tables(c.query), c.query)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	cases := []struct {
+		policy  Policy
+		query   string
+		allowed bool
+	}{
+		{ReadOnly(), "select * from foo", true},
+		{ReadOnly(), "  SHOW tables", true},
+		{ReadOnly(), "with x as (select 1) select * from x", true},
+		{ReadOnly(), "with x as (delete from foo returning id) select * from x", false},
+		{ReadOnly(), "update foo set i = 1", false},
+		{ReadOnly(), "select 1; delete from foo", false},
+		{ReadOnly(), "select ';' from foo;", true},
+		{ReadOnly(), `SELECT 'a\'' ; DELETE FROM t; -- '`, false},
+		{ReadOnly(), `SELECT 'a\' ; DELETE FROM t; -- '`, false},
+		{ReadOnly(), "SELECT * INTO newtab FROM t", false},
+		{ReadOnly(), "SELECT 1 INTO OUTFILE '/tmp/x'", false},
+		{ReadOnly(), "select 'into' from foo", true},
+		{ReadOnly(), "explain select * from foo", true},
+		{ReadOnly(), "explain analyze select * from foo", true},
+		{ReadOnly(), "explain delete from foo", true},
+		{ReadOnly(), "EXPLAIN ANALYZE DELETE FROM users", false},
+		{ReadOnly(), "explain verbose analyze update foo set i = 1", false},
+		{ReadOnly(), "explain (analyze, buffers) insert into foo values (1)", false},
+		{ReadOnly(), "explain (analyze true) with x as (delete from foo returning id) select * from x", false},
+		{ReadOnly(), "explain (analyze false) delete from foo", true},
+		{ReadOnly(), "explain (costs off) delete from foo", true},
+		{ReadOnly(), "SELECT 1 /*! INTO OUTFILE '/tmp/x' */", false},
+		{ReadOnly(), "SELECT 1 /*!50000 INTO OUTFILE '/tmp/x' */", false},
+		{ReadOnly(), "SELECT 1 /*!; DELETE FROM t */", false},
+		{ReadOnly(), "SELECT 1 /*! FROM foo */", true},
+		{ReadOnly(), "SELECT 1 /* INTO OUTFILE '/tmp/x' */", true},
+		{ReadOnly(), "/*! SELECT */ 1", true},
+		{NoDDL(), "insert into foo values (1)", true},
+		{NoDDL(), "drop table foo", false},
+		{NoDDL(), "/* x */ alter table foo add i int", false},
+		{NoDDL(), "select 1; drop table foo", false},
+		{NoDDL(), "/*!50000 drop table foo */", false},
+		{TablePrefix("app_"), "select * from app_foo join app_bar on 1", true},
+		{TablePrefix("app_"), "select * from app_foo join users on 1", false},
+		{TablePrefix("app_", "tmp_"), "insert into tmp_foo select * from app_foo", true},
+		{TablePrefix("app_"), "select 1", true},
+		{TablePrefix("app_"), "select * from app_foo straight_join secret on 1", false},
+		{TablePrefix("app_"), "delete from app_foo using secret where 1", false},
+		{TablePrefix("app_"), "delete from app_foo using app_bar where 1", true},
+		{TablePrefix("app_"), "select * from only app_foo", true},
+		{TablePrefix("app_"), "select * from app_foo, lateral (select * from app_bar) x", true},
+		{TablePrefix("app_"), "select 1 /*! from secret */", false},
+	}
+
+	for _, c := range cases {
+		err := c.policy(c.query)
+		if c.allowed {
+			assert.Nil(t, err, c.query)
+		} else {
+			assert.True(t, errors.Is(err, ErrRestricted), c.query)
+		}
+	}
+
+	assert.EqualError(t, TablePrefix("app_")("select * from users"), "sqlpp: statement not allowed: table users")
+}
+
+func TestDB_Clone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithRowsCapacity(8), WithMaxStmts(4))
+	ro := s.Clone(WithPolicy(ReadOnly()))
+	assert.Equal(t, s.DB, ro.DB)
+	assert.Equal(t, 8, ro.config().rowsCapacity)
+	assert.Equal(t, int64(4), ro.config().maxStmts)
+	assert.Empty(t, s.config().policies)
+
+	mock.ExpectPrepare("^select i from foo$").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+
+	var i int
+	assert.Nil(t, ro.QueryRow("select i from foo", nil, &i))
+	_, err = ro.Exec("delete from foo")
+	assert.True(t, errors.Is(err, ErrRestricted))
+	_, err = ro.Compile("delete from foo").Exec()
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	// closing the clone keeps the pool open
+	mock.ExpectClose()
+	assert.Nil(t, ro.Close())
+	assert.NotNil(t, mock.ExpectationsWereMet())
+	assert.Nil(t, s.Close())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Clone_cache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithResultCache(NewLRUCache(10)))
+	scan := func(rows *sql.Rows) (interface{}, error) {
+		var v string
+		return v, rows.Scan(&v)
+	}
+
+	mock.ExpectPrepare("^SELECT \\* FROM secret$").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("topsecret"))
+	ctx := Cache(context.Background(), time.Minute)
+	r, err := s.SelectContext(ctx, scan, "SELECT * FROM secret")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"topsecret"}, r)
+
+	// the policies run before the cache and coalesced calls are read
+	app := s.Clone(WithPolicy(TablePrefix("app_")))
+	_, err = app.SelectContext(ctx, scan, "SELECT * FROM secret")
+	assert.True(t, errors.Is(err, ErrRestricted))
+	_, err = app.SelectContext(Coalesce(ctx), scan, "SELECT * FROM secret")
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	// clones key their own entries
+	assert.NotEqual(t, s.CacheKey("SELECT * FROM secret"), s.Clone().CacheKey("SELECT * FROM secret"))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_tokens(t *testing.T) {
+	query := `select 'a\' ; delete from t; -- '`
+	assert.Equal(t, []string{"select", "'"}, tokens(query, true))
+	assert.Equal(t, []string{"select", "'", ";", "delete", "from", "t", ";"}, tokens(query, false))
+}
@@ -0,0 +1,78 @@
+package sqlpp
+
+import (
+	"sync"
+)
+
+// pooled buffers larger than this are dropped instead of being kept alive
+const maxPooledCap = 1 << 16
+
+var (
+	argsPool = sync.Pool{
+		New: func() interface{} {
+			args := make([]interface{}, 0, 16)
+			return &args
+		},
+	}
+
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 0, 256)
+			return &b
+		},
+	}
+
+	transformerPool = sync.Pool{
+		New: func() interface{} {
+			return &transformer{}
+		},
+	}
+)
+
+func getArgs() *[]interface{} {
+	return argsPool.Get().(*[]interface{})
+}
+
+func putArgs(args *[]interface{}) {
+	if cap(*args) > maxPooledCap {
+		return
+	}
+
+	// drop the references so pooled slices don't keep args alive
+	tempArgs := *args
+	for i := range tempArgs {
+		tempArgs[i] = nil
+	}
+
+	*args = tempArgs[:0]
+	argsPool.Put(args)
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledCap {
+		return
+	}
+
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+func getTransformer(numbered string) *transformer {
+	t := transformerPool.Get().(*transformer)
+	t.numbered = numbered
+	return t
+}
+
+func putTransformer(t *transformer) {
+	if t.Cap() > maxPooledCap {
+		return
+	}
+
+	t.Reset()
+	t.n = 0
+	transformerPool.Put(t)
+}
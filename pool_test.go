@@ -0,0 +1,29 @@
+package sqlpp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_putArgs(t *testing.T) {
+	args := getArgs()
+	*args = append(*args, 1, "a")
+	tempArgs := *args
+
+	putArgs(args)
+
+	assert.Len(t, *args, 0)
+	assert.Equal(t, []interface{}{nil, nil}, tempArgs)
+}
+
+func Test_putTransformer(t *testing.T) {
+	tr := getTransformer("$")
+	tr.write("select ? from foo")
+	assert.Equal(t, "select $1 from foo", tr.String())
+
+	putTransformer(tr)
+
+	assert.Equal(t, 0, tr.Len())
+	assert.Equal(t, int64(0), tr.n)
+}
@@ -0,0 +1,17 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	postgresErrCachedPlanChanged = "cached plan must not change result type"
+)
+
+func isPostgresCachedPlanChanged(err error) bool {
+	return err != nil && strings.Contains(err.Error(), postgresErrCachedPlanChanged)
+}
+
+func postgresQuoteIdent(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
@@ -0,0 +1,40 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isPostgresCachedPlanChanged(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("pq: cached plan must not change result type"),
+			true,
+		},
+		{
+			errors.New("ERROR: cached plan must not change result type (SQLSTATE 0A000)"),
+			true,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isPostgresCachedPlanChanged(c.err))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package sqlpp
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrOverloaded = errors.New("sqlpp: overloaded")
+)
+
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityHigh
+	PriorityBackground
+)
+
+// WithPriority returns a ctx whose queries wait for the concurrency limit
+// with priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey, p)
+}
+
+// WithDefaultPriority sets the priority of queries whose ctx has none,
+// e.g. PriorityBackground for the Clone handed to batch jobs.
+func WithDefaultPriority(p Priority) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.priority = p
+	}
+}
+
+// WithConcurrencyLimit lets n queries run at a time, including reading
+// the rows of Query. Queries over it wait, the high priority ones first
+// and background ones last. Background queries fail with ErrOverloaded
+// when backgroundQueue of them are already waiting. Clones share the limit.
+func WithConcurrencyLimit(n, backgroundQueue int) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.limiter = nil
+		if n > 0 {
+			sqlpp.cfg.limiter = newLimiter(n, backgroundQueue)
+		}
+	}
+}
+
+func (sqlpp *DB) priority(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey).(Priority); ok {
+		return p
+	}
+
+	return sqlpp.config().priority
+}
+
+type limiter struct {
+	mu sync.Mutex
+
+	slots           int
+	used            int
+	backgroundQueue int
+
+	// waiting chans by priority, closed to hand over a slot
+	waiters [3]list.List
+}
+
+func newLimiter(slots, backgroundQueue int) *limiter {
+	return &limiter{slots: slots, backgroundQueue: backgroundQueue}
+}
+
+// priorityOrder is the order waiters get slots in.
+var priorityOrder = [...]Priority{PriorityHigh, PriorityNormal, PriorityBackground}
+
+func (l *limiter) acquire(ctx context.Context, p Priority) error {
+	if p < PriorityNormal || p > PriorityBackground {
+		p = PriorityNormal
+	}
+
+	l.mu.Lock()
+	if l.used < l.slots {
+		l.used++
+		l.mu.Unlock()
+		return nil
+	}
+
+	if p == PriorityBackground && l.waiters[p].Len() >= l.backgroundQueue {
+		l.mu.Unlock()
+		return ErrOverloaded
+	}
+
+	ready := make(chan struct{})
+	e := l.waiters[p].PushBack(ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	select {
+	case <-ready:
+		// handed a slot meanwhile, pass it on
+		l.mu.Unlock()
+		l.release()
+	default:
+		l.waiters[p].Remove(e)
+		l.mu.Unlock()
+	}
+
+	return ctx.Err()
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, p := range priorityOrder {
+		if e := l.waiters[p].Front(); e != nil {
+			close(l.waiters[p].Remove(e).(chan struct{}))
+			return
+		}
+	}
+
+	l.used--
+}
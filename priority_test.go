@@ -0,0 +1,92 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(1, 1)
+	ctx := context.Background()
+	assert.Nil(t, l.acquire(ctx, PriorityNormal))
+
+	got := make(chan Priority, 3)
+	wait := func(p Priority) {
+		go func() {
+			assert.Nil(t, l.acquire(ctx, p))
+			got <- p
+		}()
+
+		// queue in a known order
+		for {
+			l.mu.Lock()
+			n := l.waiters[p].Len()
+			l.mu.Unlock()
+			if n > 0 {
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	wait(PriorityBackground)
+	assert.Equal(t, ErrOverloaded, l.acquire(ctx, PriorityBackground))
+	wait(PriorityNormal)
+	wait(PriorityHigh)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.acquire(timeout, PriorityHigh))
+
+	for _, p := range []Priority{PriorityHigh, PriorityNormal, PriorityBackground} {
+		l.release()
+		assert.Equal(t, p, <-got)
+	}
+
+	l.release()
+	assert.Equal(t, 0, l.used)
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithConcurrencyLimit(1, 0))
+	batch := s.Clone(WithDefaultPriority(PriorityBackground))
+	mock.ExpectPrepare("^select sleep").
+		ExpectExec().WillDelayFor(50 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	done := make(chan error)
+	go func() {
+		_, err := s.Exec("select sleep(1)")
+		done <- err
+	}()
+
+	for l := s.config().limiter; ; time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		used := l.used
+		l.mu.Unlock()
+		if used == 1 {
+			break
+		}
+	}
+
+	_, err = s.ExecContext(WithPriority(context.Background(), PriorityBackground), "select 1")
+	assert.Equal(t, ErrOverloaded, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	var i int
+	assert.Equal(t, context.DeadlineExceeded, s.QueryRowContext(ctx, "select 1", nil, &i))
+	assert.Equal(t, PriorityBackground, batch.priority(context.Background()))
+	assert.Equal(t, PriorityHigh, batch.priority(WithPriority(context.Background(), PriorityHigh)))
+
+	assert.Nil(t, <-done)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
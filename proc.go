@@ -0,0 +1,130 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func (sqlpp *DB) CallProc(name string, in []interface{}, out []interface{}) error {
+	return sqlpp.CallProcContext(context.Background(), name, in, out)
+}
+
+// CallProcContext calls the stored procedure name with in followed by out
+// parameters. out must be pointers to scan into, or sql.Out values.
+// Postgres returns the out parameters as a row, SQL Server binds them as
+// sql.Out, and MySQL has no sql.Out support, so they go through session
+// variables read back on the same connection. Other dialects fail with
+// ErrNotSupported.
+func (sqlpp *DB) CallProcContext(ctx context.Context, name string, in []interface{}, out []interface{}) error {
+	name = sqlpp.QuoteIdent(name)
+	switch {
+	case sqlpp.dialect.flavor == postgresFlavor:
+		return sqlpp.callPostgres(ctx, name, in, out)
+	case sqlpp.dialect == sqlserverDialect:
+		return sqlpp.callSQLServer(ctx, name, in, out)
+	case sqlpp.dialect.flavor == mysqlFlavor:
+		return sqlpp.callMySQL(ctx, name, in, out)
+	}
+
+	return fmt.Errorf("%w: %s", ErrNotSupported, sqlpp.dialect.name)
+}
+
+// callPostgres passes NULL for out parameters and the value of in-out
+// ones, scanning them from the row CALL returns.
+func (sqlpp *DB) callPostgres(ctx context.Context, name string, in []interface{}, out []interface{}) error {
+	params := make([]string, 0, len(in)+len(out))
+	args := append([]interface{}{}, in...)
+	for range in {
+		params = append(params, "?")
+	}
+
+	dest := make([]interface{}, len(out))
+	for i, o := range out {
+		dest[i] = procDest(o)
+		if so, ok := o.(sql.Out); ok && so.In {
+			params = append(params, "?")
+			args = append(args, reflect.ValueOf(so.Dest).Elem().Interface())
+		} else {
+			params = append(params, "NULL")
+		}
+	}
+
+	query := "CALL " + name + procParams(params)
+	if len(out) == 0 {
+		_, err := sqlpp.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	return sqlpp.QueryRowContext(ctx, query, args, dest...)
+}
+
+func (sqlpp *DB) callSQLServer(ctx context.Context, name string, in []interface{}, out []interface{}) error {
+	params := make([]string, 0, len(in)+len(out))
+	args := make([]interface{}, 0, len(in)+len(out))
+	for _, i := range in {
+		params = append(params, "?")
+		args = append(args, i)
+	}
+
+	for _, o := range out {
+		if _, ok := o.(sql.Out); !ok {
+			o = sql.Out{Dest: o}
+		}
+
+		params = append(params, "? OUTPUT")
+		args = append(args, o)
+	}
+
+	query := "EXEC " + name
+	if len(params) > 0 {
+		query += " " + strings.Join(params, ", ")
+	}
+
+	_, err := sqlpp.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (sqlpp *DB) callMySQL(ctx context.Context, name string, in []interface{}, out []interface{}) error {
+	vars := make([]string, len(out))
+	dest := make([]interface{}, len(out))
+	for i, o := range out {
+		vars[i] = "@sqlpp_out" + strconv.Itoa(i)
+		dest[i] = procDest(o)
+	}
+
+	params := make([]string, len(in), len(in)+len(vars))
+	for i := range in {
+		params[i] = "?"
+	}
+
+	query := "CALL " + name + procParams(append(params, vars...))
+	return sqlpp.WithConn(ctx, func(c *Conn) error {
+		if _, err := c.ExecContext(ctx, query, in...); err != nil || len(out) == 0 {
+			return err
+		}
+
+		return c.QueryRowContext(ctx, "SELECT "+strings.Join(vars, ","), nil, dest...)
+	})
+}
+
+// procParams joins params in parentheses, a single ? spaced apart as (?)
+// expands a slice.
+func procParams(params []string) string {
+	if len(params) == 1 && params[0] == "?" {
+		return "( ? )"
+	}
+
+	return "(" + strings.Join(params, ",") + ")"
+}
+
+func procDest(o interface{}) interface{} {
+	if so, ok := o.(sql.Out); ok {
+		return so.Dest
+	}
+
+	return o
+}
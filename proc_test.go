@@ -0,0 +1,74 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_CallProc(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sDb, sMock, sErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual), sqlmock.ValueConverterOption(outConverter{}))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+	assert.Nil(t, sErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+	ss := NewSQLServer(sDb)
+
+	mMock.ExpectPrepare("CALL `foo`(?,?,@sqlpp_out0,@sqlpp_out1)").
+		ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("SELECT @sqlpp_out0,@sqlpp_out1").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"a", "b"}).AddRow(3, "baz"))
+	mMock.ExpectPrepare("CALL `app`.`bar`(?,?)").
+		ExpectExec().WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("CALL `qux`( ? )").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mMock.ExpectPrepare("CALL `baz`(@sqlpp_out0)").
+		ExpectExec().WillReturnError(errors.New("error"))
+
+	var i int
+	var s string
+	assert.Nil(t, sm.CallProc("foo", []interface{}{1, 2}, []interface{}{&i, sql.Out{Dest: &s}}))
+	assert.Equal(t, 3, i)
+	assert.Equal(t, "baz", s)
+	assert.Nil(t, sm.CallProc("app.bar", []interface{}{1, 2}, nil))
+	assert.Nil(t, sm.CallProc("qux", []interface{}{1}, nil))
+	assert.Equal(t, errors.New("error"), sm.CallProc("baz", nil, []interface{}{&i}))
+
+	// out parameters are passed as NULL and in-out ones by value
+	s = "in"
+	pMock.ExpectPrepare(`CALL "foo"($1,NULL,$2)`).
+		ExpectQuery().WithArgs(1, "in").WillReturnRows(sqlmock.NewRows([]string{"a", "b"}).AddRow(4, "out"))
+	pMock.ExpectPrepare(`CALL "bar"( $1 )`).ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, sp.CallProc("foo", []interface{}{1}, []interface{}{&i, sql.Out{Dest: &s, In: true}}))
+	assert.Equal(t, 4, i)
+	assert.Equal(t, "out", s)
+	assert.Nil(t, sp.CallProc("bar", []interface{}{1}, nil))
+
+	sMock.ExpectPrepare("EXEC [foo] @p1, @p2 OUTPUT, @p3 OUTPUT").
+		ExpectExec().WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	sMock.ExpectPrepare("EXEC [bar]").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, ss.CallProc("foo", []interface{}{1}, []interface{}{&i, sql.Out{Dest: &s, In: true}}))
+	assert.Nil(t, ss.CallProc("bar", nil, nil))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+	assert.Nil(t, sMock.ExpectationsWereMet())
+}
+
+type outConverter struct{}
+
+func (outConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if _, ok := v.(sql.Out); ok {
+		return v, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
@@ -0,0 +1,269 @@
+package sqlpp
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profiler aggregates the calls, rows and latencies of queries by
+// fingerprint in memory. Add its Hook with WithHook.
+type Profiler struct {
+	mu       sync.Mutex
+	samples  int
+	profiles map[string]*profile
+}
+
+type profile struct {
+	calls  int64
+	errors int64
+	rows   int64
+	total  time.Duration
+	max    time.Duration
+
+	// calls preparing a stmt, and their prepares
+	preparing int64
+	prepares  int64
+
+	// slice args expanded into a (?), and their total length
+	expansions int64
+	expanded   int64
+
+	// ring of the last latencies for percentiles
+	latencies []time.Duration
+	next      int
+}
+
+// QueryProfile is the aggregate of the queries with the same fingerprint.
+// Percentiles are of the last samples latencies of the Profiler.
+type QueryProfile struct {
+	Fingerprint string
+
+	Calls  int64
+	Errors int64
+	Rows   int64
+
+	// ErrorRate is Errors per call, CacheHitRate the portion of calls that
+	// found their stmt cached.
+	ErrorRate    float64
+	CacheHitRate float64
+	Prepares     int64
+	// AvgExpansion is the average length of the slice args expanded into a
+	// (?) of the query.
+	AvgExpansion float64
+
+	Total time.Duration
+	Max   time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// NewProfiler returns a Profiler keeping the last samples latencies of
+// each fingerprint, 1024 if samples is not positive.
+func NewProfiler(samples int) *Profiler {
+	if samples <= 0 {
+		samples = 1024
+	}
+
+	return &Profiler{samples: samples, profiles: map[string]*profile{}}
+}
+
+func (p *Profiler) Hook(ctx context.Context, e *QueryEvent) {
+	fingerprint := Fingerprint(e.Query)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prof, ok := p.profiles[fingerprint]
+	if !ok {
+		prof = &profile{}
+		p.profiles[fingerprint] = prof
+	}
+
+	prof.calls++
+	if e.Err != nil {
+		prof.errors++
+	}
+
+	if e.Prepares > 0 {
+		prof.preparing++
+		prof.prepares += int64(e.Prepares)
+	}
+
+	if strings.Contains(e.Query, "(?)") {
+		for _, arg := range e.Args {
+			if expands(arg) {
+				prof.expansions++
+				prof.expanded += int64(reflect.ValueOf(arg).Len())
+			}
+		}
+	}
+
+	prof.rows += int64(e.Rows)
+	prof.total += e.Duration
+	if e.Duration > prof.max {
+		prof.max = e.Duration
+	}
+
+	if len(prof.latencies) < p.samples {
+		prof.latencies = append(prof.latencies, e.Duration)
+	} else {
+		prof.latencies[prof.next] = e.Duration
+		prof.next = (prof.next + 1) % p.samples
+	}
+}
+
+// Profiles returns the profile of every fingerprint, by total time spent
+// descending.
+func (p *Profiler) Profiles() []QueryProfile {
+	p.mu.Lock()
+	profiles := make([]QueryProfile, 0, len(p.profiles))
+	for fingerprint, prof := range p.profiles {
+		latencies := append([]time.Duration(nil), prof.latencies...)
+		profiles = append(profiles, QueryProfile{
+			Fingerprint: fingerprint,
+
+			Calls:  prof.calls,
+			Errors: prof.errors,
+			Rows:   prof.rows,
+
+			ErrorRate:    float64(prof.errors) / float64(prof.calls),
+			CacheHitRate: float64(prof.calls-prof.preparing) / float64(prof.calls),
+			Prepares:     prof.prepares,
+			AvgExpansion: ratio(prof.expanded, prof.expansions),
+
+			Total: prof.total,
+			Max:   prof.max,
+			P50:   percentile(latencies, 50),
+			P95:   percentile(latencies, 95),
+			P99:   percentile(latencies, 99),
+		})
+	}
+	p.mu.Unlock()
+
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Total != profiles[j].Total {
+			return profiles[i].Total > profiles[j].Total
+		}
+
+		return profiles[i].Fingerprint < profiles[j].Fingerprint
+	})
+
+	return profiles
+}
+
+// Top returns the n profiles the most time was spent on.
+func (p *Profiler) Top(n int) []QueryProfile {
+	profiles := p.Profiles()
+	if n >= 0 && n < len(profiles) {
+		profiles = profiles[:n]
+	}
+
+	return profiles
+}
+
+// TopQueries returns the n profiles with the most prepares, the queries
+// churning the stmt cache, by total time spent after that.
+func (p *Profiler) TopQueries(n int) []QueryProfile {
+	profiles := p.Profiles()
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Prepares > profiles[j].Prepares
+	})
+
+	if n >= 0 && n < len(profiles) {
+		profiles = profiles[:n]
+	}
+
+	return profiles
+}
+
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.profiles = map[string]*profile{}
+}
+
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(d)
+}
+
+// percentile sorts latencies and returns the nearest rank percentile.
+func percentile(latencies []time.Duration, pct int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := (pct*len(latencies) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return latencies[rank-1]
+}
+
+// Fingerprint normalizes query so the queries differing only by literal
+// values or whitespace share it. String and number literals and postgres
+// $N placeholders become ?.
+func Fingerprint(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+
+		case c == '\'':
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+
+					break
+				}
+			}
+
+			c = '?'
+
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]),
+			isDigit(c) && (i == 0 || !isIdentByte(query[i-1])):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+
+			c = '?'
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+
+		space = false
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
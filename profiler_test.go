@@ -0,0 +1,109 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := []struct {
+		query, fingerprint string
+	}{
+		{"select * from foo", "select * from foo"},
+		{"  select *\n\tfrom   foo  ", "select * from foo"},
+		{"select * from foo where i = 12 and f > 1.5", "select * from foo where i = ? and f > ?"},
+		{"select * from foo where s = 'it''s' and t = ''", "select * from foo where s = ? and t = ?"},
+		{"select * from foo2 where i = $1 and j = $12", "select * from foo2 where i = ? and j = ?"},
+		{"select col_1 from foo where i in (?)", "select col_1 from foo where i in (?)"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.fingerprint, Fingerprint(c.query), c.query)
+	}
+}
+
+func TestProfiler(t *testing.T) {
+	p := NewProfiler(10)
+	ctx := context.Background()
+
+	for i := 1; i <= 20; i++ {
+		p.Hook(ctx, &QueryEvent{Query: "select * from foo where i = ?", Duration: time.Duration(i) * time.Millisecond, Rows: 2})
+	}
+
+	p.Hook(ctx, &QueryEvent{Query: "update foo set i = 1", Duration: time.Second, Err: errors.New("boom")})
+	p.Hook(ctx, &QueryEvent{Query: "update foo  set i = 2", Duration: time.Second})
+
+	profiles := p.Profiles()
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, QueryProfile{
+		Fingerprint:  "update foo set i = ?",
+		Calls:        2,
+		Errors:       1,
+		ErrorRate:    0.5,
+		CacheHitRate: 1,
+		Total:        2 * time.Second,
+		Max:          time.Second,
+		P50:          time.Second,
+		P95:          time.Second,
+		P99:          time.Second,
+	}, profiles[0])
+	assert.Equal(t, QueryProfile{
+		Fingerprint:  "select * from foo where i = ?",
+		Calls:        20,
+		Rows:         40,
+		CacheHitRate: 1,
+		Total:        210 * time.Millisecond,
+		Max:          20 * time.Millisecond,
+		P50:          15 * time.Millisecond,
+		P95:          20 * time.Millisecond,
+		P99:          20 * time.Millisecond,
+	}, profiles[1])
+
+	assert.Equal(t, profiles[:1], p.Top(1))
+	assert.Equal(t, profiles, p.Top(5))
+
+	p.Reset()
+	assert.Empty(t, p.Profiles())
+}
+
+func TestProfiler_TopQueries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	p := NewProfiler(10)
+	s := NewMySQL(db, WithHook(p.Hook))
+
+	// every length of the in list is another stmt
+	in := "update foo set i = 0 where id in (?)"
+	mock.ExpectPrepare("update foo set i = 0 where id in (?)").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("update foo set i = 0 where id in (?,?)").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 2))
+	prepared := mock.ExpectPrepare("update foo set i = 0 where id in (?,?,?)")
+	prepared.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+	prepared.ExpectExec().WillReturnError(errors.New("boom"))
+
+	mock.ExpectPrepare("select i from foo where id = ?").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}))
+
+	for _, ids := range [][]int{{1}, {1, 2}, {1, 2, 3}, {1, 2, 3}} {
+		s.Exec(in, ids)
+	}
+
+	var i int
+	s.Row("select i from foo where id = ?", 1).Scan(&i)
+
+	top := p.TopQueries(1)
+	assert.Len(t, top, 1)
+	assert.Equal(t, Fingerprint(in), top[0].Fingerprint)
+	assert.Equal(t, int64(4), top[0].Calls)
+	assert.Equal(t, int64(3), top[0].Prepares)
+	assert.Equal(t, 0.25, top[0].CacheHitRate)
+	assert.Equal(t, 0.25, top[0].ErrorRate)
+	assert.Equal(t, 2.25, top[0].AvgExpansion)
+
+	assert.Len(t, p.TopQueries(5), 2)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
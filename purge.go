@@ -0,0 +1,133 @@
+package sqlpp
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+type purge struct {
+	args     []interface{}
+	archive  string
+	key      string
+	progress func(purged int64)
+}
+
+type PurgeOption func(*purge)
+
+// PurgeArgs binds the placeholders of the PurgeOldRows predicate.
+func PurgeArgs(args ...interface{}) PurgeOption {
+	return func(p *purge) {
+		p.args = args
+	}
+}
+
+// PurgeArchive makes PurgeOldRows move the rows into table, having the same
+// columns, instead of deleting them. On mysql the batches are ordered by
+// key so both statements of a batch see the same rows.
+func PurgeArchive(table, key string) PurgeOption {
+	return func(p *purge) {
+		p.archive = table
+		p.key = key
+	}
+}
+
+// PurgeProgress calls fn with the rows purged so far after every batch.
+func PurgeProgress(fn func(purged int64)) PurgeOption {
+	return func(p *purge) {
+		p.progress = fn
+	}
+}
+
+// PurgeOldRows deletes the rows of table matching predicate in batches of
+// batchSize, sleeping pause between them so replicas keep up. It stops when
+// a batch comes up short or ctx is done, returning the rows purged. The
+// predicate is written into the query as is, it must not hold user input,
+// which binds through its placeholders with PurgeArgs instead.
+func (sqlpp *DB) PurgeOldRows(ctx context.Context, table, predicate string, batchSize int, pause time.Duration, opts ...PurgeOption) (int64, error) {
+	if err := sqlpp.helperSQL(); err != nil {
+		return 0, err
+	}
+
+	p := &purge{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		n, err := sqlpp.purgeBatch(ctx, table, predicate, batchSize, p)
+		total += n
+		if err != nil {
+			return total, err
+		}
+
+		if p.progress != nil {
+			p.progress(total)
+		}
+
+		if n < int64(batchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+}
+
+func (sqlpp *DB) purgeBatch(ctx context.Context, table, predicate string, batchSize int, p *purge) (int64, error) {
+	quoted := sqlpp.QuoteIdent(table)
+	limit := " LIMIT " + strconv.Itoa(batchSize)
+
+	// postgres deletes have no limit, the batch is picked by row address
+	where := " WHERE " + predicate + limit
+	if sqlpp.dialect.flavor == postgresFlavor {
+		where = " WHERE ctid IN (SELECT ctid FROM " + quoted + " WHERE " + predicate + limit + ")"
+	}
+
+	if p.archive == "" {
+		result, err := sqlpp.ExecContext(ctx, "DELETE FROM "+quoted+where, p.args...)
+		if err != nil {
+			return 0, err
+		}
+
+		return result.RowsAffected()
+	}
+
+	archive := sqlpp.QuoteIdent(p.archive)
+	if sqlpp.dialect.flavor == postgresFlavor {
+		result, err := sqlpp.ExecContext(ctx, "WITH moved AS (DELETE FROM "+quoted+where+" RETURNING *) INSERT INTO "+
+			archive+" SELECT * FROM moved", p.args...)
+		if err != nil {
+			return 0, err
+		}
+
+		return result.RowsAffected()
+	}
+
+	// the insert locks the rows it reads until the delete removes them
+	where = " WHERE " + predicate + " ORDER BY " + sqlpp.QuoteIdent(p.key) + limit
+	var n int64
+	err := sqlpp.WithTx(ctx, nil, func(tx *Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO "+archive+" SELECT * FROM "+quoted+where, p.args...); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM "+quoted+where, p.args...)
+		if err != nil {
+			return err
+		}
+
+		n, err = result.RowsAffected()
+		return err
+	})
+
+	return n, err
+}
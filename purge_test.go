@@ -0,0 +1,58 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_PurgeOldRows(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+	ctx := context.Background()
+	cutoff := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	prepared := mock.ExpectPrepare("DELETE FROM `logs` WHERE created < ? LIMIT 2")
+	prepared.ExpectExec().WithArgs(cutoff).WillReturnResult(sqlmock.NewResult(0, 2))
+	prepared.ExpectExec().WithArgs(cutoff).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var progress []int64
+	n, err := m.PurgeOldRows(ctx, "logs", "created < ?", 2, time.Millisecond,
+		PurgeArgs(cutoff), PurgeProgress(func(purged int64) { progress = append(progress, purged) }))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, []int64{2, 3}, progress)
+
+	mock.ExpectPrepare(`WITH moved AS (DELETE FROM "logs" WHERE ctid IN (SELECT ctid FROM "logs" WHERE created < $1 LIMIT 10)` +
+		` RETURNING *) INSERT INTO "logs_archive" SELECT * FROM moved`).
+		ExpectExec().WithArgs(cutoff).WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err = p.PurgeOldRows(ctx, "logs", "created < ?", 10, 0, PurgeArgs(cutoff), PurgeArchive("logs_archive", "id"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), n)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `logs_archive` SELECT * FROM `logs` WHERE done ORDER BY `id` LIMIT 10").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectPrepare("DELETE FROM `logs` WHERE done ORDER BY `id` LIMIT 10").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectCommit()
+
+	n, err = m.PurgeOldRows(ctx, "logs", "done", 10, 0, PurgeArchive("logs_archive", "id"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	n, err = m.PurgeOldRows(canceled, "logs", "done", 10, 0)
+	assert.Equal(t, context.Canceled, err)
+	assert.Zero(t, n)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
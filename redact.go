@@ -0,0 +1,103 @@
+package sqlpp
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "xxxxx"
+
+var redactParam = regexp.MustCompile(`(?i)\b(password|passwd|pwd|sslpassword)(\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s&;]*)`)
+
+// Redact returns dsn with its passwords replaced, for url dsns, mysql
+// user:password@net(addr)/db dsns and key=value dsns.
+func Redact(dsn string) string {
+	dsn, _ = redactDSN(dsn)
+	return dsn
+}
+
+// redactDSN returns the redacted dsn and the passwords it replaced.
+func redactDSN(dsn string) (string, []string) {
+	var passwords []string
+
+	// the user info ends at the last @ before the path, mysql dsns have no
+	// scheme and keep the network address after the @
+	start := 0
+	if i := strings.Index(dsn, "://"); i != -1 {
+		start = i + 3
+	}
+
+	end := len(dsn)
+	if i := strings.IndexByte(dsn[start:], '?'); i != -1 {
+		end = start + i
+	}
+
+	if slash := strings.LastIndexByte(dsn[start:end], '/'); slash != -1 {
+		end = start + slash
+	}
+
+	if start > 0 {
+		if slash := strings.IndexByte(dsn[start:end], '/'); slash != -1 {
+			end = start + slash
+		}
+	}
+
+	if at := strings.LastIndexByte(dsn[start:end], '@'); at != -1 {
+		at += start
+		if colon := strings.IndexByte(dsn[start:at], ':'); colon != -1 {
+			colon += start
+			password := dsn[colon+1 : at]
+			if unescaped, err := url.PathUnescape(password); err == nil && start > 0 {
+				passwords = append(passwords, unescaped)
+			}
+
+			passwords = append(passwords, password)
+			dsn = dsn[:colon+1] + redacted + dsn[at:]
+		}
+	}
+
+	dsn = redactParam.ReplaceAllStringFunc(dsn, func(param string) string {
+		m := redactParam.FindStringSubmatch(param)
+		passwords = append(passwords, strings.Trim(m[3], "'"))
+		return m[1] + m[2] + redacted
+	})
+
+	return dsn, passwords
+}
+
+// redactError hides the passwords of dsn in the message of err, keeping
+// err for errors.Is and errors.As.
+func redactError(err error, dsn string) error {
+	if err == nil || dsn == "" {
+		return err
+	}
+
+	msg := err.Error()
+	safe, passwords := redactDSN(dsn)
+	safe = strings.ReplaceAll(msg, dsn, safe)
+	for _, password := range passwords {
+		if password != "" {
+			safe = strings.ReplaceAll(safe, password, redacted)
+		}
+	}
+
+	if safe == msg {
+		return err
+	}
+
+	return &redactedError{msg: safe, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,69 @@
+package sqlpp
+
+import "context"
+
+// track registers a query to be cancelled by Shutdown, returning its ctx
+// and the func to call once it's done.
+func (sqlpp *DB) track(ctx context.Context) (context.Context, func(), error) {
+	sqlpp.inflightMu.Lock()
+	defer sqlpp.inflightMu.Unlock()
+
+	if sqlpp.shuttingDown {
+		return ctx, nil, ErrClosed
+	}
+
+	if sqlpp.inflight == nil {
+		sqlpp.inflight = map[uint64]context.CancelFunc{}
+	}
+
+	ctx, cancel := sqlpp.withTimeout(ctx)
+	sqlpp.inflightID++
+	id := sqlpp.inflightID
+	sqlpp.inflight[id] = cancel
+	sqlpp.inflightWg.Add(1)
+
+	return ctx, func() {
+		sqlpp.inflightMu.Lock()
+		delete(sqlpp.inflight, id)
+		sqlpp.inflightMu.Unlock()
+
+		cancel()
+		sqlpp.inflightWg.Done()
+	}, nil
+}
+
+// Shutdown stops new queries with ErrClosed, waits for the running ones
+// until ctx is done, then cancels the ones left and closes the db. It
+// returns ctx.Err() if queries had to be cancelled.
+func (sqlpp *DB) Shutdown(ctx context.Context) error {
+	sqlpp.inflightMu.Lock()
+	sqlpp.shuttingDown = true
+	sqlpp.inflightMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		sqlpp.inflightWg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+
+		sqlpp.inflightMu.Lock()
+		for _, cancel := range sqlpp.inflight {
+			cancel()
+		}
+		sqlpp.inflightMu.Unlock()
+
+		<-done
+	}
+
+	if e := sqlpp.Close(); err == nil {
+		err = e
+	}
+
+	return err
+}
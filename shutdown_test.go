@@ -0,0 +1,77 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func inflight(s *DB) int {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+
+	return len(s.inflight)
+}
+
+func TestDB_Shutdown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	mock.ExpectPrepare("^select sleep").WillBeClosed().
+		ExpectExec().WillDelayFor(time.Minute).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	errs := make(chan error)
+	go func() {
+		_, err := s.Exec("select sleep(60)")
+		errs <- err
+	}()
+
+	for inflight(s) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, s.Shutdown(ctx))
+	assert.Equal(t, ErrClosed, <-errs)
+	assert.Equal(t, 0, inflight(s))
+
+	_, err = s.Exec("select 1")
+	assert.Equal(t, ErrClosed, err)
+	var i int
+	assert.Equal(t, ErrClosed, s.QueryRow("select 1", nil, &i))
+	_, err = s.Compile("select 1").Query(nil, nil)
+	assert.Equal(t, ErrClosed, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Shutdown_drained(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	mock.ExpectPrepare("^update foo").WillBeClosed().
+		ExpectExec().WillDelayFor(20 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	errs := make(chan error)
+	go func() {
+		_, err := s.Exec("update foo set i = 1")
+		errs <- err
+	}()
+
+	for inflight(s) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Nil(t, s.Shutdown(context.Background()))
+	assert.Nil(t, <-errs)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,345 @@
+package sqlpp
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownFormat = errors.New("sqlpp: unknown dump format")
+)
+
+type DumpFormat int
+
+const (
+	// DumpJSON writes an array of rows keyed by column, the rows of a Fixture.
+	DumpJSON DumpFormat = iota
+	// DumpCSV writes a header of the columns then the rows, NULL as \N.
+	DumpCSV
+)
+
+// Dumped strings starting with a backslash are marked values: \N is the
+// csv NULL, \x a hex encoded binary value, and a string of the column that
+// starts with a backslash has another one prepended.
+const (
+	csvNull      = `\N`
+	binaryPrefix = `\x`
+)
+
+// DumpTable streams every row of table to w in format, reading them through
+// the hooks and policies. Text columns are written as strings, binary ones
+// as \x and their hex, and times in the format the db parses, so LoadTable
+// can insert the dump back.
+func (sqlpp *DB) DumpTable(ctx context.Context, table string, w io.Writer, format DumpFormat) error {
+	if format != DumpJSON && format != DumpCSV {
+		return ErrUnknownFormat
+	}
+
+	rows, err := sqlpp.RowsContext(ctx, "SELECT * FROM "+sqlpp.QuoteIdent(table))
+	if err != nil {
+		return err
+	}
+
+	return sqlpp.writeRows(rows, w, format)
+}
+
+// writeRows writes rows to w in format and closes them.
+func (sqlpp *DB) writeRows(rows *sql.Rows, w io.Writer, format DumpFormat) error {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	binary := make([]bool, len(columns))
+	if types, err := rows.ColumnTypes(); err == nil {
+		for i, t := range types {
+			binary[i] = isBinaryType(t.DatabaseTypeName())
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	bw := bufio.NewWriter(w)
+	write := sqlpp.jsonRowWriter(bw, columns)
+	if format == DumpCSV {
+		write = sqlpp.csvRowWriter(bw, columns)
+	}
+
+	if err = write(nil); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		for i, v := range values {
+			values[i] = sqlpp.dumpValue(v, binary[i])
+		}
+
+		if err = write(values); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if err = write(nil); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// dumpValue returns v as written to a dump, bytes of binary columns or
+// that aren't utf-8 hex encoded, see binaryPrefix.
+func (sqlpp *DB) dumpValue(v interface{}, binary bool) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		if binary || !utf8.Valid(v) {
+			return binaryPrefix + hex.EncodeToString(v)
+		}
+
+		return dumpString(string(v))
+	case string:
+		return dumpString(v)
+	case time.Time:
+		return sqlpp.timestamp(v)
+	}
+
+	return v
+}
+
+func dumpString(s string) string {
+	if strings.HasPrefix(s, `\`) {
+		return `\` + s
+	}
+
+	return s
+}
+
+// loadString returns the value of a dumped string.
+func loadString(s string) (interface{}, error) {
+	switch {
+	case s == csvNull:
+		return nil, nil
+	case strings.HasPrefix(s, binaryPrefix):
+		return hex.DecodeString(s[len(binaryPrefix):])
+	case strings.HasPrefix(s, `\`):
+		return s[1:], nil
+	}
+
+	return s, nil
+}
+
+func isBinaryType(name string) bool {
+	switch strings.ToUpper(name) {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BIT", "GEOMETRY":
+		return true
+	}
+
+	return false
+}
+
+// jsonRowWriter returns a func writing a row, or the array brackets on
+// the first and last nil row.
+func (sqlpp *DB) jsonRowWriter(w *bufio.Writer, columns []string) func([]interface{}) error {
+	started, n := false, 0
+	row := make(map[string]interface{}, len(columns))
+	return func(values []interface{}) error {
+		if values == nil {
+			if !started {
+				started = true
+				_, err := w.WriteString("[")
+				return err
+			}
+
+			_, err := w.WriteString("\n]\n")
+			return err
+		}
+
+		if n > 0 {
+			w.WriteByte(',')
+		}
+
+		n++
+		w.WriteString("\n  ")
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+		return err
+	}
+}
+
+// csvRowWriter returns a func writing a row, or the header on the first
+// nil row.
+func (sqlpp *DB) csvRowWriter(w *bufio.Writer, columns []string) func([]interface{}) error {
+	cw := csv.NewWriter(w)
+	header := false
+	record := make([]string, len(columns))
+	return func(values []interface{}) error {
+		if values == nil {
+			if !header {
+				header = true
+				return cw.Write(columns)
+			}
+
+			cw.Flush()
+			return cw.Error()
+		}
+
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				record[i] = csvNull
+			case string:
+				record[i] = v
+			case int64:
+				record[i] = strconv.FormatInt(v, 10)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			case bool:
+				record[i] = strconv.FormatBool(v)
+			default:
+				b, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+
+				record[i] = string(b)
+			}
+		}
+
+		return cw.Write(record)
+	}
+}
+
+// LoadTable inserts the rows of a DumpTable dump read from r into table in
+// a single transaction, telling json from csv by the first byte.
+func (sqlpp *DB) LoadTable(ctx context.Context, table string, r io.Reader) error {
+	if err := sqlpp.helperSQL(); err != nil {
+		return err
+	} else if err := sqlpp.helperFeature(sqlpp.dialect.identityOverride); err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	next, err := jsonRowReader(br)
+	if err == errNotJSON {
+		next, err = csvRowReader(br)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return sqlpp.WithTx(ctx, nil, func(tx *Tx) error {
+		return sqlpp.insertRows(ctx, tx, table, next)
+	})
+}
+
+var errNotJSON = errors.New("not json")
+
+func jsonRowReader(br *bufio.Reader) (func() (map[string]interface{}, error), error) {
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return func() (map[string]interface{}, error) { return nil, io.EOF }, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+
+		br.UnreadByte()
+		if c != '[' {
+			return nil, errNotJSON
+		}
+
+		break
+	}
+
+	d := json.NewDecoder(br)
+	d.UseNumber()
+	if _, err := d.Token(); err != nil {
+		return nil, err
+	}
+
+	return func() (map[string]interface{}, error) {
+		if !d.More() {
+			return nil, io.EOF
+		}
+
+		var row map[string]interface{}
+		if err := d.Decode(&row); err != nil {
+			return nil, err
+		}
+
+		for column, v := range row {
+			if s, ok := v.(string); ok {
+				v, err := loadString(s)
+				if err != nil {
+					return nil, err
+				}
+
+				row[column] = v
+			}
+		}
+
+		return row, nil
+	}, nil
+}
+
+func csvRowReader(br *bufio.Reader) (func() (map[string]interface{}, error), error) {
+	cr := csv.NewReader(br)
+	columns, err := cr.Read()
+	if err == io.EOF {
+		return func() (map[string]interface{}, error) { return nil, io.EOF }, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return func() (map[string]interface{}, error) {
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if row[column], err = loadString(record[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		return row, nil
+	}, nil
+}
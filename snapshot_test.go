@@ -0,0 +1,143 @@
+package sqlpp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_DumpTable(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	created := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
+			AddRow(int64(1), []byte("foo, \"bar\""), nil, created).
+			AddRow(int64(2), []byte("baz"), []byte("baz@example.com"), created)
+	}
+
+	users := mMock.ExpectPrepare("SELECT * FROM `users`")
+	users.ExpectQuery().WillReturnRows(rows())
+	users.ExpectQuery().WillReturnRows(rows())
+	mMock.ExpectPrepare("SELECT * FROM `posts`").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mMock.ExpectPrepare("SELECT * FROM `koo`").ExpectQuery().WillReturnError(errors.New("error"))
+	mMock.ExpectPrepare("SELECT * FROM `files`").ExpectQuery().WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("data").OfType("BLOB", nil), sqlmock.NewColumn("name")).
+			AddRow([]byte("ab"), []byte(`\N`)).
+			AddRow([]byte{0xff, 0}, `\x00`))
+	pMock.ExpectPrepare(`SELECT * FROM "users"`).ExpectQuery().WillReturnRows(rows())
+
+	var b bytes.Buffer
+	ctx := context.Background()
+	assert.Nil(t, sm.DumpTable(ctx, "users", &b, DumpJSON))
+	assert.Equal(t, `[
+  {"created_at":"2021-02-03 04:05:06","email":null,"id":1,"name":"foo, \"bar\""},
+  {"created_at":"2021-02-03 04:05:06","email":"baz@example.com","id":2,"name":"baz"}
+]
+`, b.String())
+
+	b.Reset()
+	assert.Nil(t, sm.DumpTable(ctx, "users", &b, DumpCSV))
+	assert.Equal(t, `id,name,email,created_at
+1,"foo, ""bar""",\N,2021-02-03 04:05:06
+2,baz,baz@example.com,2021-02-03 04:05:06
+`, b.String())
+
+	b.Reset()
+	assert.Nil(t, sm.DumpTable(ctx, "posts", &b, DumpJSON))
+	assert.Equal(t, "[\n]\n", b.String())
+
+	b.Reset()
+	assert.Nil(t, sp.DumpTable(ctx, "users", &b, DumpCSV))
+	assert.Contains(t, b.String(), "2021-02-03 04:05:06Z")
+
+	assert.Equal(t, errors.New("error"), sm.DumpTable(ctx, "koo", &b, DumpJSON))
+	assert.Equal(t, ErrUnknownFormat, sm.DumpTable(ctx, "users", &b, DumpFormat(5)))
+
+	// binary values and strings starting with a backslash are marked
+	b.Reset()
+	assert.Nil(t, sm.DumpTable(ctx, "files", &b, DumpCSV))
+	assert.Equal(t, `data,name
+\x6162,\\N
+\xff00,\\x00
+`, b.String())
+
+	assert.True(t, errors.Is(sm.Clone(WithPolicy(TablePrefix("app_"))).DumpTable(ctx, "users", &b, DumpCSV), ErrRestricted))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
+
+func TestDB_LoadTable(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	mMock.ExpectBegin()
+	insert := mMock.ExpectPrepare("INSERT INTO `users` (`email`,`id`,`name`) VALUES (?,?,?)")
+	insert.ExpectExec().WithArgs(nil, "1", "foo").WillReturnResult(sqlmock.NewResult(1, 1))
+	insert.ExpectExec().WithArgs("b@example.com", "2", "bar").WillReturnResult(sqlmock.NewResult(2, 1))
+	mMock.ExpectCommit()
+	mMock.ExpectBegin()
+	insert = mMock.ExpectPrepare("INSERT INTO `users` (`email`,`id`,`name`) VALUES (?,?,?)")
+	insert.ExpectExec().WithArgs(nil, "1", "foo, \"bar\"").WillReturnResult(sqlmock.NewResult(1, 1))
+	insert.ExpectExec().WithArgs("", "2", "baz").WillReturnError(errors.New("error"))
+	mMock.ExpectRollback()
+
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(`INSERT INTO "users" ("id","name") OVERRIDING SYSTEM VALUE VALUES ($1,$2)`).
+		ExpectExec().WithArgs("1", "foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectPrepare(`SELECT setval(pg_get_serial_sequence($1, 'id'), MAX(id)) FROM "users"`).
+		ExpectExec().WithArgs("users").WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectCommit()
+	pMock.ExpectBegin()
+	pMock.ExpectCommit()
+	pMock.ExpectBegin()
+	pMock.ExpectRollback()
+	pMock.ExpectBegin()
+	pMock.ExpectPrepare(`INSERT INTO "files" ("data","name") OVERRIDING SYSTEM VALUE VALUES ($1,$2)`).
+		ExpectExec().WithArgs([]byte("ab"), `\N`).WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectExec(`INSERT INTO "files" ("data","name") OVERRIDING SYSTEM VALUE VALUES ($1,$2)`).
+		WithArgs([]byte{0xff, 0}, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	pMock.ExpectCommit()
+
+	ctx := context.Background()
+	assert.Nil(t, sm.LoadTable(ctx, "users", strings.NewReader(`
+[
+  {"email":null,"id":1,"name":"foo"},
+  {"email":"b@example.com","id":2,"name":"bar"}
+]`)))
+	assert.Equal(t, errors.New("error"), sm.LoadTable(ctx, "users", strings.NewReader(`id,name,email
+1,"foo, ""bar""",\N
+2,baz,
+`)))
+
+	assert.Nil(t, sp.LoadTable(ctx, "users", strings.NewReader("id,name\n1,foo\n")))
+	assert.Nil(t, sp.LoadTable(ctx, "users", strings.NewReader("[]")))
+	assert.Equal(t, io.ErrUnexpectedEOF, sp.LoadTable(ctx, "users", strings.NewReader(`[{"id":`)))
+	assert.Nil(t, sp.LoadTable(ctx, "files", strings.NewReader(`[
+  {"data":"\\x6162","name":"\\\\N"},
+  {"data":"\\xff00","name":null}
+]`)))
+	assert.True(t, errors.Is(NewRedshift(pDb).LoadTable(ctx, "users", strings.NewReader("[]")), ErrNotSupported))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
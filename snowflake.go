@@ -0,0 +1,27 @@
+package sqlpp
+
+import (
+	"reflect"
+	"strings"
+)
+
+var (
+	// 0A000 is the sqlstate of the statements the driver can't prepare,
+	// like some ddl
+	snowflakeErrUnsupported = "(0A000)"
+	// error 000008, a multi statement query prepared as a single one
+	snowflakeErrStatementCount = "did not match the desired statement count"
+)
+
+func isSnowflakePrepareNotSupported(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), snowflakeErrUnsupported) ||
+		strings.Contains(err.Error(), snowflakeErrStatementCount))
+}
+
+// isArrayBind reports whether arg is a pointer to a slice, what the
+// snowflake driver's Array returns to bind a slice as an array, e.g. for
+// a batch insert.
+func isArrayBind(arg interface{}) bool {
+	rv := reflect.ValueOf(arg)
+	return rv.Kind() == reflect.Ptr && rv.Type().Elem().Kind() == reflect.Slice
+}
@@ -0,0 +1,73 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isSnowflakePrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("000002 (0A000): Unsupported feature 'TODO: ALTER SESSION'."),
+			true,
+		},
+		{
+			errors.New("000008 (0A000): Actual statement count 2 did not match the desired statement count 1."),
+			true,
+		},
+		{
+			errors.New("002003 (42S02): SQL compilation error: Object 'FOO' does not exist or not authorized."),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isSnowflakePrepareNotSupported(c.err))
+		})
+	}
+}
+
+func TestNewSnowflakeDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewSnowflakeDB(db)
+	ids := []int{1, 2}
+	query, args := s.Transform("select * from foo where a in (?) and b = ?", ids, 3)
+	assert.Equal(t, "select * from foo where a in (?,?) and b = ?", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	// the array binds of the driver fill a (?) as one value
+	names := []string{"a", "b"}
+	query, args = s.Transform("insert into foo (id, name) values (?, ?)", &ids, &names)
+	assert.Equal(t, "insert into foo (id, name) values (?, ?)", query)
+	assert.Equal(t, []interface{}{&ids, &names}, args)
+	query, args = s.Transform("insert into foo (id) values (?)", &ids)
+	assert.Equal(t, "insert into foo (id) values (?)", query)
+	assert.Equal(t, []interface{}{&ids}, args)
+
+	mock.ExpectPrepare("alter session set timezone = 'UTC'").
+		WillReturnError(errors.New("000002 (0A000): Unsupported feature 'TODO: ALTER SESSION'."))
+	mock.ExpectExec("alter session set timezone = 'UTC'").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("alter session set timezone = 'UTC'")
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
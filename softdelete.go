@@ -0,0 +1,109 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+// WithSoftDelete makes the struct helpers Delete, Find and UpdateVersioned
+// treat rows with a non null column as deleted. Delete sets the column to
+// the current timestamp instead of deleting the row, and the others skip
+// deleted rows unless run with a WithDeleted context.
+func WithSoftDelete(column string) Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.softDelete = column
+	}
+}
+
+// WithDeleted makes the struct helpers run with ctx include soft deleted
+// rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey, true)
+}
+
+// notDeleted returns the condition skipping soft deleted rows, or "" if
+// there are none or ctx includes them.
+func (sqlpp *DB) notDeleted(ctx context.Context) string {
+	column := sqlpp.config().softDelete
+	if deleted, _ := ctx.Value(deletedKey).(bool); column == "" || deleted {
+		return ""
+	}
+
+	return sqlpp.QuoteIdent(column) + " IS NULL"
+}
+
+// Find selects the ColumnsOf T from table where the where condition, if
+// not empty, holds for args, skipping soft deleted rows. It reads the rows
+// of an earlier time with AsOf.
+func Find[T any](db *DB, table, where string, args ...interface{}) ([]T, error) {
+	return FindContext[T](context.Background(), db, table, where, args...)
+}
+func FindContext[T any](ctx context.Context, db *DB, table, where string, args ...interface{}) ([]T, error) {
+	var conditions []string
+	if where != "" {
+		conditions = append(conditions, "("+where+")")
+	}
+
+	if notDeleted := db.notDeleted(ctx); notDeleted != "" {
+		conditions = append(conditions, notDeleted)
+	}
+
+	from, fromArgs := db.source(ctx, table)
+	query := "SELECT " + Columns[T](db) + " FROM " + from
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(fromArgs, args...)
+
+	return SelectContext(ctx, db, func(rows *sql.Rows) (T, error) {
+		var v T
+		err := rows.Scan(db.FieldsOf(table, &v)...)
+		return v, err
+	}, query, args...)
+}
+
+// Delete deletes the row of the struct v, or v points to, found by its
+// primary key like UpdateVersioned. With WithSoftDelete it marks the row
+// deleted instead, leaving already deleted rows as they are.
+func (sqlpp *DB) Delete(table string, v interface{}) (sql.Result, error) {
+	return sqlpp.DeleteContext(context.Background(), table, v)
+}
+func (sqlpp *DB) DeleteContext(ctx context.Context, table string, v interface{}) (sql.Result, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	where, args := sqlpp.keyOf(rv)
+	if len(where) == 0 {
+		return nil, ErrNoKey
+	}
+
+	table = sqlpp.QuoteIdent(table)
+	column := sqlpp.config().softDelete
+	if column == "" {
+		return sqlpp.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+strings.Join(where, " AND "), args...)
+	}
+
+	column = sqlpp.QuoteIdent(column)
+	where = append(where, column+" IS NULL")
+	return sqlpp.ExecContext(ctx, "UPDATE "+table+" SET "+column+" = CURRENT_TIMESTAMP WHERE "+
+		strings.Join(where, " AND "), args...)
+}
+
+// keyOf returns the conditions finding the row of the struct rv by its
+// primary key, and their args.
+func (sqlpp *DB) keyOf(rv reflect.Value) ([]string, []interface{}) {
+	fields := fieldsOf(rv.Type())
+	pk := hasPK(fields)
+
+	var where []string
+	var args []interface{}
+	for _, f := range fields {
+		if f.isKey(pk) {
+			where = append(where, sqlpp.QuoteIdent(f.column)+" = ?")
+			args = append(args, f.arg(rv))
+		}
+	}
+
+	return where, args
+}
@@ -0,0 +1,56 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type softDoc struct {
+	ID    int64  `db:"id"`
+	Title string `db:"title"`
+}
+
+func TestDB_SoftDelete(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db, WithSoftDelete("deleted_at"))
+	ctx := context.Background()
+
+	mock.ExpectPrepare("DELETE FROM `docs` WHERE `id` = ?").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`UPDATE "docs" SET "deleted_at" = CURRENT_TIMESTAMP WHERE "id" = $1 AND "deleted_at" IS NULL`).
+		ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`SELECT "id", "title" FROM "docs" WHERE ("title" = $1) AND "deleted_at" IS NULL`).
+		ExpectQuery().WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(3, "a"))
+	mock.ExpectPrepare(`SELECT "id", "title" FROM "docs"`).
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(2, "b").AddRow(3, "a"))
+	mock.ExpectPrepare("SELECT `id`, `title` FROM `docs`").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
+
+	_, err = m.DeleteContext(ctx, "docs", softDoc{ID: 1})
+	assert.Nil(t, err)
+	_, err = p.DeleteContext(ctx, "docs", &softDoc{ID: 2})
+	assert.Nil(t, err)
+
+	docs, err := FindContext[softDoc](ctx, p, "docs", `"title" = ?`, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []softDoc{{3, "a"}}, docs)
+
+	docs, err = FindContext[softDoc](WithDeleted(ctx), p, "docs", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []softDoc{{2, "b"}, {3, "a"}}, docs)
+
+	docs, err = Find[softDoc](m, "docs", "")
+	assert.Nil(t, err)
+	assert.Empty(t, docs)
+
+	_, err = m.Delete("docs", struct{ Title string }{})
+	assert.Equal(t, ErrNoKey, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,16 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// the grpc status of the statements spanner can't run in the prepared
+	// protocol, as the driver formats it, e.g. spanner: code =
+	// "Unimplemented", desc = ...
+	spannerErrUnimplemented = `code = "Unimplemented"`
+)
+
+func isSpannerPrepareNotSupported(err error) bool {
+	return err != nil && strings.Contains(err.Error(), spannerErrUnimplemented)
+}
@@ -0,0 +1,69 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isSpannerPrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New(`spanner: code = "Unimplemented", desc = "Unsupported statement"`),
+			true,
+		},
+		{
+			errors.New("Error 1295: This command is not supported in the prepared statement protocol yet"),
+			false,
+		},
+		{
+			errors.New(`spanner: code = "InvalidArgument", desc = "Syntax error"`),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isSpannerPrepareNotSupported(c.err))
+		})
+	}
+}
+
+func TestNewSpanner(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewSpanner(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = @param1 and b in (@param2,@param3)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	// runs the statements the driver can't prepare directly
+	mock.ExpectPrepare("start batch ddl").
+		WillReturnError(errors.New(`spanner: code = "Unimplemented", desc = "Unsupported statement"`))
+	mock.ExpectExec("start batch ddl").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("start batch ddl")
+	assert.Nil(t, err)
+
+	mock.ExpectPrepare("update foo set a = @param1").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
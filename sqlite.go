@@ -0,0 +1,23 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	sqliteErrSchemaChanged = "database schema has changed"
+	sqliteErrBusy          = "database is locked"
+	sqliteErrTooManyParams = "too many SQL variables"
+)
+
+// isSQLiteSchemaChanged matches SQLITE_SCHEMA, returned by a stmt prepared
+// before a schema change.
+func isSQLiteSchemaChanged(err error) bool {
+	return err != nil && strings.Contains(err.Error(), sqliteErrSchemaChanged)
+}
+
+// isSQLiteBusy matches SQLITE_BUSY, returned when another connection holds
+// the write lock for longer than the busy timeout.
+func isSQLiteBusy(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), sqliteErrBusy) || strings.Contains(err.Error(), "SQLITE_BUSY"))
+}
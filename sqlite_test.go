@@ -0,0 +1,78 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isSQLiteBusy(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("database is locked"),
+			true,
+		},
+		{
+			errors.New("database is locked (5) (SQLITE_BUSY)"),
+			true,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isSQLiteBusy(c.err))
+		})
+	}
+}
+
+func TestNewSQLite(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewSQLite(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = ? and b in (?,?)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+	assert.Equal(t, `"foo"."bar"`, s.QuoteIdent("foo.bar"))
+
+	literal, err := s.interpolation("select ?", []interface{}{`a\'b`})
+	assert.Nil(t, err)
+	assert.Equal(t, `select 'a\''b'`, literal)
+
+	// re-prepares a stmt invalidated by a schema change
+	mock.ExpectPrepare("update foo set a = ?").WillBeClosed().
+		ExpectExec().WithArgs(1).WillReturnError(errors.New("database schema has changed"))
+	mock.ExpectPrepare("update foo set a = ?").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	mock.ExpectExec("update foo set a = ?").WithArgs(2).WillReturnError(errors.New("database is locked"))
+	_, err = s.Exec("update foo set a = ?", 2)
+	assert.True(t, errors.Is(err, ErrBusy))
+
+	// mysql prepare errors aren't cached as unsupported
+	mock.ExpectPrepare("select b from foo").WillReturnError(errPrepareNotSupported)
+	mock.ExpectPrepare("select b from foo").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"b"}).AddRow(1))
+	var b int
+	assert.NotNil(t, s.QueryRow("select b from foo", nil, &b))
+	assert.Nil(t, s.QueryRow("select b from foo", nil, &b))
+	assert.Equal(t, 1, b)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
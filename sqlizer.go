@@ -0,0 +1,48 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Sqlizer is a query builder, squirrel's builders implement it. Built
+// queries run like raw ones, through the transform and stmt cache.
+type Sqlizer interface {
+	ToSql() (string, []interface{}, error)
+}
+
+func (sqlpp *DB) ExecSqlizer(s Sqlizer) (sql.Result, error) {
+	return sqlpp.ExecSqlizerContext(context.Background(), s)
+}
+func (sqlpp *DB) ExecSqlizerContext(ctx context.Context, s Sqlizer) (sql.Result, error) {
+	query, args, err := s.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlpp.ExecContext(ctx, query, args...)
+}
+
+func (sqlpp *DB) QueryRowSqlizer(s Sqlizer, dest ...interface{}) error {
+	return sqlpp.QueryRowSqlizerContext(context.Background(), s, dest...)
+}
+func (sqlpp *DB) QueryRowSqlizerContext(ctx context.Context, s Sqlizer, dest ...interface{}) error {
+	query, args, err := s.ToSql()
+	if err != nil {
+		return err
+	}
+
+	return sqlpp.QueryRowContext(ctx, query, args, dest...)
+}
+
+func (sqlpp *DB) QuerySqlizer(s Sqlizer, scan Scanner) ([]interface{}, error) {
+	return sqlpp.QuerySqlizerContext(context.Background(), s, scan)
+}
+func (sqlpp *DB) QuerySqlizerContext(ctx context.Context, s Sqlizer, scan Scanner) ([]interface{}, error) {
+	query, args, err := s.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlpp.QueryContext(ctx, query, args, scan)
+}
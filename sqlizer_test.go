@@ -0,0 +1,59 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlizer struct {
+	query string
+	args  []interface{}
+	err   error
+}
+
+func (s sqlizer) ToSql() (string, []interface{}, error) {
+	return s.query, s.args, s.err
+}
+
+func TestDB_Sqlizer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	mock.ExpectPrepare(`^update foo set j = \$1 where i in \(\$2,\$3\)$`).
+		ExpectExec().WithArgs("j", 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectPrepare(`^select i from foo where i = \$1$`).
+		ExpectQuery().WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mock.ExpectPrepare(`^select i from foo where i in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+
+	_, err = s.ExecSqlizer(sqlizer{"update foo set j = ? where i in (?)", []interface{}{"j", []int{1, 2}}, nil})
+	assert.Nil(t, err)
+
+	var i int
+	assert.Nil(t, s.QueryRowSqlizer(sqlizer{"select i from foo where i = ?", []interface{}{1}, nil}, &i))
+	assert.Equal(t, 1, i)
+
+	results, err := s.QuerySqlizer(sqlizer{"select i from foo where i in (?)", []interface{}{[]int{1, 2}}, nil}, func(rows *sql.Rows) (interface{}, error) {
+		var i int
+		return i, rows.Scan(&i)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, results)
+
+	errBuild := errors.New("build")
+	_, err = s.ExecSqlizer(sqlizer{err: errBuild})
+	assert.Equal(t, errBuild, err)
+	assert.Equal(t, errBuild, s.QueryRowSqlizer(sqlizer{err: errBuild}, &i))
+	_, err = s.QuerySqlizer(sqlizer{err: errBuild}, nil)
+	assert.Equal(t, errBuild, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,133 +1,723 @@
 package sqlpp
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
 	ErrNilRows    = errors.New("sqlpp: nil rows")
 	ErrNilScanner = errors.New("sqlpp: nil scanner")
+
+	ErrStrictPrepare = errors.New("sqlpp: interpolation not allowed in strict prepare mode")
 )
 
-func NewPostgreSQL(db *sql.DB) *DB {
-	return new(db, true)
+func NewPostgreSQL(db *sql.DB, opts ...Option) *DB {
+	return new(db, postgresDialect, opts)
+}
+
+func NewMySQL(db *sql.DB, opts ...Option) *DB {
+	return new(db, mysqlDialect, opts)
+}
+
+// NewTiDB wraps a tidb db as NewMySQL does, also running directly the
+// queries tidb can't prepare and retrying its write conflicts 3 times by
+// default, see WithRetry.
+func NewTiDB(db *sql.DB, opts ...Option) *DB {
+	return new(db, tidbDialect, opts)
+}
+
+// NewSQLite wraps a sqlite db. The helpers building database specific
+// queries, like the locks and queues, support mysql and postgres only and
+// fail with ErrNotSupported on it.
+func NewSQLite(db *sql.DB, opts ...Option) *DB {
+	return new(db, sqliteDialect, opts)
+}
+
+// NewSQLServer wraps a sql server db, numbering placeholders as @p1, @p2
+// the way mssql drivers bind them.
+func NewSQLServer(db *sql.DB, opts ...Option) *DB {
+	return new(db, sqlserverDialect, opts)
+}
+
+// NewOracle wraps an oracle db, numbering placeholders as :1, :2 and
+// splitting the IN lists of slices over 1000 elements.
+func NewOracle(db *sql.DB, opts ...Option) *DB {
+	return new(db, oracleDialect, opts)
+}
+
+// NewCockroachDB wraps a cockroach db, numbering placeholders as
+// postgres does and retrying statements on its serialization errors, see
+// WithRetry. The locks, fixtures and consistency tokens fail with
+// ErrNotSupported.
+func NewCockroachDB(db *sql.DB, opts ...Option) *DB {
+	return new(db, cockroachDialect, opts)
+}
+
+// NewBigQuery wraps a bigquery db, numbering placeholders as @p1, @p2 and
+// passing args as the sql.Named values its driver binds. See WithUnnest
+// for binding slices as arrays.
+func NewBigQuery(db *sql.DB, opts ...Option) *DB {
+	return new(db, bigqueryDialect, opts)
+}
+
+// NewSpanner wraps a cloud spanner db, numbering placeholders as @param1,
+// @param2. The statements its driver can't prepare run directly.
+func NewSpanner(db *sql.DB, opts ...Option) *DB {
+	return new(db, spannerDialect, opts)
+}
+
+// NewRedshift wraps a redshift db, numbering placeholders as postgres
+// does and splitting the IN lists of slices over 1000 elements. Queries
+// run directly instead of through cached stmts, see WithDirect. The
+// helpers building postgres sql fail with ErrNotSupported.
+func NewRedshift(db *sql.DB, opts ...Option) *DB {
+	return new(db, redshiftDialect, opts)
+}
+
+// NewDuckDB wraps a duckdb db. Slice args expand as on mysql, and the
+// statements its driver can't prepare run directly.
+func NewDuckDB(db *sql.DB, opts ...Option) *DB {
+	return new(db, duckdbDialect, opts)
+}
+
+// NewTrino wraps a trino or presto db. Its drivers can't prepare, so
+// queries always run directly, with slice args expanded as on mysql.
+func NewTrino(db *sql.DB, opts ...Option) *DB {
+	return new(db, trinoDialect, opts)
+}
+
+// NewVertica wraps a vertica db. Slice args expand as on mysql, and the
+// statements vertica can't prepare run directly.
+func NewVertica(db *sql.DB, opts ...Option) *DB {
+	return new(db, verticaDialect, opts)
 }
 
-func NewMySQL(db *sql.DB) *DB {
-	return new(db, false)
+// NewHANA wraps a sap hana db. Slice args expand as on mysql, a query
+// they expand over the 32767 placeholders of hana fails with a
+// TooManyParamsError instead of being sent.
+func NewHANA(db *sql.DB, opts ...Option) *DB {
+	return new(db, hanaDialect, opts)
 }
 
-func new(db *sql.DB, postgres bool) *DB {
-	return &DB{
-		DB:       db,
-		postgres: postgres,
+// NewSnowflakeDB wraps a snowflake db. Slice args expand as on mysql, the
+// array binds of the driver's Array fill their (?) as one value.
+func NewSnowflakeDB(db *sql.DB, opts ...Option) *DB {
+	return new(db, snowflakeDialect, opts)
+}
+
+func new(db *sql.DB, d *dialect, opts []Option) *DB {
+	sqlpp := &DB{
+		DB:      db,
+		dialect: d,
+
+		cfg: &config{
+			parallelism:  runtime.GOMAXPROCS(0),
+			retries:      d.retries,
+			direct:       d.direct,
+			retryBackoff: defaultRetryBackoff,
+		},
+		asyncSem: make(chan struct{}, runtime.GOMAXPROCS(0)),
+
+		queries: NewLRUCache(maxTransforms),
+		stmts:   sync.Map{},
+		stats:   &cacheStats{},
+	}
 
-		stmts: sync.Map{},
+	for _, opt := range opts {
+		opt(sqlpp)
 	}
+
+	sqlpp.current.Store(sqlpp.cfg)
+	return sqlpp
 }
 
 type DB struct {
 	*sql.DB
 
-	postgres bool
+	dialect *dialect
+
+	// settings adjustable by SetOption, cfg is the one options write to
+	configMu sync.Mutex
+	current  atomic.Value
+	cfg      *config
+
+	// ExecAsync worker pool
+	asyncSem chan struct{}
+	asyncWg  sync.WaitGroup
+	asyncMu  sync.Mutex
+	closed   bool
+
+	// in-flight queries, cancelled by Shutdown
+	inflightMu   sync.Mutex
+	inflight     map[uint64]context.CancelFunc
+	inflightID   uint64
+	inflightWg   sync.WaitGroup
+	shuttingDown bool
+
+	// transformed query cache, keyed by query and slice arg lengths
+	queries *LRUCache
 
 	// stmt cache
 	stmts sync.Map
+	stats *cacheStats
+
+	// Coalesce queries running, by cache key
+	flights sync.Map
+
+	// StartStatsReporter goroutines, stopped by Close
+	reportersMu sync.Mutex
+	reporters   []*statsReporter
+
+	// whether DB is shared with the parent
+	clone bool
+}
+
+// Transform returns query and args as sqlpp sends them to the db, with
+// the (?) of slice args expanded and placeholders numbered on postgres.
+func (sqlpp *DB) Transform(query string, args ...interface{}) (string, []interface{}) {
+	return sqlpp.transform(query, args)
 }
 
 func (sqlpp *DB) transform(query string, args []interface{}) (string, []interface{}) {
-	if i := strings.LastIndex(query, "(?)"); i != -1 {
-		indices := []int{}
-		tempQuery := query
-		for ; i != -1; i = strings.LastIndex(tempQuery, "(?)") {
-			indices = append(indices, i)
-			tempQuery = tempQuery[:i]
-		}
-
-		lenIndices := len(indices)
-		tempArgs := []interface{}{}
-		for _, arg := range args {
-			switch reflect.TypeOf(arg).Kind() {
-			case reflect.Array, reflect.Slice:
-				v := reflect.ValueOf(arg)
-				l := v.Len()
-				if l == 0 {
-					tempQuery += "(?)"
-				} else {
-					tempQuery += "(" + strings.Repeat("?,", l)[:l*2-1] + ")"
-				}
-
-				if lenIndices--; lenIndices > 0 {
-					tempQuery += query[indices[lenIndices]+3 : indices[lenIndices-1]]
-				} else {
-					tempQuery += query[indices[0]+3:]
-				}
-
-				for i := 0; i < l; i++ {
-					tempArgs = append(tempArgs, v.Index(i).Interface())
-				}
-
-			default:
-				tempArgs = append(tempArgs, arg)
-			}
+	tempArgs := make([]interface{}, 0, len(args))
+	return sqlpp.transformTo(&tempArgs, query, args)
+}
+
+// maxTransforms bounds the transformed query cache, as every length of a
+// slice arg transforms its query anew.
+const maxTransforms = 4096
+
+// transformTo flattens the slice args into dst if the query has any (?),
+// encoding the args of registered types.
+func (sqlpp *DB) transformTo(dst *[]interface{}, query string, args []interface{}) (string, []interface{}) {
+	args = encodeArgs(args)
+	i := strings.Index(query, "(?)")
+	if i == -1 && sqlpp.dialect.numbered == "" {
+		return query, args
+	}
+
+	// the transformed query only depends on the lengths of the slice args
+	var lengths []int
+	if i != -1 {
+		cfg := sqlpp.config()
+		args, lengths = flatten(dst, args, cfg.flattenNested, sqlpp.dialect.arrayBinds, cfg.unnest)
+	}
+
+	if sqlpp.dialect.namedArgs {
+		args = namedArgs(sqlpp.dialect.numbered, args)
+	}
+
+	key := transformKey(query, lengths)
+	if cached, ok := sqlpp.queries.Get(key); ok {
+		return cached[0].(string), args
+	}
+
+	transformed := sqlpp.build(query, i, lengths)
+	sqlpp.queries.Set(key, []interface{}{transformed}, 0, nil)
+	return transformed, args
+}
+
+// flatten expands the slice args into dst, returning their lengths. The
+// array binds of arrayBinds fill their (?) as one value, and the slices of
+// unnest fill it as one array with a length of -1.
+func flatten(dst *[]interface{}, args []interface{}, nested, arrayBinds, unnest bool) ([]interface{}, []int) {
+	lengths := []int{}
+	tempArgs := (*dst)[:0]
+	for _, arg := range args {
+		if arrayBinds && isArrayBind(arg) {
+			tempArgs = append(tempArgs, arg)
+			lengths = append(lengths, 1)
+			continue
+		} else if !expands(arg) {
+			tempArgs = append(tempArgs, arg)
+			continue
+		} else if unnest {
+			tempArgs = append(tempArgs, arg)
+			lengths = append(lengths, -1)
+			continue
 		}
 
-		query = tempQuery
-		args = tempArgs
+		l := len(tempArgs)
+		tempArgs = appendElems(tempArgs, reflect.ValueOf(arg), nested)
+		lengths = append(lengths, len(tempArgs)-l)
 	}
 
-	if sqlpp.postgres {
-		count := strings.Count(query, "?")
-		for i := 1; i <= count; i++ {
-			query = strings.Replace(query, "?", "$"+strconv.Itoa(i), 1)
+	*dst = tempArgs
+	return tempArgs, lengths
+}
+
+// appendElems appends the encoded elements of the slice v, and those of
+// its nested slices if nested.
+func appendElems(dst []interface{}, v reflect.Value, nested bool) []interface{} {
+	for i := 0; i < v.Len(); i++ {
+		elem := encoded(v.Index(i).Interface())
+		if nested && expands(elem) {
+			dst = appendElems(dst, reflect.ValueOf(elem), nested)
+			continue
 		}
+
+		dst = append(dst, elem)
+	}
+
+	return dst
+}
+
+// expands reports whether arg is a slice or array filling a (?). []byte,
+// Valuers like driver array types and sql.Out are values of their own.
+func expands(arg interface{}) bool {
+	switch arg.(type) {
+	case []byte, driver.Valuer, sql.Out, sql.NamedArg:
+		return false
 	}
 
-	return query, args
+	k := reflect.ValueOf(arg).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
+
+func transformKey(query string, lengths []int) string {
+	if lengths == nil {
+		return query
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+
+	key := append(*b, query...)
+	key = append(key, 0)
+	for _, l := range lengths {
+		key = strconv.AppendInt(key, int64(l), 10)
+		key = append(key, ',')
+	}
+
+	*b = key
+	return string(key)
+}
+
+// build expands the (?) at i and the ones after it by lengths. every
+// length expands the next (?) and copies the query up to the one after it.
+func (sqlpp *DB) build(query string, i int, lengths []int) string {
+	t := getTransformer(sqlpp.dialect.numbered)
+	defer putTransformer(t)
+
+	t.Grow(len(query) + len(query)/4)
+	if i == -1 {
+		t.write(query)
+		return t.String()
+	}
+
+	t.write(query[:i])
+	rest := query[i+3:]
+	for _, l := range lengths {
+		if l == -1 {
+			t.unnest()
+		} else if max := sqlpp.dialect.maxInList; max > 0 && l > max {
+			t.expandIn(l, max)
+		} else {
+			t.expand(l)
+		}
+
+		if j := strings.Index(rest, "(?)"); j == -1 {
+			t.write(rest)
+		} else {
+			t.write(rest[:j])
+			rest = rest[j+3:]
+		}
+	}
+
+	return t.String()
+}
+
+type transformer struct {
+	bytes.Buffer
+
+	numbered string
+	n        int64
+	buf      [20]byte
+}
+
+// write copies s, numbering its placeholders for postgres.
+func (t *transformer) write(s string) {
+	if t.numbered == "" {
+		t.WriteString(s)
+		return
+	}
+
+	for i := strings.IndexByte(s, '?'); i != -1; i = strings.IndexByte(s, '?') {
+		t.WriteString(s[:i])
+		t.placeholder()
+		s = s[i+1:]
+	}
+
+	t.WriteString(s)
+}
+
+func (t *transformer) expand(l int) {
+	if l == 0 {
+		l = 1
+	}
+
+	t.WriteByte('(')
+	for i := 0; i < l; i++ {
+		if i > 0 {
+			t.WriteByte(',')
+		}
+
+		t.placeholder()
+	}
+
+	t.WriteByte(')')
+}
+
+// unnest fills a (?) with one array placeholder, as UNNEST(@p1).
+func (t *transformer) unnest() {
+	t.WriteString("UNNEST(")
+	t.placeholder()
+	t.WriteByte(')')
+}
+
+// expandIn expands a slice longer than max to IN lists of max elements,
+// ORed in parentheses or ANDed for NOT IN, e.g. (a IN (..) OR a IN (..)).
+// A (?) without an IN before it expands to one list.
+func (t *transformer) expandIn(l, max int) {
+	start, end, not := inOperand(t.Bytes())
+	if start == -1 {
+		t.expand(l)
+		return
+	}
+
+	operand, in := string(t.Bytes()[start:end]), string(t.Bytes()[end:])
+	join := " OR "
+	if not {
+		join = " AND "
+	}
+
+	t.Truncate(start)
+	t.WriteByte('(')
+	for l > 0 {
+		n := max
+		if l < n {
+			n = l
+		}
+
+		t.WriteString(operand)
+		t.WriteString(in)
+		t.expand(n)
+		if l -= n; l > 0 {
+			t.WriteString(join)
+		}
+	}
+
+	t.WriteByte(')')
+}
+
+func (t *transformer) placeholder() {
+	if t.numbered == "" {
+		t.WriteByte('?')
+		return
+	}
+
+	t.n++
+	t.WriteString(t.numbered)
+	t.Write(strconv.AppendInt(t.buf[:0], t.n, 10))
 }
 
 func (sqlpp *DB) prepare(ctx context.Context, query string, args []interface{}) (*sql.Stmt, string, []interface{}, error) {
 	query, args = sqlpp.transform(query, args)
+	stmt, _, err := sqlpp.stmt(ctx, query)
+	return stmt, query, args, err
+}
 
+func (sqlpp *DB) stmt(ctx context.Context, query string) (*sql.Stmt, bool, error) {
 	if loaded, ok := sqlpp.stmts.Load(query); ok {
 		if stmt, o := loaded.(*sql.Stmt); o {
-			return stmt, query, args, nil
+			atomic.AddInt64(&sqlpp.stats.hits, 1)
+			return stmt, false, nil
 		} else if err, o := loaded.(error); o {
-			return nil, query, args, err
+			return nil, false, err
 		} else {
 			sqlpp.stmts.Delete(query)
 		}
 	}
 
-	stmt, err := sqlpp.PrepareContext(ctx, query)
+	atomic.AddInt64(&sqlpp.stats.misses, 1)
+	stmt, err := sqlpp.DB.PrepareContext(ctx, query)
 	if err != nil {
-		if isMysqlPrepareNotSupported(err) {
+		if sqlpp.dialect.prepareNotSupported(err) {
 			sqlpp.stmts.Store(query, err)
 		}
 
-		return nil, query, args, err
+		return nil, true, err
+	}
+
+	// another call may have prepared the same query meanwhile
+	if loaded, ok := sqlpp.stmts.LoadOrStore(query, stmt); ok {
+		if cached, o := loaded.(*sql.Stmt); o {
+			stmt.Close()
+			return cached, true, nil
+		}
+
+		sqlpp.stmts.Store(query, stmt)
+	}
+
+	if max := sqlpp.config().maxStmts; atomic.AddInt64(&sqlpp.stats.stmts, 1) > max && max > 0 {
+		sqlpp.evict(query)
+	}
+
+	return stmt, true, nil
+}
+
+// evict closes cached stmts other than keep until the cache fits maxStmts.
+// sync.Map has no order, so the evicted stmts are arbitrary.
+func (sqlpp *DB) evict(keep string) {
+	sqlpp.stmts.Range(func(key, value interface{}) bool {
+		if atomic.LoadInt64(&sqlpp.stats.stmts) <= sqlpp.config().maxStmts {
+			return false
+		}
+
+		if stmt, o := value.(*sql.Stmt); o && key != keep {
+			sqlpp.invalidate(key.(string), stmt)
+			atomic.AddInt64(&sqlpp.stats.evictions, 1)
+		}
+
+		return true
+	})
+}
+
+// invalidate drops stmt from the cache unless it was already replaced.
+func (sqlpp *DB) invalidate(query string, stmt *sql.Stmt) {
+	if loaded, ok := sqlpp.stmts.Load(query); ok && loaded == stmt {
+		sqlpp.stmts.Delete(query)
+		atomic.AddInt64(&sqlpp.stats.stmts, -1)
+	}
+
+	stmt.Close()
+}
+
+// InvalidateStatements drops every cached stmt, e.g. after a migration
+// changed the schema, so queries re-prepare instead of first failing on a
+// stale plan. To flush on a postgres LISTEN/NOTIFY channel:
+//
+//	for range listener.Notify {
+//		db.InvalidateStatements()
+//	}
+func (sqlpp *DB) InvalidateStatements() {
+	sqlpp.stmts.Range(func(key, value interface{}) bool {
+		if stmt, o := value.(*sql.Stmt); o {
+			sqlpp.invalidate(key.(string), stmt)
+		} else {
+			sqlpp.stmts.Delete(key)
+		}
+
+		return true
+	})
+}
+
+// stmts closed by another invalidation or Close surface as this error
+var errStmtClosed = "sql: statement is closed"
+
+func (sqlpp *DB) stmtInvalidated(err error) bool {
+	return sqlpp.dialect.stmtInvalidated(err) || err != nil && err.Error() == errStmtClosed
+}
+
+type runFunc func(stmt *sql.Stmt, query string, args []interface{}) error
+
+// stmtCache returns the stmt of query, reporting whether it prepared it
+// instead of taking a cached one.
+type stmtCache interface {
+	stmt(ctx context.Context, query string) (*sql.Stmt, bool, error)
+	invalidate(query string, stmt *sql.Stmt)
+}
+
+// run calls fn with the cached stmt of the transformed query. stmt is nil
+// when the query has to run directly on the db.
+func (sqlpp *DB) run(ctx context.Context, e *QueryEvent, fn runFunc) error {
+	return sqlpp.retry(ctx, func() error {
+		return sqlpp.runWith(ctx, e, sqlpp, fn)
+	})
+}
+
+// runWith is run taking the stmts from cache.
+func (sqlpp *DB) runWith(ctx context.Context, e *QueryEvent, cache stmtCache, fn runFunc) error {
+	if err := sqlpp.allow(e.Query); err != nil {
+		return err
+	}
+
+	if err := sqlpp.supported(e.Query); err != nil {
+		return err
+	}
+
+	if err := sqlpp.checkSlices(e.Query, e.Args); err != nil {
+		return err
+	}
+
+	if err := sqlpp.checkParams(e.Query, e.Args); err != nil {
+		return err
+	}
+
+	tempArgs := getArgs()
+	defer putArgs(tempArgs)
+
+	query, args := sqlpp.transformTo(tempArgs, e.Query, e.Args)
+	e.Statement = query
+	if err := sqlpp.checkArgs(args); err != nil {
+		return err
+	}
+
+	if sqlpp.interpolates(ctx) {
+		if sqlpp.config().strict {
+			return ErrStrictPrepare
+		}
+
+		query, err := sqlpp.literals(query, args)
+		if err != nil {
+			return err
+		}
+
+		return fn(nil, query, nil)
+	}
+
+	return sqlpp.execute(ctx, e, cache, query, args, fn)
+}
+
+func (sqlpp *DB) maxParams() int {
+	if caps := sqlpp.config().capabilities; caps != nil {
+		return caps.MaxParams
 	}
 
-	sqlpp.stmts.Store(query, stmt)
-	return stmt, query, args, nil
+	return sqlpp.dialect.maxParams
+}
+
+// direct reports whether queries run without a cached stmt.
+func (sqlpp *DB) direct() bool {
+	return sqlpp.dialect.noPrepare || sqlpp.config().direct
+}
+
+// fallback reports whether a query the db can't prepare runs directly.
+func (sqlpp *DB) fallback(err error) bool {
+	return !sqlpp.config().strict && sqlpp.dialect.prepareNotSupported(err)
+}
+
+// execute calls fn with the stmt of the transformed query from cache,
+// counting the prepares in e. A stmt invalidated by a schema change is
+// re-prepared and fn is retried once.
+func (sqlpp *DB) execute(ctx context.Context, e *QueryEvent, cache stmtCache, query string, args []interface{}, fn runFunc) error {
+	if len(args) > sqlpp.maxParams() {
+		return ErrTooManyParams
+	}
+
+	if sqlpp.direct() {
+		return fn(nil, query, args)
+	}
+
+	stmt, prepared, err := cache.stmt(ctx, query)
+	if prepared {
+		e.Prepares++
+	}
+
+	if err != nil {
+		if sqlpp.fallback(err) {
+			return fn(nil, query, args)
+		}
+
+		return err
+	}
+
+	err = fn(stmt, query, args)
+	if !sqlpp.stmtInvalidated(err) {
+		return err
+	}
+
+	cache.invalidate(query, stmt)
+	if stmt, prepared, err = cache.stmt(ctx, query); prepared {
+		e.Prepares++
+	}
+
+	if err != nil {
+		if sqlpp.fallback(err) {
+			return fn(nil, query, args)
+		}
+
+		return err
+	}
+
+	return fn(stmt, query, args)
+}
+
+// execQuerier runs queries without a stmt, *sql.DB or *sql.Conn.
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (sqlpp *DB) execer(ctx context.Context, direct execQuerier, result *sql.Result) runFunc {
+	return func(stmt *sql.Stmt, query string, args []interface{}) (err error) {
+		if stmt == nil {
+			*result, err = direct.ExecContext(ctx, query, args...)
+		} else {
+			*result, err = stmt.ExecContext(ctx, args...)
+		}
+
+		return err
+	}
+}
+
+func (sqlpp *DB) rowScanner(ctx context.Context, direct execQuerier, dest []interface{}) runFunc {
+	return func(stmt *sql.Stmt, query string, args []interface{}) error {
+		if stmt == nil {
+			return direct.QueryRowContext(ctx, query, args...).Scan(dest...)
+		}
+
+		return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+	}
+}
+
+func (sqlpp *DB) querier(ctx context.Context, direct execQuerier, rows **sql.Rows) runFunc {
+	return func(stmt *sql.Stmt, query string, args []interface{}) (err error) {
+		if stmt == nil {
+			*rows, err = direct.QueryContext(ctx, query, args...)
+		} else {
+			*rows, err = stmt.QueryContext(ctx, args...)
+		}
+
+		return err
+	}
 }
 
 type Scanner func(*sql.Rows) (interface{}, error)
 
-func (sqlpp *DB) parse(rows *sql.Rows, scanner Scanner) ([]interface{}, error) {
+// query runs e with run and parses the rows with scan.
+func (sqlpp *DB) query(ctx context.Context, e *QueryEvent, direct execQuerier, scan Scanner, run func(context.Context, *QueryEvent, runFunc) error) ([]interface{}, error) {
+	var rows *sql.Rows
+	if err := run(ctx, e, sqlpp.querier(ctx, direct, &rows)); err != nil {
+		return nil, sqlpp.end(ctx, e, err)
+	}
+
+	results, err := sqlpp.parse(rows, scan, sqlpp.capacity(ctx))
+	e.Rows = len(results)
+	e.returned = results
+	return results, sqlpp.end(ctx, e, err)
+}
+
+func (sqlpp *DB) parse(rows *sql.Rows, scanner Scanner, capacity int) ([]interface{}, error) {
 	if rows == nil {
 		return nil, ErrNilRows
 	} else if scanner == nil {
 		return nil, ErrNilScanner
 	}
 
-	results := []interface{}{}
+	results := make([]interface{}, 0, capacity)
 	for rows.Next() {
 		scanned, err := scanner(rows)
 		if err != nil {
@@ -141,11 +731,32 @@ func (sqlpp *DB) parse(rows *sql.Rows, scanner Scanner) ([]interface{}, error) {
 	return results, nil
 }
 
+// Deprecated: use NewArgs, which checks the args as well.
 func (sqlpp *DB) Args(args ...interface{}) []interface{} {
 	return args
 }
 
+// PostgreSQL reports whether the helpers build postgres sql on the db,
+// created by NewPostgreSQL or NewCockroachDB.
+func (sqlpp *DB) PostgreSQL() bool {
+	return sqlpp.dialect.flavor == postgresFlavor
+}
+
+// MySQL reports whether the helpers build mysql sql on the db, created by
+// NewMySQL or NewTiDB. The helpers fail with ErrNotSupported on the dbs
+// neither reports.
+func (sqlpp *DB) MySQL() bool {
+	return sqlpp.dialect.flavor == mysqlFlavor
+}
+
+// Dialect returns the name of the dialect of the db, e.g. mysql.
+func (sqlpp *DB) Dialect() string {
+	return sqlpp.dialect.name
+}
+
 func (sqlpp *DB) Close() error {
+	sqlpp.stopReporters(nil)
+	sqlpp.drain()
 	sqlpp.stmts.Range(func(key, value interface{}) bool {
 		if stmt, o := value.(*sql.Stmt); o {
 			stmt.Close()
@@ -155,6 +766,11 @@ func (sqlpp *DB) Close() error {
 	})
 
 	sqlpp.stmts = sync.Map{}
+	atomic.StoreInt64(&sqlpp.stats.stmts, 0)
+	if sqlpp.clone {
+		return nil
+	}
+
 	return sqlpp.DB.Close()
 }
 
@@ -162,53 +778,182 @@ func (sqlpp *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
 	return sqlpp.ExecContext(context.Background(), query, args...)
 }
 func (sqlpp *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	stmt, query, args, err := sqlpp.prepare(ctx, query, args)
+	ctx, e, err := sqlpp.begin(ctx, query, args)
 	if err != nil {
-		if isMysqlPrepareNotSupported(err) {
-			return sqlpp.DB.ExecContext(ctx, query, args...)
-		}
-
 		return nil, err
 	}
 
-	return stmt.ExecContext(ctx, args...)
+	var result sql.Result
+	err = sqlpp.run(ctx, e, sqlpp.execer(ctx, sqlpp.DB, &result))
+	e.Result = result
+	return result, sqlpp.end(ctx, e, err)
 }
 
-func (sqlpp *DB) QueryRow(query string, args []interface{}, dest ...interface{}) error {
-	return sqlpp.QueryRowContext(context.Background(), query, args, dest...)
+type Result struct {
+	LastInsertID int64
+	RowsAffected int64
+	// ConsistencyToken after the write, with WithConsistencyTokens
+	Token string
 }
-func (sqlpp *DB) QueryRowContext(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
-	stmt, query, args, err := sqlpp.prepare(ctx, query, args)
+
+func (sqlpp *DB) ExecResult(query string, args ...interface{}) (Result, error) {
+	return sqlpp.ExecResultContext(context.Background(), query, args...)
+}
+func (sqlpp *DB) ExecResultContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	var result Result
+	r, err := sqlpp.ExecContext(ctx, query, args...)
 	if err != nil {
-		if isMysqlPrepareNotSupported(err) {
-			err = sqlpp.DB.QueryRowContext(ctx, query, args...).Scan(dest...)
+		return result, err
+	}
+
+	if result.RowsAffected, err = r.RowsAffected(); err != nil {
+		return result, err
+	}
+
+	// drivers without a last insert id fail, use returning or OUTPUT
+	// instead
+	if sqlpp.dialect.lastInsertID {
+		if result.LastInsertID, err = r.LastInsertId(); err != nil {
+			return result, err
 		}
+	}
 
-		return err
+	if sqlpp.config().consistencyTokens {
+		result.Token, err = sqlpp.ConsistencyToken(ctx)
 	}
 
-	return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+	return result, err
 }
 
-func (sqlpp *DB) Query(query string, args []interface{}, scan Scanner) ([]interface{}, error) {
-	return sqlpp.QueryContext(context.Background(), query, args, scan)
+// Row is a single row query, run by Scan as the dest are only known then.
+type Row struct {
+	scan func(dest []interface{}) error
 }
-func (sqlpp *DB) QueryContext(ctx context.Context, query string, args []interface{}, scan Scanner) ([]interface{}, error) {
-	var rows *sql.Rows
-	stmt, query, args, err := sqlpp.prepare(ctx, query, args)
+
+func (r *Row) Scan(dest ...interface{}) error {
+	return r.scan(dest)
+}
+
+// Row returns the first row of query for Scan, taking args like Exec.
+func (sqlpp *DB) Row(query string, args ...interface{}) *Row {
+	return sqlpp.RowContext(context.Background(), query, args...)
+}
+func (sqlpp *DB) RowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{func(dest []interface{}) error {
+		return sqlpp.QueryRowContext(ctx, query, args, dest...)
+	}}
+}
+
+// GetRow returns the first row of query parsed by scan, so single rows
+// can share the scanners of Select. It fails with sql.ErrNoRows if there
+// is no row.
+func (sqlpp *DB) GetRow(scan Scanner, query string, args ...interface{}) (interface{}, error) {
+	return sqlpp.GetRowContext(context.Background(), scan, query, args...)
+}
+func (sqlpp *DB) GetRowContext(ctx context.Context, scan Scanner, query string, args ...interface{}) (interface{}, error) {
+	ctx, e, err := sqlpp.begin(ctx, query, args)
 	if err != nil {
-		if isMysqlPrepareNotSupported(err) {
-			rows, err = sqlpp.DB.QueryContext(ctx, query, args...)
-		} else {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if err := sqlpp.run(ctx, e, sqlpp.querier(ctx, sqlpp.DB, &rows)); err != nil {
+		return nil, sqlpp.end(ctx, e, err)
+	}
+
+	result, err := sqlpp.first(rows, scan)
+	if err == nil {
+		e.Rows = 1
+		e.returned = []interface{}{result}
+	}
+
+	return result, sqlpp.end(ctx, e, err)
+}
+
+// first parses the first row with scanner and closes rows.
+func (sqlpp *DB) first(rows *sql.Rows, scanner Scanner) (interface{}, error) {
+	if rows == nil {
+		return nil, ErrNilRows
+	}
+	defer rows.Close()
+
+	if scanner == nil {
+		return nil, ErrNilScanner
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
 			return nil, err
 		}
-	} else {
-		rows, err = stmt.QueryContext(ctx, args...)
+
+		return nil, sql.ErrNoRows
 	}
 
+	result, err := scanner(rows)
 	if err != nil {
 		return nil, err
 	}
 
-	return sqlpp.parse(rows, scan)
+	return result, rows.Close()
+}
+
+// Select returns the rows of query parsed by scan, taking args like Exec.
+func (sqlpp *DB) Select(scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	return sqlpp.SelectContext(context.Background(), scan, query, args...)
+}
+func (sqlpp *DB) SelectContext(ctx context.Context, scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	return sqlpp.QueryContext(ctx, query, args, scan)
+}
+
+// Deprecated: use Row, whose args are variadic like those of the other
+// methods.
+func (sqlpp *DB) QueryRow(query string, args []interface{}, dest ...interface{}) error {
+	return sqlpp.QueryRowContext(context.Background(), query, args, dest...)
+}
+
+// Deprecated: use RowContext.
+func (sqlpp *DB) QueryRowContext(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
+	ctx, e, err := sqlpp.begin(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	err = sqlpp.run(ctx, e, sqlpp.rowScanner(ctx, sqlpp.DB, dest))
+	e.returned = dest
+	return sqlpp.end(ctx, e, err)
+}
+
+// Deprecated: use Select, whose args are variadic like those of the other
+// methods.
+func (sqlpp *DB) Query(query string, args []interface{}, scan Scanner) ([]interface{}, error) {
+	return sqlpp.QueryContext(context.Background(), query, args, scan)
+}
+
+// Deprecated: use SelectContext.
+func (sqlpp *DB) QueryContext(ctx context.Context, query string, args []interface{}, scan Scanner) ([]interface{}, error) {
+	return sqlpp.cached(ctx, query, args, func() ([]interface{}, error) {
+		return sqlpp.coalesced(ctx, query, args, func() ([]interface{}, error) {
+			ctx, e, err := sqlpp.begin(ctx, query, args)
+			if err != nil {
+				return nil, err
+			}
+
+			return sqlpp.query(ctx, e, sqlpp.DB, scan, sqlpp.run)
+		})
+	})
+}
+
+// Rows returns the rows of query for the caller to iterate and close. The
+// rows outlive the call, so they aren't cancelled by Shutdown or the default
+// query timeout, nor counted by the concurrency limit, and hooks see the
+// time to the first row.
+func (sqlpp *DB) Rows(query string, args ...interface{}) (*sql.Rows, error) {
+	return sqlpp.RowsContext(context.Background(), query, args...)
+}
+func (sqlpp *DB) RowsContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	e := &QueryEvent{Query: query, Args: args, Start: time.Now(), parent: ctx, done: func() {}}
+
+	var rows *sql.Rows
+	err := sqlpp.run(ctx, e, sqlpp.querier(ctx, sqlpp.DB, &rows))
+	return rows, sqlpp.end(ctx, e, err)
 }
@@ -6,6 +6,8 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 
@@ -15,6 +17,18 @@ import (
 
 var errPrepareNotSupported = errors.New(mysqlErrPrefixPrepareNotSupported)
 
+// valuerSlice is a driver array type, a single arg
+type valuerSlice []string
+
+func (v valuerSlice) Value() (driver.Value, error) {
+	return "{" + strings.Join(v, ",") + "}", nil
+}
+
+var (
+	outDest int
+	testOut = sql.Out{Dest: &outDest}
+)
+
 func TestDB_transform(t *testing.T) {
 	cases := []struct {
 		query     string
@@ -68,6 +82,11 @@ func TestDB_transform(t *testing.T) {
 			"select koo.bar from foo koo inner join loo moo on koo.bar = moo.baz where koo.bar in (?,?,?) order by 1",
 			"select koo.bar from foo koo inner join loo moo on koo.bar = moo.baz where koo.bar in ($1,$2,$3) order by 1",
 			[]interface{}{1, 2, 3},
+		}, {
+			"call foo(?, (?), ?, ?)", []interface{}{[]byte("b"), []int{1, 2}, valuerSlice{"v"}, testOut},
+			"call foo(?, (?,?), ?, ?)",
+			"call foo($1, ($2,$3), $4, $5)",
+			[]interface{}{[]byte("b"), 1, 2, valuerSlice{"v"}, testOut},
 		},
 	}
 
@@ -89,6 +108,65 @@ func TestDB_transform(t *testing.T) {
 	}
 }
 
+func TestDB_transformCache(t *testing.T) {
+	m := NewMySQL(nil)
+	p := NewPostgreSQL(nil)
+
+	cases := []struct {
+		query    string
+		args     []interface{}
+		ePgQuery string
+		eArgs    []interface{}
+	}{
+		{
+			"select * from foo where i = ? and j in (?)", []interface{}{1, []int{2, 3}},
+			"select * from foo where i = $1 and j in ($2,$3)",
+			[]interface{}{1, 2, 3},
+		}, {
+			"select * from foo where i = ? and j in (?)", []interface{}{4, []int{5, 6}},
+			"select * from foo where i = $1 and j in ($2,$3)",
+			[]interface{}{4, 5, 6},
+		}, {
+			"select * from foo where i = ? and j in (?)", []interface{}{4, []int{5}},
+			"select * from foo where i = $1 and j in ($2)",
+			[]interface{}{4, 5},
+		}, {
+			"select * from foo where i = ?", []interface{}{1},
+			"select * from foo where i = $1",
+			[]interface{}{1},
+		},
+	}
+
+	for _, c := range cases {
+		_, mea := m.transform(c.query, c.args)
+		peq, pea := p.transform(c.query, c.args)
+
+		assert.Equal(t, c.ePgQuery, peq)
+		assert.Equal(t, c.eArgs, mea)
+		assert.Equal(t, c.eArgs, pea)
+
+		var lengths []int
+		if strings.Contains(c.query, "(?)") {
+			_, lengths = flatten(&[]interface{}{}, c.args, false, false, false)
+		}
+
+		cached, ok := p.queries.Get(transformKey(c.query, lengths))
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{c.ePgQuery}, cached)
+	}
+}
+
+func TestDB_transform_bounded(t *testing.T) {
+	p := NewPostgreSQL(nil)
+	for i := 0; i < maxTransforms+10; i++ {
+		p.transform("select * from foo where i = ? limit "+strconv.Itoa(i), []interface{}{1})
+	}
+
+	assert.Equal(t, maxTransforms, p.queries.Len())
+	_, ok := p.queries.Get(transformKey("select * from foo where i = ? limit 0", nil))
+	assert.False(t, ok)
+}
+
 func TestDB_prepare(t *testing.T) {
 	mDb, mMock, mErr := sqlmock.New()
 	pDb, pMock, pErr := sqlmock.New()
@@ -212,7 +290,7 @@ func TestDB_Close(t *testing.T) {
 	}
 
 	assertLen := func(s, e int) {
-		len := func(m sync.Map) (int, int, int) {
+		len := func(m *sync.Map) (int, int, int) {
 			ls := 0
 			le := 0
 			lu := 0
@@ -231,8 +309,8 @@ func TestDB_Close(t *testing.T) {
 			return ls, le, lu
 		}
 
-		mls, mle, mlu := len(sm.stmts)
-		pls, ple, plu := len(sp.stmts)
+		mls, mle, mlu := len(&sm.stmts)
+		pls, ple, plu := len(&sp.stmts)
 
 		assert.Equal(t, mls, pls)
 		assert.Equal(t, mle, ple)
@@ -570,3 +648,321 @@ func TestDB_Query(t *testing.T) {
 	assert.Nil(t, mMock.ExpectationsWereMet())
 	assert.Nil(t, pMock.ExpectationsWereMet())
 }
+
+func TestDB_ExecResult(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New()
+	pDb, pMock, pErr := sqlmock.New()
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	cases := []struct {
+		query   string
+		eQuery  string
+		result  driver.Result
+		eResult Result
+		err     error
+	}{
+		{
+			"insert into foo select ?",
+			"^insert into foo select (.+)$",
+			sqlmock.NewResult(3, 1),
+			Result{LastInsertID: 3, RowsAffected: 1},
+			nil,
+		}, {
+			"update foo set i = ?",
+			"^update foo set i = (.+)$",
+			sqlmock.NewErrorResult(errors.New("result err")),
+			Result{},
+			errors.New("result err"),
+		},
+	}
+
+	for _, c := range cases {
+		mMock.ExpectPrepare(c.eQuery).ExpectExec().WithArgs(1).WillReturnResult(c.result)
+		pMock.ExpectPrepare(c.eQuery).ExpectExec().WithArgs(1).WillReturnResult(c.result)
+
+		rm, em := sm.ExecResult(c.query, 1)
+		rp, ep := sp.ExecResult(c.query, 1)
+
+		assert.Equal(t, c.err, em)
+		assert.Equal(t, c.err, ep)
+		assert.Equal(t, c.eResult, rm)
+		if c.err == nil {
+			assert.Equal(t, Result{RowsAffected: c.eResult.RowsAffected}, rp)
+		}
+	}
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
+
+func TestDB_reprepare(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New()
+	pDb, pMock, pErr := sqlmock.New()
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	mMock.ExpectPrepare("^select (.+) from foo$").WillBeClosed().
+		ExpectQuery().WillReturnError(errors.New("Error 1615: Prepared statement needs to be re-prepared"))
+	mMock.ExpectPrepare("^select (.+) from foo$").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	pMock.ExpectPrepare("^select (.+) from foo$").WillBeClosed().
+		ExpectQuery().WillReturnError(errors.New("pq: cached plan must not change result type"))
+	pMock.ExpectPrepare("^select (.+) from foo$").
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+
+	var im, ip int
+	assert.Nil(t, sm.QueryRow("select * from foo", nil, &im))
+	assert.Nil(t, sp.QueryRow("select * from foo", nil, &ip))
+	assert.Equal(t, 1, im)
+	assert.Equal(t, 1, ip)
+
+	// retries only once
+	mMock.ExpectPrepare("^select (.+) from bar$").WillBeClosed().
+		ExpectExec().WillReturnError(errors.New("Error 1615: Prepared statement needs to be re-prepared"))
+	mMock.ExpectPrepare("^select (.+) from bar$").
+		ExpectExec().WillReturnError(errors.New("Error 1615: Prepared statement needs to be re-prepared"))
+
+	_, err := sm.Exec("select * from bar")
+	assert.True(t, isMysqlNeedsReprepare(err))
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
+
+func TestDB_InvalidateStatements(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	mock.ExpectPrepare("^select (.+) from foo$").WillBeClosed().
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("^select (.+) from foo$").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = s.Exec("select * from foo")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), s.CacheStats().Stmts)
+
+	s.InvalidateStatements()
+	assert.Equal(t, int64(0), s.CacheStats().Stmts)
+
+	_, err = s.Exec("select * from foo")
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func BenchmarkDB_transform(b *testing.B) {
+	ids := make([]int, 3000)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	m := NewMySQL(nil)
+	p := NewPostgreSQL(nil)
+	query := "select * from foo where i = ? and j in (?) and k = ?"
+	args := []interface{}{1, ids, 2}
+
+	b.Run("mysql", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.transform(query, args)
+		}
+	})
+
+	b.Run("postgres", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p.transform(query, args)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tempArgs := getArgs()
+			p.transformTo(tempArgs, query, args)
+			putArgs(tempArgs)
+		}
+	})
+}
+
+func TestDB_parse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	scanner := func(r *sql.Rows) (interface{}, error) {
+		var i int
+		return i, r.Scan(&i)
+	}
+
+	mock.ExpectQuery("^select (.+) from foo$").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	rows, err := db.Query("select * from foo")
+	assert.Nil(t, err)
+
+	results, err := s.parse(rows, scanner, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, results)
+	assert.Equal(t, 10, cap(results))
+
+	_, err = s.parse(nil, scanner, 0)
+	assert.Equal(t, ErrNilRows, err)
+	_, err = s.parse(rows, nil, 0)
+	assert.Equal(t, ErrNilScanner, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_QuoteIdent(t *testing.T) {
+	cases := []struct {
+		ident  string
+		eMysql string
+		ePg    string
+	}{
+		{"foo", "`foo`", `"foo"`},
+		{"foo.bar", "`foo`.`bar`", `"foo"."bar"`},
+		{"fo`o\"", "`fo``o\"`", `"fo` + "`" + `o"""`},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(c.ident, func(t *testing.T) {
+			assert.Equal(t, c.eMysql, NewMySQL(nil).QuoteIdent(c.ident))
+			assert.Equal(t, c.ePg, NewPostgreSQL(nil).QuoteIdent(c.ident))
+		})
+	}
+}
+
+func TestDB_strictPrepare(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithStrictPrepare())
+	mock.ExpectPrepare("^lock tables foo read$").WillReturnError(errPrepareNotSupported)
+
+	_, err = s.Exec("lock tables foo read")
+	assert.True(t, errors.Is(err, ErrPrepareNotSupported))
+	assert.True(t, errors.Is(err, errPrepareNotSupported))
+	_, err = s.ExecContext(Interpolate(context.Background()), "update foo set i = ?", 1)
+	assert.Equal(t, ErrStrictPrepare, err)
+	_, err = s.Clone(WithInterpolation()).Exec("update foo set i = ?", 1)
+	assert.Equal(t, ErrStrictPrepare, err)
+
+	var verr *ValidateError
+	mock.ExpectPrepare("^lock tables foo read$").WillReturnError(errPrepareNotSupported)
+	assert.True(t, errors.As(s.Validate(context.Background(), "lock tables foo read"), &verr))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Rows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var events []*QueryEvent
+	s := NewPostgreSQL(db, WithHook(func(ctx context.Context, e *QueryEvent) {
+		events = append(events, e)
+	}))
+
+	mock.ExpectPrepare(`^select i from foo where i in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+
+	rows, err := s.Rows("select i from foo where i in (?)", []int{1, 2})
+	assert.Nil(t, err)
+
+	var got []int
+	for rows.Next() {
+		var i int
+		assert.Nil(t, rows.Scan(&i))
+		got = append(got, i)
+	}
+
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, []int{1, 2}, got)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "select i from foo where i in ($1,$2)", events[0].Statement)
+
+	query, args := s.Transform("select i from foo where i in (?)", []int{1, 2})
+	assert.Equal(t, "select i from foo where i in ($1,$2)", query)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_RowSelect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	scan := func(rows *sql.Rows) (interface{}, error) {
+		var i int
+		return i, rows.Scan(&i)
+	}
+
+	mock.ExpectPrepare(`^select i from foo where j = \$1 and k in \(\$2,\$3\)$`).
+		ExpectQuery().WithArgs("j", 1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mock.ExpectPrepare(`^select i from foo where k in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	mock.ExpectPrepare(`^select i from foo where j = \$1$`).
+		ExpectQuery().WithArgs("j").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(3))
+	mock.ExpectQuery(`^select i from foo where j = \$1$`).
+		WithArgs("k").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(4))
+
+	var i int
+	assert.Nil(t, s.Row("select i from foo where j = ? and k in (?)", "j", []int{1, 2}).Scan(&i))
+	assert.Equal(t, 1, i)
+
+	results, err := s.Select(scan, "select i from foo where k in (?)", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, results)
+
+	cq := s.Compile("select i from foo where j = ?")
+	assert.Nil(t, cq.Row("j").Scan(&i))
+	assert.Equal(t, 3, i)
+
+	results, err = cq.Select(scan, "k")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{4}, results)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_GetRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	type foo struct {
+		i int
+		j string
+	}
+	scan := func(rows *sql.Rows) (interface{}, error) {
+		var f foo
+		return f, rows.Scan(&f.i, &f.j)
+	}
+
+	prepared := mock.ExpectPrepare(`^select i, j from foo where i in \(\?,\?\)$`)
+	prepared.ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i", "j"}).AddRow(1, "a").AddRow(2, "b"))
+	prepared.ExpectQuery().WithArgs(3, 4).WillReturnRows(sqlmock.NewRows([]string{"i", "j"}))
+
+	row, err := s.GetRow(scan, "select i, j from foo where i in (?)", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, foo{1, "a"}, row)
+
+	_, err = s.GetRow(scan, "select i, j from foo where i in (?)", []int{3, 4})
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	mock.ExpectPrepare(`^select 1$`).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	_, err = s.GetRow(nil, "select 1")
+	assert.Equal(t, ErrNilScanner, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
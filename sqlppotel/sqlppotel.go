@@ -0,0 +1,89 @@
+// Package sqlppotel adapts an OpenTelemetry meter to sqlpp.Metrics.
+package sqlppotel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nzmprlr/sqlpp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type metrics struct {
+	meter metric.Meter
+
+	mu         sync.Mutex
+	counters   map[string]metric.Float64Counter
+	histograms map[string]metric.Float64Histogram
+	gauges     map[string]metric.Float64Gauge
+}
+
+// New returns a sqlpp.Metrics recording to instruments of meter, created on
+// first use, the labels become attributes. Instruments failing to be
+// created are dropped, as meter returns no-op ones with the error.
+func New(meter metric.Meter) sqlpp.Metrics {
+	return &metrics{
+		meter:      meter,
+		counters:   map[string]metric.Float64Counter{},
+		histograms: map[string]metric.Float64Histogram{},
+		gauges:     map[string]metric.Float64Gauge{},
+	}
+}
+
+func (m *metrics) Counter(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	c, ok := m.counters[name]
+	if !ok {
+		c, _ = m.meter.Float64Counter(name)
+		m.counters[name] = c
+	}
+	m.mu.Unlock()
+
+	c.Add(context.Background(), delta, attributes(labels))
+}
+
+func (m *metrics) Histogram(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	h, ok := m.histograms[name]
+	if !ok {
+		h, _ = m.meter.Float64Histogram(name, metric.WithUnit(unit(name)))
+		m.histograms[name] = h
+	}
+	m.mu.Unlock()
+
+	h.Record(context.Background(), value, attributes(labels))
+}
+
+func (m *metrics) Gauge(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	g, ok := m.gauges[name]
+	if !ok {
+		g, _ = m.meter.Float64Gauge(name)
+		m.gauges[name] = g
+	}
+	m.mu.Unlock()
+
+	g.Record(context.Background(), value, attributes(labels))
+}
+
+func attributes(labels map[string]string) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+	return metric.WithAttributes(kvs...)
+}
+
+// unit maps the _seconds suffix of sqlpp's duration names to the unit.
+func unit(name string) string {
+	if strings.HasSuffix(name, "_seconds") {
+		return "s"
+	}
+
+	return ""
+}
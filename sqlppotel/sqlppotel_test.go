@@ -0,0 +1,49 @@
+package sqlppotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNew(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("sqlpp"))
+
+	labels := map[string]string{"statement": "select", "status": "ok"}
+	m.Counter("sqlpp_queries_total", labels, 1)
+	m.Counter("sqlpp_queries_total", labels, 2)
+	m.Histogram("sqlpp_query_duration_seconds", labels, 0.5)
+	m.Gauge("sqlpp_connections_open", nil, 3)
+	m.Gauge("sqlpp_connections_open", nil, 4)
+
+	var rm metricdata.ResourceMetrics
+	assert.Nil(t, reader.Collect(context.Background(), &rm))
+	assert.Len(t, rm.ScopeMetrics, 1)
+
+	got := map[string]metricdata.Metrics{}
+	for _, md := range rm.ScopeMetrics[0].Metrics {
+		got[md.Name] = md
+	}
+	attrs := attribute.NewSet(attribute.String("statement", "select"), attribute.String("status", "ok"))
+
+	sum := got["sqlpp_queries_total"].Data.(metricdata.Sum[float64])
+	assert.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, 3.0, sum.DataPoints[0].Value)
+	assert.True(t, attrs.Equals(&sum.DataPoints[0].Attributes))
+
+	hist := got["sqlpp_query_duration_seconds"]
+	assert.Equal(t, "s", hist.Unit)
+	points := hist.Data.(metricdata.Histogram[float64]).DataPoints
+	assert.Len(t, points, 1)
+	assert.Equal(t, uint64(1), points[0].Count)
+	assert.Equal(t, 0.5, points[0].Sum)
+
+	gauge := got["sqlpp_connections_open"].Data.(metricdata.Gauge[float64])
+	assert.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, 4.0, gauge.DataPoints[0].Value)
+}
@@ -0,0 +1,99 @@
+// Package sqlppprom adapts a prometheus registerer to sqlpp.Metrics.
+package sqlppprom
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nzmprlr/sqlpp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	reg prometheus.Registerer
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+	gauges     map[string]*prometheus.GaugeVec
+}
+
+// New returns a sqlpp.Metrics registering its collectors in reg on first
+// use, labeled by the label names of that use, as sqlpp uses the same ones
+// for a name. Registering panics as MustRegister does, unless the same
+// collector is registered already.
+func New(reg prometheus.Registerer) sqlpp.Metrics {
+	return &metrics{
+		reg:        reg,
+		counters:   map[string]*prometheus.CounterVec{},
+		histograms: map[string]*prometheus.HistogramVec{},
+		gauges:     map[string]*prometheus.GaugeVec{},
+	}
+}
+
+func (m *metrics) Counter(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	c, ok := m.counters[name]
+	if !ok {
+		c = m.register(prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help(name)}, labelNames(labels))).(*prometheus.CounterVec)
+		m.counters[name] = c
+	}
+	m.mu.Unlock()
+
+	c.With(labels).Add(delta)
+}
+
+func (m *metrics) Histogram(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	h, ok := m.histograms[name]
+	if !ok {
+		h = m.register(prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help(name)}, labelNames(labels))).(*prometheus.HistogramVec)
+		m.histograms[name] = h
+	}
+	m.mu.Unlock()
+
+	h.With(labels).Observe(value)
+}
+
+func (m *metrics) Gauge(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	g, ok := m.gauges[name]
+	if !ok {
+		g = m.register(prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help(name)}, labelNames(labels))).(*prometheus.GaugeVec)
+		m.gauges[name] = g
+	}
+	m.mu.Unlock()
+
+	g.With(labels).Set(value)
+}
+
+// register returns the collector registered already for c, e.g. by another
+// db using reg.
+func (m *metrics) register(c prometheus.Collector) prometheus.Collector {
+	if err := m.reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+
+		panic(err)
+	}
+
+	return c
+}
+
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func help(name string) string {
+	return "sqlpp " + strings.ReplaceAll(strings.TrimPrefix(name, "sqlpp_"), "_", " ")
+}
@@ -0,0 +1,34 @@
+package sqlppprom
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(reg)
+
+	labels := map[string]string{"statement": "select", "status": "ok"}
+	m.Counter("sqlpp_queries_total", labels, 1)
+	m.Counter("sqlpp_queries_total", labels, 2)
+	m.Histogram("sqlpp_query_duration_seconds", labels, 0.5)
+	m.Gauge("sqlpp_connections_open", nil, 3)
+
+	// a second adapter on reg shares the collectors
+	New(reg).Gauge("sqlpp_connections_open", nil, 4)
+
+	m2 := m.(*metrics)
+	assert.Equal(t, 3.0, testutil.ToFloat64(m2.counters["sqlpp_queries_total"].With(labels)))
+	assert.Equal(t, 4.0, testutil.ToFloat64(m2.gauges["sqlpp_connections_open"]))
+	assert.Equal(t, 1, testutil.CollectAndCount(m2.histograms["sqlpp_query_duration_seconds"]))
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	assert.Len(t, families, 3)
+
+	assert.Panics(t, func() { New(reg).Counter("sqlpp_queries_total", map[string]string{"other": "x"}, 1) })
+}
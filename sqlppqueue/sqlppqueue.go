@@ -0,0 +1,226 @@
+// Package sqlppqueue is a job queue in a table of the db, so jobs can be
+// enqueued in the transactions producing them.
+package sqlppqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/nzmprlr/sqlpp"
+)
+
+var (
+	ErrClaimLost = errors.New("sqlppqueue: job claimed by another worker")
+)
+
+// Job is a job claimed by Dequeue.
+type Job struct {
+	ID       int64
+	Queue    string
+	Payload  []byte
+	Attempts int
+	// error of the last failed attempt
+	LastError string
+
+	claim string
+}
+
+// Queue stores jobs of named queues in a table. A job is handed to one
+// worker at a time and runs at least once, workers must tolerate
+// duplicates.
+type Queue struct {
+	db    *sqlpp.DB
+	table string
+
+	// Visibility is how long a claimed job stays hidden from Dequeue. A job
+	// not acked or nacked in time is claimed again.
+	Visibility time.Duration
+	// MaxAttempts moves jobs failing that many times to the dead letters.
+	MaxAttempts int
+	// Backoff returns how long a job waits after failing attempt times.
+	Backoff func(attempt int) time.Duration
+}
+
+func New(db *sqlpp.DB, table string) *Queue {
+	return &Queue{
+		db:    db,
+		table: table,
+
+		Visibility:  30 * time.Second,
+		MaxAttempts: 5,
+		Backoff:     ExponentialBackoff(time.Second, time.Hour),
+	}
+}
+
+// ExponentialBackoff doubles the wait from base for each attempt, up to max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+
+		if d > max {
+			return max
+		}
+
+		return d
+	}
+}
+
+// Create creates the table of the queue if it doesn't exist.
+func (q *Queue) Create(ctx context.Context) error {
+	table := q.db.QuoteIdent(q.table)
+	if !q.db.PostgreSQL() && !q.db.MySQL() {
+		return sqlpp.ErrNotSupported
+	} else if q.db.MySQL() {
+		_, err := q.db.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" (id BIGINT AUTO_INCREMENT PRIMARY KEY, "+
+			"queue VARCHAR(255) NOT NULL, payload BLOB NOT NULL, attempts INT NOT NULL DEFAULT 0, "+
+			"visible_at BIGINT NOT NULL, claim VARCHAR(32), last_error TEXT, dead BOOLEAN NOT NULL DEFAULT FALSE, "+
+			"INDEX (queue, dead, visible_at), INDEX (claim))")
+		return err
+	}
+
+	_, err := q.db.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" (id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY, "+
+		"queue VARCHAR(255) NOT NULL, payload BYTEA NOT NULL, attempts INT NOT NULL DEFAULT 0, "+
+		"visible_at BIGINT NOT NULL, claim VARCHAR(32), last_error TEXT, dead BOOLEAN NOT NULL DEFAULT FALSE)")
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.DB.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+q.db.QuoteIdent(q.table+"_visible")+
+		" ON "+table+" (queue, dead, visible_at)")
+	return err
+}
+
+// Enqueue adds a job to queue, in tx if not nil so it's only visible if tx
+// commits.
+func (q *Queue) Enqueue(ctx context.Context, tx *sql.Tx, queue string, payload []byte) error {
+	query := "INSERT INTO " + q.db.QuoteIdent(q.table) + " (queue, payload, visible_at) VALUES (?,?,?)"
+	args := []interface{}{queue, payload, time.Now().UnixMicro()}
+	if tx == nil {
+		_, err := q.db.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	query, args = q.db.Transform(query, args...)
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Dequeue claims up to n visible jobs of queue, oldest first, hiding them
+// for Visibility. Jobs out of attempts whose worker never returned are
+// moved to the dead letters first.
+func (q *Queue) Dequeue(ctx context.Context, queue string, n int) ([]Job, error) {
+	if !q.db.PostgreSQL() && !q.db.MySQL() {
+		return nil, sqlpp.ErrNotSupported
+	}
+
+	table := q.db.QuoteIdent(q.table)
+	now := time.Now()
+	if _, err := q.db.ExecContext(ctx, "UPDATE "+table+" SET dead = TRUE, claim = NULL"+
+		" WHERE queue = ? AND dead = FALSE AND attempts >= ? AND visible_at <= ?",
+		queue, q.MaxAttempts, now.UnixMicro()); err != nil {
+		return nil, err
+	}
+
+	claim, err := newClaim()
+	if err != nil {
+		return nil, err
+	}
+
+	set := "UPDATE " + table + " SET claim = ?, attempts = attempts + 1, visible_at = ?"
+	args := []interface{}{claim, now.Add(q.Visibility).UnixMicro(), queue, now.UnixMicro(), n}
+	// skip locked lets concurrent workers claim other rows instead of
+	// waiting, mysql claims by marking rows with a single update
+	if q.db.PostgreSQL() {
+		if caps := q.db.Capabilities(); !caps.SkipLocked || !caps.Returning {
+			return nil, sqlpp.ErrNotSupported
+		}
+
+		return sqlpp.SelectContext(ctx, q.db, scanJob(claim), set+" WHERE id IN (SELECT id FROM "+table+
+			" WHERE queue = ? AND dead = FALSE AND visible_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED)"+
+			" RETURNING "+jobColumns, args...)
+	}
+
+	if _, err := q.db.ExecContext(ctx, set+" WHERE queue = ? AND dead = FALSE AND visible_at <= ? ORDER BY id LIMIT ?", args...); err != nil {
+		return nil, err
+	}
+
+	return sqlpp.SelectContext(ctx, q.db, scanJob(claim), "SELECT "+jobColumns+" FROM "+table+
+		" WHERE claim = ? ORDER BY id", claim)
+}
+
+// Ack removes a job once it's done. It fails with ErrClaimLost if the job
+// was claimed again after its visibility ran out.
+func (q *Queue) Ack(ctx context.Context, j Job) error {
+	result, err := q.db.ExecContext(ctx, "DELETE FROM "+q.db.QuoteIdent(q.table)+" WHERE id = ? AND claim = ?", j.ID, j.claim)
+	return claimed(result, err)
+}
+
+// Nack returns a failed job to the queue after its Backoff, or moves it to
+// the dead letters when it's out of attempts.
+func (q *Queue) Nack(ctx context.Context, j Job, cause error) error {
+	dead := j.Attempts >= q.MaxAttempts
+	visible := time.Now()
+	if !dead {
+		visible = visible.Add(q.Backoff(j.Attempts))
+	}
+
+	result, err := q.db.ExecContext(ctx, "UPDATE "+q.db.QuoteIdent(q.table)+
+		" SET claim = NULL, last_error = ?, visible_at = ?, dead = ? WHERE id = ? AND claim = ?",
+		cause.Error(), visible.UnixMicro(), dead, j.ID, j.claim)
+	return claimed(result, err)
+}
+
+// DeadLetters returns up to n jobs of queue that ran out of attempts.
+func (q *Queue) DeadLetters(ctx context.Context, queue string, n int) ([]Job, error) {
+	return sqlpp.SelectContext(ctx, q.db, scanJob(""), "SELECT "+jobColumns+" FROM "+q.db.QuoteIdent(q.table)+
+		" WHERE queue = ? AND dead = TRUE ORDER BY id LIMIT ?", queue, n)
+}
+
+// Retry returns a dead letter to its queue with its attempts reset.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, "UPDATE "+q.db.QuoteIdent(q.table)+
+		" SET dead = FALSE, attempts = 0, visible_at = ? WHERE id = ? AND dead = TRUE", time.Now().UnixMicro(), id)
+	return err
+}
+
+const jobColumns = "id, queue, payload, attempts, last_error"
+
+func scanJob(claim string) sqlpp.ScannerOf[Job] {
+	return func(rows *sql.Rows) (Job, error) {
+		j := Job{claim: claim}
+		var lastError sql.NullString
+		err := rows.Scan(&j.ID, &j.Queue, &j.Payload, &j.Attempts, &lastError)
+		j.LastError = lastError.String
+		return j, err
+	}
+}
+
+func claimed(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
+
+func newClaim() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
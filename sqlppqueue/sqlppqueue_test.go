@@ -0,0 +1,122 @@
+package sqlppqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+)
+
+var anyArg = sqlmock.AnyArg()
+
+func TestQueue_MySQL(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	q := New(sqlpp.NewMySQL(conn), "jobs")
+	q.MaxAttempts = 2
+	ctx := context.Background()
+
+	mock.ExpectPrepare("INSERT INTO `jobs` (queue, payload, visible_at) VALUES (?,?,?)").
+		ExpectExec().WithArgs("mail", []byte("a"), anyArg).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `jobs` (queue, payload, visible_at) VALUES (?,?,?)").
+		WithArgs("mail", []byte("b"), anyArg).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, q.Enqueue(ctx, nil, "mail", []byte("a")))
+	tx, err := conn.Begin()
+	assert.Nil(t, err)
+	assert.Nil(t, q.Enqueue(ctx, tx, "mail", []byte("b")))
+	assert.Nil(t, tx.Commit())
+
+	mock.ExpectPrepare("UPDATE `jobs` SET dead = TRUE, claim = NULL WHERE queue = ? AND dead = FALSE AND attempts >= ? AND visible_at <= ?").
+		ExpectExec().WithArgs("mail", 2, anyArg).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("UPDATE `jobs` SET claim = ?, attempts = attempts + 1, visible_at = ? WHERE queue = ? AND dead = FALSE AND visible_at <= ? ORDER BY id LIMIT ?").
+		ExpectExec().WithArgs(anyArg, anyArg, "mail", anyArg, 10).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectPrepare("SELECT id, queue, payload, attempts, last_error FROM `jobs` WHERE claim = ? ORDER BY id").
+		ExpectQuery().WithArgs(anyArg).WillReturnRows(sqlmock.NewRows([]string{"id", "queue", "payload", "attempts", "last_error"}).
+		AddRow(1, "mail", []byte("a"), 1, nil).AddRow(2, "mail", []byte("b"), 2, "boom"))
+
+	jobs, err := q.Dequeue(ctx, "mail", 10)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, []byte("a"), jobs[0].Payload)
+	assert.Equal(t, "boom", jobs[1].LastError)
+	assert.Len(t, jobs[0].claim, 32)
+
+	mock.ExpectPrepare("DELETE FROM `jobs` WHERE id = ? AND claim = ?").
+		ExpectExec().WithArgs(1, jobs[0].claim).WillReturnResult(sqlmock.NewResult(0, 0))
+	nack := mock.ExpectPrepare("UPDATE `jobs` SET claim = NULL, last_error = ?, visible_at = ?, dead = ? WHERE id = ? AND claim = ?")
+	nack.ExpectExec().WithArgs("again", anyArg, true, 2, jobs[1].claim).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Equal(t, ErrClaimLost, q.Ack(ctx, jobs[0]))
+	assert.Nil(t, q.Nack(ctx, jobs[1], errors.New("again")))
+
+	mock.ExpectPrepare("SELECT id, queue, payload, attempts, last_error FROM `jobs` WHERE queue = ? AND dead = TRUE ORDER BY id LIMIT ?").
+		ExpectQuery().WithArgs("mail", 5).WillReturnRows(sqlmock.NewRows([]string{"id", "queue", "payload", "attempts", "last_error"}).
+		AddRow(2, "mail", []byte("b"), 2, "again"))
+	mock.ExpectPrepare("UPDATE `jobs` SET dead = FALSE, attempts = 0, visible_at = ? WHERE id = ? AND dead = TRUE").
+		ExpectExec().WithArgs(anyArg, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	dead, err := q.DeadLetters(ctx, "mail", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []Job{{ID: 2, Queue: "mail", Payload: []byte("b"), Attempts: 2, LastError: "again"}}, dead)
+	assert.Nil(t, q.Retry(ctx, 2))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestQueue_PostgreSQL(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	q := New(sqlpp.NewPostgreSQL(conn), "jobs")
+	ctx := context.Background()
+
+	mock.ExpectPrepare(`UPDATE "jobs" SET dead = TRUE, claim = NULL WHERE queue = $1 AND dead = FALSE AND attempts >= $2 AND visible_at <= $3`).
+		ExpectExec().WithArgs("mail", 5, anyArg).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`UPDATE "jobs" SET claim = $1, attempts = attempts + 1, visible_at = $2 WHERE id IN (SELECT id FROM "jobs"`+
+		` WHERE queue = $3 AND dead = FALSE AND visible_at <= $4 ORDER BY id LIMIT $5 FOR UPDATE SKIP LOCKED)`+
+		` RETURNING id, queue, payload, attempts, last_error`).
+		ExpectQuery().WithArgs(anyArg, anyArg, "mail", anyArg, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "queue", "payload", "attempts", "last_error"}).
+		AddRow(3, "mail", []byte("c"), 1, nil))
+
+	jobs, err := q.Dequeue(ctx, "mail", 1)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 1)
+
+	mock.ExpectPrepare(`UPDATE "jobs" SET claim = NULL, last_error = $1, visible_at = $2, dead = $3 WHERE id = $4 AND claim = $5`).
+		ExpectExec().WithArgs("boom", anyArg, false, 3, jobs[0].claim).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`DELETE FROM "jobs" WHERE id = $1 AND claim = $2`).
+		ExpectExec().WithArgs(3, jobs[0].claim).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, q.Nack(ctx, jobs[0], errors.New("boom")))
+	assert.Nil(t, q.Ack(ctx, jobs[0]))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestQueue_SQLite(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	q := New(sqlpp.NewSQLite(conn), "jobs")
+	assert.Equal(t, sqlpp.ErrNotSupported, q.Create(context.Background()))
+	_, err = q.Dequeue(context.Background(), "mail", 1)
+	assert.Equal(t, sqlpp.ErrNotSupported, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 5*time.Second)
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 4*time.Second, backoff(3))
+	assert.Equal(t, 5*time.Second, backoff(4))
+	assert.Equal(t, 5*time.Second, backoff(40))
+}
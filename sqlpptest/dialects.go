@@ -0,0 +1,106 @@
+package sqlpptest
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/nzmprlr/sqlpp"
+)
+
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	Postgres Dialect = "postgres"
+)
+
+// Backend opens a db of Dialect for a test. Open should skip t when the
+// backend is unavailable.
+type Backend struct {
+	Dialect Dialect
+	Open    func(t testing.TB) *sqlpp.DB
+}
+
+// EnvBackends returns mysql and postgres backends opening the dsns in the
+// SQLPP_MYSQL_DSN and SQLPP_POSTGRES_DSN env vars with the given drivers,
+// skipping the tests of a backend whose dsn is not set.
+func EnvBackends(mysqlDriver, postgresDriver string) []Backend {
+	return []Backend{
+		{MySQL, envOpen(mysqlDriver, "SQLPP_MYSQL_DSN", sqlpp.NewMySQL)},
+		{Postgres, envOpen(postgresDriver, "SQLPP_POSTGRES_DSN", sqlpp.NewPostgreSQL)},
+	}
+}
+
+func envOpen(driver, env string, newDB func(*sql.DB, ...sqlpp.Option) *sqlpp.DB) func(testing.TB) *sqlpp.DB {
+	return func(t testing.TB) *sqlpp.DB {
+		t.Helper()
+
+		dsn := os.Getenv(env)
+		if dsn == "" {
+			t.Skipf("sqlpptest: %s is not set", env)
+		}
+
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			t.Fatalf("sqlpptest: open %s: %v", driver, err)
+		}
+
+		s := newDB(db)
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+}
+
+// Run runs test as a subtest named by dialect against each backend.
+func Run(t *testing.T, backends []Backend, test func(t *testing.T, db *sqlpp.DB, dialect Dialect)) {
+	t.Helper()
+	for _, b := range backends {
+		b := b
+		t.Run(string(b.Dialect), func(t *testing.T) {
+			test(t, b.Open(t), b.Dialect)
+		})
+	}
+}
+
+// Only skips t unless dialect is one of dialects, for cases specific to
+// some dialects.
+func Only(t testing.TB, dialect Dialect, dialects ...Dialect) {
+	t.Helper()
+	for _, d := range dialects {
+		if d == dialect {
+			return
+		}
+	}
+
+	t.Skipf("sqlpptest: %s only", dialects)
+}
+
+// Equivalent runs test against each backend and fails t unless the
+// backends that didn't skip or fail returned equal results.
+func Equivalent(t *testing.T, backends []Backend, test func(t *testing.T, db *sqlpp.DB) interface{}) {
+	t.Helper()
+
+	var first Dialect
+	var expected interface{}
+	for _, b := range backends {
+		b := b
+		var result interface{}
+		done := false
+		t.Run(string(b.Dialect), func(t *testing.T) {
+			result = test(t, b.Open(t))
+			done = !t.Failed()
+		})
+
+		if !done {
+			continue
+		}
+
+		if first == "" {
+			first, expected = b.Dialect, result
+		} else if !reflect.DeepEqual(expected, result) {
+			t.Errorf("sqlpptest: %s returned %#v, %s returned %#v", first, expected, b.Dialect, result)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package sqlpptest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockBackends(t *testing.T) []Backend {
+	open := func(newDB func(*sql.DB, ...sqlpp.Option) *sqlpp.DB, placeholder string) func(testing.TB) *sqlpp.DB {
+		return func(tb testing.TB) *sqlpp.DB {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.Nil(t, err)
+
+			mock.ExpectPrepare("select i from foo where i in (" + placeholder + ")").
+				ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+			tb.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+			return newDB(db)
+		}
+	}
+
+	return []Backend{
+		{MySQL, open(sqlpp.NewMySQL, "?")},
+		{Postgres, open(sqlpp.NewPostgreSQL, "$1")},
+	}
+}
+
+func queryFoo(t *testing.T, db *sqlpp.DB) interface{} {
+	var i int
+	assert.Nil(t, db.QueryRow("select i from foo where i in (?)", db.Args([]int{1}), &i))
+	return i
+}
+
+func TestRun(t *testing.T) {
+	var ran []Dialect
+	Run(t, mockBackends(t), func(t *testing.T, db *sqlpp.DB, dialect Dialect) {
+		ran = append(ran, dialect)
+		queryFoo(t, db)
+	})
+
+	assert.Equal(t, []Dialect{MySQL, Postgres}, ran)
+}
+
+func TestOnly(t *testing.T) {
+	var ran []Dialect
+	Run(t, mockBackends(t), func(t *testing.T, db *sqlpp.DB, dialect Dialect) {
+		queryFoo(t, db)
+		Only(t, dialect, Postgres)
+		ran = append(ran, dialect)
+	})
+
+	assert.Equal(t, []Dialect{Postgres}, ran)
+}
+
+func TestEquivalent(t *testing.T) {
+	Equivalent(t, mockBackends(t), queryFoo)
+}
+
+func TestEnvBackends(t *testing.T) {
+	t.Setenv("SQLPP_MYSQL_DSN", "")
+	t.Setenv("SQLPP_POSTGRES_DSN", "")
+
+	opened := false
+	Run(t, EnvBackends("mysql", "postgres"), func(t *testing.T, db *sqlpp.DB, dialect Dialect) {
+		opened = true
+	})
+
+	assert.False(t, opened)
+}
@@ -0,0 +1,130 @@
+package sqlpptest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nzmprlr/sqlpp"
+)
+
+// Recorded is a query seen by a Recorder.
+type Recorded struct {
+	Query string
+	// Shape lists the arg types, slices with their length, e.g. "int, []string(3)".
+	Shape string
+	Err   error
+}
+
+// Recorder records the queries of a db to assert on them, add its Hook
+// with sqlpp.WithHook. The zero value is ready to use.
+type Recorder struct {
+	mu      sync.Mutex
+	queries []Recorded
+}
+
+func (r *Recorder) Hook(ctx context.Context, e *sqlpp.QueryEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = append(r.queries, Recorded{Query: e.Query, Shape: Shape(e.Args), Err: e.Err})
+}
+
+// Queries returns the recorded queries in the order they ran.
+func (r *Recorder) Queries() []Recorded {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Recorded(nil), r.queries...)
+}
+
+// Count returns how many times query ran.
+func (r *Recorder) Count(query string) int {
+	n := 0
+	for _, q := range r.Queries() {
+		if q.Query == query {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Shapes returns the arg shapes query ran with, in the order it ran.
+func (r *Recorder) Shapes(query string) []string {
+	var shapes []string
+	for _, q := range r.Queries() {
+		if q.Query == query {
+			shapes = append(shapes, q.Shape)
+		}
+	}
+
+	return shapes
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = nil
+}
+
+// AssertCount fails t unless query ran n times.
+func (r *Recorder) AssertCount(t testing.TB, query string, n int) bool {
+	t.Helper()
+	if count := r.Count(query); count != n {
+		t.Errorf("sqlpptest: %q ran %d times, expected %d", query, count, n)
+		return false
+	}
+
+	return true
+}
+
+// AssertMaxCount fails t if any query ran more than n times, e.g. n=1 to
+// catch queries repeated in a loop.
+func (r *Recorder) AssertMaxCount(t testing.TB, n int) bool {
+	t.Helper()
+
+	counts := map[string]int{}
+	var order []string
+	for _, q := range r.Queries() {
+		if counts[q.Query] == 0 {
+			order = append(order, q.Query)
+		}
+
+		counts[q.Query]++
+	}
+
+	ok := true
+	for _, query := range order {
+		if counts[query] > n {
+			t.Errorf("sqlpptest: %q ran %d times, expected at most %d", query, counts[query], n)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// Shape describes args by type, slices with their length.
+func Shape(args []interface{}) string {
+	shapes := make([]string, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			shapes[i] = "nil"
+			continue
+		}
+
+		rv := reflect.ValueOf(arg)
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			shapes[i] = fmt.Sprintf("%T(%d)", arg, rv.Len())
+		} else {
+			shapes[i] = fmt.Sprintf("%T", arg)
+		}
+	}
+
+	return strings.Join(shapes, ", ")
+}
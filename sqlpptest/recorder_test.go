@@ -0,0 +1,55 @@
+package sqlpptest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	rec := &Recorder{}
+	s := sqlpp.NewMySQL(db, sqlpp.WithHook(rec.Hook))
+
+	mock.ExpectPrepare("^select i from foo where i in (.+) and j = (.+)$").
+		ExpectQuery().WithArgs(1, 2, "a").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	mock.ExpectPrepare("^select i from foo where i in (.+) and j = (.+)$").
+		ExpectQuery().WithArgs(3, "b").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(3))
+	mock.ExpectPrepare("^delete from foo$").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var i int
+	query := "select i from foo where i in (?) and j = ?"
+	assert.Nil(t, s.QueryRow(query, s.Args([]int{1, 2}, "a"), &i))
+	assert.Nil(t, s.QueryRow(query, s.Args([]int{3}, "b"), &i))
+	_, err = s.Exec("delete from foo")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, rec.Count(query))
+	assert.Equal(t, []string{"[]int(2), string", "[]int(1), string"}, rec.Shapes(query))
+	assert.Len(t, rec.Queries(), 3)
+
+	assert.True(t, rec.AssertCount(t, "delete from foo", 1))
+	assert.True(t, rec.AssertMaxCount(t, 2))
+
+	failing := &recordingT{}
+	assert.False(t, rec.AssertMaxCount(failing, 1))
+	assert.False(t, rec.AssertCount(failing, "delete from foo", 2))
+	assert.Equal(t, []string{
+		`sqlpptest: "select i from foo where i in (?) and j = ?" ran 2 times, expected at most 1`,
+		`sqlpptest: "delete from foo" ran 1 times, expected 2`,
+	}, failing.errors)
+
+	rec.Reset()
+	assert.Empty(t, rec.Queries())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestShape(t *testing.T) {
+	assert.Equal(t, "", Shape(nil))
+	assert.Equal(t, "int, nil, []uint8, []string(0)", Shape([]interface{}{1, nil, []byte("a"), []string{}}))
+}
@@ -0,0 +1,19 @@
+// Package sqlpptest provides helpers for tests using sqlpp.
+package sqlpptest
+
+import (
+	"testing"
+
+	"github.com/nzmprlr/sqlpp"
+)
+
+// VerifyClean fails t at cleanup if db still has connections in use,
+// which means rows, conns or locks taken during the test were not closed.
+func VerifyClean(t testing.TB, db *sqlpp.DB) {
+	t.Helper()
+	t.Cleanup(func() {
+		if inUse := db.Stats().InUse; inUse > 0 {
+			t.Errorf("sqlpptest: %d connections still in use, rows or conns were not closed", inUse)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package sqlpptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingT struct {
+	testing.TB
+
+	cleanups []func()
+	errors   []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestVerifyClean(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := sqlpp.NewMySQL(db)
+	mock.ExpectQuery("^select 1$").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+
+	clean := &recordingT{}
+	VerifyClean(clean, s)
+	clean.cleanup()
+	assert.Empty(t, clean.errors)
+
+	rows, err := s.DB.Query("select 1")
+	assert.Nil(t, err)
+
+	leaked := &recordingT{}
+	VerifyClean(leaked, s)
+	leaked.cleanup()
+	assert.Equal(t, []string{"sqlpptest: 1 connections still in use, rows or conns were not closed"}, leaked.errors)
+
+	rows.Close()
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,121 @@
+// Package sqlppx adapts a sqlpp.DB to the sqlx interfaces, so code written
+// against sqlx.Ext can move to sqlpp one call at a time.
+package sqlppx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nzmprlr/sqlpp"
+)
+
+var _ sqlx.ExtContext = (*DB)(nil)
+
+// DB implements sqlx.Ext and sqlx.ExtContext with a sqlpp.DB, keeping its
+// (?) expansion of slice args and stmt cache. Queries use ? placeholders
+// on every db, sqlpp numbers them as the db does.
+type DB struct {
+	sqlpp *sqlpp.DB
+	sqlx  *sqlx.DB
+}
+
+// New adapts db, driverName is the name its pool was opened with.
+func New(db *sqlpp.DB, driverName string) *DB {
+	return &DB{sqlpp: db, sqlx: sqlx.NewDb(db.DB, driverName)}
+}
+
+// Wrap adapts the pool of x with the dialect of its bind type: postgres
+// or cockroach for $1, sql server for @p1, oracle for :1 and mysql or
+// sqlite for ?. Drivers sqlx doesn't know use the sqlpp dialect of their
+// name if one is registered, or mysql.
+func Wrap(x *sqlx.DB, opts ...sqlpp.Option) *DB {
+	return &DB{sqlpp: wrap(x, opts), sqlx: x}
+}
+
+func wrap(x *sqlx.DB, opts []sqlpp.Option) *sqlpp.DB {
+	name := x.DriverName()
+	switch sqlx.BindType(name) {
+	case sqlx.DOLLAR:
+		if strings.Contains(name, "cockroach") {
+			return sqlpp.NewCockroachDB(x.DB, opts...)
+		}
+
+		return sqlpp.NewPostgreSQL(x.DB, opts...)
+	case sqlx.AT:
+		return sqlpp.NewSQLServer(x.DB, opts...)
+	case sqlx.NAMED:
+		return sqlpp.NewOracle(x.DB, opts...)
+	case sqlx.QUESTION:
+		if strings.Contains(name, "sqlite") {
+			return sqlpp.NewSQLite(x.DB, opts...)
+		}
+	case sqlx.UNKNOWN:
+		if db, err := sqlpp.New(x.DB, name, opts...); err == nil {
+			return db
+		}
+	}
+
+	return sqlpp.NewMySQL(x.DB, opts...)
+}
+
+// SQLPP returns the adapted db.
+func (db *DB) SQLPP() *sqlpp.DB {
+	return db.sqlpp
+}
+
+// SQLX returns a sqlx.DB on the same pool, its queries bypass sqlpp.
+func (db *DB) SQLX() *sqlx.DB {
+	return db.sqlx
+}
+
+func (db *DB) DriverName() string {
+	return db.sqlx.DriverName()
+}
+
+// Rebind returns query as is, sqlpp rebinds the ? placeholders itself.
+func (db *DB) Rebind(query string) string {
+	return query
+}
+
+func (db *DB) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return sqlx.BindNamed(sqlx.QUESTION, query, arg)
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.sqlpp.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.sqlpp.RowsContext(ctx, query, args...)
+}
+
+func (db *DB) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return db.QueryxContext(context.Background(), query, args...)
+}
+func (db *DB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	rows, err := db.sqlpp.RowsContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlx.Rows{Rows: rows, Mapper: db.sqlx.Mapper}, nil
+}
+
+// QueryRowx expands the slice args with sqlpp but runs the query with
+// sqlx, as only sqlx can make a sqlx.Row. It doesn't use the stmt cache
+// and hooks don't see it.
+func (db *DB) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return db.QueryRowxContext(context.Background(), query, args...)
+}
+func (db *DB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	query, args = db.sqlpp.Transform(query, args...)
+	return db.sqlx.QueryRowxContext(ctx, query, args...)
+}
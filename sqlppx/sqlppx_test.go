@@ -0,0 +1,85 @@
+package sqlppx
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+)
+
+type foo struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestDB(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	db := New(sqlpp.NewPostgreSQL(conn), "postgres")
+	assert.Equal(t, "postgres", db.DriverName())
+	assert.Equal(t, "select * from foo where id = ?", db.Rebind("select * from foo where id = ?"))
+
+	mock.ExpectPrepare(`^select id, name from foo where id in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	var foos []foo
+	assert.Nil(t, sqlx.Select(db, &foos, "select id, name from foo where id in (?)", []int{1, 2}))
+	assert.Equal(t, []foo{{1, "a"}, {2, "b"}}, foos)
+
+	mock.ExpectQuery(`^select id, name from foo where id in \(\$1\) and name = \$2$`).
+		WithArgs(1, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var f foo
+	assert.Nil(t, sqlx.Get(db, &f, "select id, name from foo where id in (?) and name = ?", []int{1}, "a"))
+	assert.Equal(t, foo{1, "a"}, f)
+
+	mock.ExpectPrepare(`^insert into foo \(id, name\) values \(\$1, \$2\)$`).
+		ExpectExec().WithArgs(3, "c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = sqlx.NamedExec(db, "insert into foo (id, name) values (:id, :name)", foo{3, "c"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestWrap(t *testing.T) {
+	conn, _, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	x := sqlx.NewDb(conn, "pgx")
+	db := Wrap(x)
+	assert.Equal(t, x, db.SQLX())
+	assert.Equal(t, conn, db.SQLPP().DB)
+
+	query, _ := db.SQLPP().Transform("select * from foo where id = ?", 1)
+	assert.Equal(t, "select * from foo where id = $1", query)
+
+	query, _ = Wrap(sqlx.NewDb(conn, "mysql")).SQLPP().Transform("select * from foo where id = ?", 1)
+	assert.Equal(t, "select * from foo where id = ?", query)
+
+	cases := []struct {
+		driver  string
+		dialect string
+		query   string
+	}{
+		{"cockroach", "cockroachdb", "select * from foo where id = $1"},
+		{"sqlserver", "sqlserver", "select * from foo where id = @p1"},
+		{"godror", "oracle", "select * from foo where id = :1"},
+		{"sqlite3", "sqlite", "select * from foo where id = ?"},
+		{"duckdb", "duckdb", "select * from foo where id = ?"},
+		{"unknown", "mysql", "select * from foo where id = ?"},
+	}
+
+	for _, c := range cases {
+		db := Wrap(sqlx.NewDb(conn, c.driver)).SQLPP()
+		query, _ := db.Transform("select * from foo where id = ?", 1)
+		assert.Equal(t, c.dialect, db.Dialect(), c.driver)
+		assert.Equal(t, c.query, query, c.driver)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/nzmprlr/sqlpp/sqlppzap
+
+go 1.18
+
+require (
+	github.com/nzmprlr/sqlpp v0.0.0
+	github.com/stretchr/testify v1.7.0
+	go.uber.org/zap v1.21.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+)
+
+replace github.com/nzmprlr/sqlpp => ../
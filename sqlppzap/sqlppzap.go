@@ -0,0 +1,31 @@
+// Package sqlppzap adapts a zap logger to sqlpp.Logger.
+package sqlppzap
+
+import (
+	"context"
+
+	"github.com/nzmprlr/sqlpp"
+	"go.uber.org/zap"
+)
+
+type logger struct {
+	l *zap.SugaredLogger
+}
+
+// New returns a sqlpp.Logger writing to l, the keyvals become fields.
+func New(l *zap.Logger) sqlpp.Logger {
+	return logger{l.Sugar()}
+}
+
+func (l logger) Log(ctx context.Context, level sqlpp.LogLevel, msg string, keyvals ...interface{}) {
+	switch level {
+	case sqlpp.LogDebug:
+		l.l.Debugw(msg, keyvals...)
+	case sqlpp.LogInfo:
+		l.l.Infow(msg, keyvals...)
+	case sqlpp.LogWarn:
+		l.l.Warnw(msg, keyvals...)
+	default:
+		l.l.Errorw(msg, keyvals...)
+	}
+}
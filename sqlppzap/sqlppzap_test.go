@@ -0,0 +1,28 @@
+package sqlppzap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nzmprlr/sqlpp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := New(zap.New(core))
+
+	l.Log(context.Background(), sqlpp.LogDebug, "query", "statement", "select 1", "rows", 1)
+	l.Log(context.Background(), sqlpp.LogError, "failed", "error", errors.New("boom"))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, map[string]interface{}{"statement": "select 1", "rows": int64(1)}, entries[0].ContextMap())
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, "boom", entries[1].ContextMap()["error"])
+}
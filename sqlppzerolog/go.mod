@@ -0,0 +1,21 @@
+module github.com/nzmprlr/sqlpp/sqlppzerolog
+
+go 1.18
+
+require (
+	github.com/nzmprlr/sqlpp v0.0.0
+	github.com/rs/zerolog v1.28.0
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/mattn/go-colorable v0.1.12 // indirect
+	github.com/mattn/go-isatty v0.0.14 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+)
+
+replace github.com/nzmprlr/sqlpp => ../
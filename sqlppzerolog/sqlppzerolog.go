@@ -0,0 +1,34 @@
+// Package sqlppzerolog adapts a zerolog logger to sqlpp.Logger.
+package sqlppzerolog
+
+import (
+	"context"
+
+	"github.com/nzmprlr/sqlpp"
+	"github.com/rs/zerolog"
+)
+
+type logger struct {
+	l zerolog.Logger
+}
+
+// New returns a sqlpp.Logger writing to l, the keyvals become fields.
+func New(l zerolog.Logger) sqlpp.Logger {
+	return logger{l}
+}
+
+func (l logger) Log(ctx context.Context, level sqlpp.LogLevel, msg string, keyvals ...interface{}) {
+	var e *zerolog.Event
+	switch level {
+	case sqlpp.LogDebug:
+		e = l.l.Debug()
+	case sqlpp.LogInfo:
+		e = l.l.Info()
+	case sqlpp.LogWarn:
+		e = l.l.Warn()
+	default:
+		e = l.l.Error()
+	}
+
+	e.Fields(keyvals).Msg(msg)
+}
@@ -0,0 +1,23 @@
+package sqlppzerolog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nzmprlr/sqlpp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(zerolog.New(&buf).Level(zerolog.InfoLevel))
+
+	l.Log(context.Background(), sqlpp.LogDebug, "query", "statement", "select 1")
+	assert.Equal(t, "", buf.String(), "below the logger level")
+
+	l.Log(context.Background(), sqlpp.LogError, "failed", "statement", "select 1", "error", errors.New("boom"))
+	assert.Equal(t, `{"level":"error","statement":"select 1","error":"boom","message":"failed"}`+"\n", buf.String())
+}
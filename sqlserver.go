@@ -0,0 +1,26 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// error 8180, the batch has a statement sp_prepare can't compile, like
+	// one using a temp table created in the same batch
+	sqlserverErrPrepareNotSupported = "Statement(s) could not be prepared"
+	// error 8179, the prepared handle is gone after a connection reset or
+	// a schema change
+	sqlserverErrStmtNotFound = "Could not find prepared statement with handle"
+)
+
+func isSQLServerPrepareNotSupported(err error) bool {
+	return err != nil && strings.Contains(err.Error(), sqlserverErrPrepareNotSupported)
+}
+
+func isSQLServerStmtNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), sqlserverErrStmtNotFound)
+}
+
+func sqlserverQuoteIdent(ident string) string {
+	return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+}
@@ -0,0 +1,91 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isSQLServerPrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("mssql: Statement(s) could not be prepared."),
+			true,
+		},
+		{
+			errors.New("Error 1295: This command is not supported in the prepared statement protocol yet"),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isSQLServerPrepareNotSupported(c.err))
+		})
+	}
+}
+
+// rowsResult is the result of mssql drivers, without a last insert id.
+type rowsResult int64
+
+func (r rowsResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported. Please use the OUTPUT clause or add `select ID = convert(bigint, SCOPE_IDENTITY())` to the end of your query.")
+}
+
+func (r rowsResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+func TestNewSQLServer(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewSQLServer(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?) and c = ?", 1, []int{2, 3}, 4)
+	assert.Equal(t, "select * from foo where a = @p1 and b in (@p2,@p3) and c = @p4", query)
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, args)
+	assert.Equal(t, "[dbo].[a]]b]", s.QuoteIdent("dbo.a]b"))
+
+	literal, err := s.interpolation("insert into foo values (?, ?, ?, ?)",
+		[]interface{}{true, []byte{0xca, 0xfe}, `it's \`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, `insert into foo values (1, 0xcafe, N'it''s \', '2024-01-02 03:04:05')`, literal)
+
+	// runs the queries sp_prepare can't compile directly
+	mock.ExpectPrepare("select * into #foo from foo where a = @p1").
+		WillReturnError(errors.New("mssql: Statement(s) could not be prepared."))
+	mock.ExpectExec("select * into #foo from foo where a = @p1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("select * into #foo from foo where a = ?", 1)
+	assert.Nil(t, err)
+
+	// re-prepares a stmt whose handle is gone
+	mock.ExpectPrepare("update foo set a = @p1").WillBeClosed().
+		ExpectExec().WithArgs(1).WillReturnError(errors.New("mssql: Could not find prepared statement with handle 3."))
+	mock.ExpectPrepare("update foo set a = @p1").
+		ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	mock.ExpectExec("update foo set a = @p1").WithArgs(2).WillReturnResult(rowsResult(3))
+	result, err := s.ExecResult("update foo set a = ?", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, Result{RowsAffected: 3}, result)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
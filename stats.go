@@ -0,0 +1,105 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+type cacheStats struct {
+	stmts     int64
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type CacheStats struct {
+	Stmts     int64
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// CacheStats returns the stmt cache counters.
+func (sqlpp *DB) CacheStats() CacheStats {
+	return CacheStats{
+		Stmts:     atomic.LoadInt64(&sqlpp.stats.stmts),
+		Hits:      atomic.LoadInt64(&sqlpp.stats.hits),
+		Misses:    atomic.LoadInt64(&sqlpp.stats.misses),
+		Evictions: atomic.LoadInt64(&sqlpp.stats.evictions),
+	}
+}
+
+// Stats is a sample of the pool stats and sqlpp counters.
+type Stats struct {
+	sql.DBStats
+
+	Cache    CacheStats
+	InFlight int
+}
+
+func (sqlpp *DB) SampleStats() Stats {
+	sqlpp.inflightMu.Lock()
+	inFlight := len(sqlpp.inflight)
+	sqlpp.inflightMu.Unlock()
+
+	return Stats{DBStats: sqlpp.DB.Stats(), Cache: sqlpp.CacheStats(), InFlight: inFlight}
+}
+
+type statsReporter struct {
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// StartStatsReporter calls report with a stats sample every interval until
+// the returned stop is called or the db is closed.
+func (sqlpp *DB) StartStatsReporter(interval time.Duration, report func(Stats)) (stop func()) {
+	r := &statsReporter{done: make(chan struct{}), stopped: make(chan struct{})}
+
+	sqlpp.reportersMu.Lock()
+	sqlpp.reporters = append(sqlpp.reporters, r)
+	sqlpp.reportersMu.Unlock()
+
+	go func() {
+		defer close(r.stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				report(sqlpp.SampleStats())
+			}
+		}
+	}()
+
+	return func() {
+		sqlpp.stopReporters(r)
+	}
+}
+
+// stopReporters stops the stats reporter only, or all if only is nil, and
+// waits for them to return.
+func (sqlpp *DB) stopReporters(only *statsReporter) {
+	sqlpp.reportersMu.Lock()
+	var stopped []*statsReporter
+	kept := sqlpp.reporters[:0]
+	for _, r := range sqlpp.reporters {
+		if only != nil && r != only {
+			kept = append(kept, r)
+			continue
+		}
+
+		close(r.done)
+		stopped = append(stopped, r)
+	}
+	sqlpp.reporters = kept
+	sqlpp.reportersMu.Unlock()
+
+	for _, r := range stopped {
+		<-r.stopped
+	}
+}
@@ -0,0 +1,62 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_CacheStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+
+	s := NewMySQL(db, WithMaxStmts(2))
+	mock.ExpectPrepare("^select (.+) from foo$")
+	mock.ExpectPrepare("^select (.+) from bar$")
+	mock.ExpectPrepare("^select (.+) from baz$")
+
+	for _, query := range []string{"select * from foo", "select * from bar", "select * from foo", "select * from baz"} {
+		_, _, err := s.stmt(context.Background(), query)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, CacheStats{Stmts: 2, Hits: 1, Misses: 3, Evictions: 1}, s.CacheStats())
+
+	_, ok := s.stmts.Load("select * from baz")
+	assert.True(t, ok, "newest stmt is kept")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_StartStatsReporter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db)
+	samples := make(chan Stats, 16)
+	stop := s.StartStatsReporter(time.Millisecond, func(stats Stats) { samples <- stats })
+
+	sample := <-samples
+	assert.Equal(t, 0, sample.InFlight)
+	assert.Equal(t, CacheStats{}, sample.Cache)
+	stop()
+	stop()
+
+	closed := make(chan struct{})
+	s.StartStatsReporter(time.Millisecond, func(stats Stats) {
+		select {
+		case <-closed:
+			t.Error("reported after close")
+		default:
+		}
+	})
+
+	mock.ExpectClose()
+	assert.Nil(t, s.Close())
+	close(closed)
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
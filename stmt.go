@@ -0,0 +1,109 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	ErrStmtClosed = errors.New("sqlpp: statement is closed")
+)
+
+// Stmt is a query prepared until Close, for callers managing the lifetime
+// of their stmts. It shares the stmt cache, and is re-prepared when a
+// schema change invalidates it. Queries with (?) prepare per expansion as
+// they run.
+type Stmt struct {
+	cq     *CompiledQuery
+	closed int32
+}
+
+// Prepare transforms and prepares query. Queries mysql can't prepare run
+// directly unless strict prepare is on.
+func (sqlpp *DB) Prepare(query string) (*Stmt, error) {
+	return sqlpp.PrepareContext(context.Background(), query)
+}
+func (sqlpp *DB) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	cq := sqlpp.Compile(query)
+	if !cq.dynamic && !sqlpp.direct() {
+		if _, _, err := cq.stmt(ctx, cq.transformed); err != nil && !sqlpp.fallback(err) {
+			return nil, sqlpp.classify(err)
+		}
+	}
+
+	return &Stmt{cq: cq}, nil
+}
+
+// Close closes the prepared stmt, dropping it from the stmt cache.
+func (s *Stmt) Close() error {
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		return nil
+	}
+
+	if c := s.cq.cached.Load().(*compiledStmt); c.stmt != nil {
+		s.cq.invalidate(s.cq.transformed, c.stmt)
+	}
+
+	return nil
+}
+
+func (s *Stmt) isClosed() bool {
+	return atomic.LoadInt32(&s.closed) == 1
+}
+
+func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	if s.isClosed() {
+		return nil, ErrStmtClosed
+	}
+
+	return s.cq.ExecContext(ctx, args...)
+}
+
+func (s *Stmt) Row(args ...interface{}) *Row {
+	return s.RowContext(context.Background(), args...)
+}
+func (s *Stmt) RowContext(ctx context.Context, args ...interface{}) *Row {
+	return &Row{func(dest []interface{}) error {
+		return s.QueryRowContext(ctx, args, dest...)
+	}}
+}
+
+func (s *Stmt) Select(scan Scanner, args ...interface{}) ([]interface{}, error) {
+	return s.SelectContext(context.Background(), scan, args...)
+}
+func (s *Stmt) SelectContext(ctx context.Context, scan Scanner, args ...interface{}) ([]interface{}, error) {
+	return s.QueryContext(ctx, args, scan)
+}
+
+// Deprecated: use Row.
+func (s *Stmt) QueryRow(args []interface{}, dest ...interface{}) error {
+	return s.QueryRowContext(context.Background(), args, dest...)
+}
+
+// Deprecated: use RowContext.
+func (s *Stmt) QueryRowContext(ctx context.Context, args []interface{}, dest ...interface{}) error {
+	if s.isClosed() {
+		return ErrStmtClosed
+	}
+
+	return s.cq.QueryRowContext(ctx, args, dest...)
+}
+
+// Deprecated: use Select.
+func (s *Stmt) Query(args []interface{}, scan Scanner) ([]interface{}, error) {
+	return s.QueryContext(context.Background(), args, scan)
+}
+
+// Deprecated: use SelectContext.
+func (s *Stmt) QueryContext(ctx context.Context, args []interface{}, scan Scanner) ([]interface{}, error) {
+	if s.isClosed() {
+		return nil, ErrStmtClosed
+	}
+
+	return s.cq.QueryContext(ctx, args, scan)
+}
@@ -0,0 +1,73 @@
+package sqlpp
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Prepare(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewPostgreSQL(db)
+	prepared := mock.ExpectPrepare(`^select i from foo where j = \$1$`)
+	prepared.ExpectQuery().WithArgs("j").WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	prepared.ExpectExec().WithArgs("k").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepared.WillBeClosed()
+
+	stmt, err := s.Prepare("select i from foo where j = ?")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), s.CacheStats().Stmts, "shares the stmt cache")
+
+	var i int
+	assert.Nil(t, stmt.Row("j").Scan(&i))
+	assert.Equal(t, 1, i)
+	_, err = stmt.Exec("k")
+	assert.Nil(t, err)
+
+	assert.Nil(t, stmt.Close())
+	assert.Nil(t, stmt.Close())
+	assert.Equal(t, int64(0), s.CacheStats().Stmts)
+
+	_, err = stmt.Exec("k")
+	assert.Equal(t, ErrStmtClosed, err)
+	assert.Equal(t, ErrStmtClosed, stmt.Row().Scan(&i))
+	_, err = stmt.Select(func(rows *sql.Rows) (interface{}, error) { return nil, nil })
+	assert.Equal(t, ErrStmtClosed, err)
+
+	mock.ExpectPrepare(`^select bad$`).WillReturnError(sqlmock.ErrCancelled)
+	_, err = s.Prepare("select bad")
+	assert.Equal(t, sqlmock.ErrCancelled, err)
+
+	// (?) prepares per expansion when run
+	dynamic, err := s.Prepare("select i from foo where j in (?)")
+	assert.Nil(t, err)
+	mock.ExpectPrepare(`^select i from foo where j in \(\$1,\$2\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1))
+	assert.Nil(t, dynamic.Row([]int{1, 2}).Scan(&i))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Prepare_notSupported(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	mock.ExpectPrepare("^lock tables foo read$").WillReturnError(errPrepareNotSupported)
+	mock.ExpectExec("^lock tables foo read$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("^lock tables foo read$").WillReturnError(errPrepareNotSupported)
+
+	stmt, err := NewMySQL(db).Prepare("lock tables foo read")
+	assert.Nil(t, err)
+	_, err = stmt.Exec()
+	assert.Nil(t, err)
+
+	_, err = NewMySQL(db, WithStrictPrepare()).Prepare("lock tables foo read")
+	assert.True(t, errors.Is(err, ErrPrepareNotSupported))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
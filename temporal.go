@@ -0,0 +1,45 @@
+package sqlpp
+
+import (
+	"context"
+	"time"
+)
+
+// WithSystemVersioning makes AsOf reads use FOR SYSTEM_TIME AS OF, for
+// system-versioned tables of mariadb or sql server. Without it they read
+// the history table convention of AsOf.
+func WithSystemVersioning() Option {
+	return func(sqlpp *DB) {
+		sqlpp.cfg.systemVersioning = true
+	}
+}
+
+// AsOf makes Find run with ctx read the rows as they were at t.
+//
+// Unless WithSystemVersioning is set, rows are read from table, holding the
+// current rows since their valid_from column, and table_history, holding
+// the replaced and deleted rows with the same columns followed by a
+// valid_to column, as triggers on table maintain it.
+func AsOf(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey, t)
+}
+
+// source returns the quoted table, or the rows of table as of the AsOf
+// time of ctx and their args.
+func (sqlpp *DB) source(ctx context.Context, table string) (string, []interface{}) {
+	quoted := sqlpp.QuoteIdent(table)
+	t, ok := ctx.Value(asOfKey).(time.Time)
+	if !ok {
+		return quoted, nil
+	}
+
+	if sqlpp.config().systemVersioning {
+		return quoted + " FOR SYSTEM_TIME AS OF ?", []interface{}{t}
+	}
+
+	validFrom := sqlpp.QuoteIdent("valid_from")
+	validTo := sqlpp.QuoteIdent("valid_to")
+	return "(SELECT *, NULL AS " + validTo + " FROM " + quoted + " WHERE " + validFrom + " <= ?" +
+		" UNION ALL SELECT * FROM " + sqlpp.QuoteIdent(table+"_history") +
+		" WHERE " + validFrom + " <= ? AND " + validTo + " > ?) AS " + quoted, []interface{}{t, t, t}
+}
@@ -0,0 +1,37 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsOf(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db, WithSystemVersioning())
+	p := NewPostgreSQL(db, WithSoftDelete("deleted_at"))
+	at := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := AsOf(context.Background(), at)
+
+	mock.ExpectPrepare("SELECT `id`, `title` FROM `docs` FOR SYSTEM_TIME AS OF ? WHERE (`id` = ?)").
+		ExpectQuery().WithArgs(at, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "a"))
+	mock.ExpectPrepare(`SELECT "id", "title" FROM (SELECT *, NULL AS "valid_to" FROM "docs" WHERE "valid_from" <= $1`+
+		` UNION ALL SELECT * FROM "docs_history" WHERE "valid_from" <= $2 AND "valid_to" > $3) AS "docs"`+
+		` WHERE ("id" = $4) AND "deleted_at" IS NULL`).
+		ExpectQuery().WithArgs(at, at, at, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "b"))
+
+	docs, err := FindContext[softDoc](ctx, m, "docs", "`id` = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []softDoc{{1, "a"}}, docs)
+
+	docs, err = FindContext[softDoc](ctx, p, "docs", `"id" = ?`, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []softDoc{{1, "b"}}, docs)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
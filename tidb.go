@@ -0,0 +1,34 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// error 8111 and 8112, a multi statement query or ddl with params
+	// prepared
+	tidbErrPrefixesPrepareNotSupported = []string{"Error 8111:", "Error 8112:"}
+	// write conflict of optimistic transactions, a retryable kv error,
+	// the schema changed during the transaction and a deadlock
+	tidbErrPrefixesRetryable = []string{"Error 9007:", "Error 8022:", "Error 8028:", "Error 1213:"}
+)
+
+// isTiDBPrepareNotSupported matches the prepare errors of mysql and the
+// ones of tidb.
+func isTiDBPrepareNotSupported(err error) bool {
+	return isMysqlPrepareNotSupported(err) || err != nil && hasAnyPrefix(err.Error(), tidbErrPrefixesPrepareNotSupported)
+}
+
+func isTiDBRetryable(err error) bool {
+	return err != nil && hasAnyPrefix(err.Error(), tidbErrPrefixesRetryable)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
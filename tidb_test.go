@@ -0,0 +1,112 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isTiDBPrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("Error 1295: This command is not supported in the prepared statement protocol yet"),
+			true,
+		},
+		{
+			errors.New("Error 8111: Can not prepare multiple statements"),
+			true,
+		},
+		{
+			errors.New("Error 8112: Can not prepare DDL statements with parameters"),
+			true,
+		},
+		{
+			errors.New("Error 9007: Write conflict"),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isTiDBPrepareNotSupported(c.err))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("Error 9007: Write conflict, txnStartTS=1, conflictStartTS=2, conflictCommitTS=3"),
+			true,
+		},
+		{
+			errors.New("Error 8022: Error: KV error safe to retry"),
+			true,
+		},
+		{
+			errors.New("pq: could not serialize access due to concurrent update"),
+			true,
+		},
+		{
+			errors.New("Error 1062: Duplicate entry '1' for key 'PRIMARY'"),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, IsRetryable(c.err))
+		})
+	}
+}
+
+func TestNewTiDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewTiDB(db, WithRetry(1, time.Millisecond))
+	assert.Equal(t, "`foo`", s.QuoteIdent("foo"))
+
+	mock.ExpectPrepare("create table foo as select ?").
+		WillReturnError(errors.New("Error 8112: Can not prepare DDL statements with parameters"))
+	mock.ExpectExec("create table foo as select ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Exec("create table foo as select ?", 1)
+	assert.Nil(t, err)
+
+	// retries the write conflicts
+	mock.ExpectPrepare("update foo set a = ?").
+		ExpectExec().WithArgs(1).WillReturnError(errors.New("Error 9007: Write conflict"))
+	mock.ExpectExec("update foo set a = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("update foo set a = ?", 1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
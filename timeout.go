@@ -0,0 +1,55 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrQueryTimeout = errors.New("sqlpp: query timeout")
+)
+
+// WithDeadlineReserve keeps portion of the time left to the caller's
+// deadline for the work after the query, e.g. 0.2 of a 1s deadline times
+// the query out after 800ms with ErrQueryTimeout.
+func WithDeadlineReserve(portion float64) Option {
+	return func(sqlpp *DB) {
+		if portion > 0 && portion < 1 {
+			sqlpp.cfg.deadlineReserve = portion
+		}
+	}
+}
+
+// withTimeout returns the ctx of a query, timing out after the default
+// query timeout when the caller's has no deadline, or before the caller's
+// deadline by its reserve.
+func (sqlpp *DB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	cfg := sqlpp.config()
+	deadline, ok := ctx.Deadline()
+	if !ok && cfg.queryTimeout > 0 {
+		return context.WithTimeout(ctx, cfg.queryTimeout)
+	} else if ok && cfg.deadlineReserve > 0 {
+		left := time.Until(deadline)
+		return context.WithTimeout(ctx, left-time.Duration(float64(left)*cfg.deadlineReserve))
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// timeoutError tells why a failed query's ctx is done: the caller's ctx
+// error when the caller's is done too, else ErrQueryTimeout for its own
+// timeout or ErrClosed for a Shutdown.
+func timeoutError(parent, ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	if parent.Err() != nil {
+		return parent.Err()
+	} else if ctx.Err() == context.DeadlineExceeded {
+		return ErrQueryTimeout
+	}
+
+	return ErrClosed
+}
@@ -0,0 +1,86 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDefaultQueryTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	s := NewMySQL(db, WithDefaultQueryTimeout(10*time.Millisecond))
+	p := mock.ExpectPrepare("^select sleep")
+	p.ExpectExec().WillDelayFor(time.Minute).WillReturnResult(sqlmock.NewResult(0, 0))
+	p.ExpectExec().WillDelayFor(30 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	start := time.Now()
+	_, err = s.Exec("select sleep(60)")
+	assert.Equal(t, ErrQueryTimeout, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	// the caller's deadline wins over the default
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = s.ExecContext(ctx, "select sleep(60)")
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestWithDeadlineReserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var hooked error
+	s := NewMySQL(db, WithDeadlineReserve(0.5), WithHook(func(ctx context.Context, e *QueryEvent) {
+		hooked = e.Err
+	}))
+
+	p := mock.ExpectPrepare("^select sleep")
+	p.ExpectQuery().WillDelayFor(time.Minute).WillReturnRows(sqlmock.NewRows([]string{"i"}))
+	p.ExpectQuery().WillDelayFor(time.Minute).WillReturnRows(sqlmock.NewRows([]string{"i"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var i int
+	start := time.Now()
+	assert.Equal(t, ErrQueryTimeout, s.QueryRowContext(ctx, "select sleep(60)", nil, &i))
+	assert.Equal(t, ErrQueryTimeout, hooked)
+	assert.Nil(t, ctx.Err())
+	assert.Less(t, int64(time.Since(start)), int64(90*time.Millisecond))
+
+	// cancelled by the caller
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	assert.Equal(t, context.Canceled, s.QueryRowContext(ctx, "select sleep(60)", nil, &i))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_timeoutError(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parent)
+	expired, cancelExpired := context.WithTimeout(parent, 0)
+	defer cancelExpired()
+
+	errQuery := errors.New("error")
+	assert.Nil(t, timeoutError(parent, ctx, nil))
+	assert.Equal(t, errQuery, timeoutError(parent, ctx, errQuery))
+	assert.Equal(t, ErrQueryTimeout, timeoutError(parent, expired, errQuery))
+
+	cancel()
+	assert.Equal(t, ErrClosed, timeoutError(parent, ctx, errQuery))
+
+	cancelParent()
+	assert.Equal(t, context.Canceled, timeoutError(parent, ctx, errQuery))
+}
@@ -0,0 +1,113 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Tx runs queries in a transaction through the hooks, policies and checks
+// of the db, as its own queries do. Its stmts are prepared in the
+// transaction and closed with it.
+type Tx struct {
+	db *DB
+	tx *sql.Tx
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// WithTx calls fn in a transaction, committing it when fn returns nil and
+// rolling it back otherwise.
+func (sqlpp *DB) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	tx, err := sqlpp.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Tx{db: sqlpp, tx: tx, stmts: map[string]*sql.Stmt{}}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Raw returns the transaction for calls sqlpp doesn't cover.
+func (t *Tx) Raw() *sql.Tx {
+	return t.tx
+}
+
+func (t *Tx) stmt(ctx context.Context, query string) (*sql.Stmt, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stmt, ok := t.stmts[query]; ok {
+		return stmt, false, nil
+	}
+
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, true, err
+	}
+
+	t.stmts[query] = stmt
+	return stmt, true, nil
+}
+
+func (t *Tx) invalidate(query string, stmt *sql.Stmt) {
+	t.mu.Lock()
+	if t.stmts[query] == stmt {
+		delete(t.stmts, query)
+	}
+	t.mu.Unlock()
+
+	stmt.Close()
+}
+
+func (t *Tx) run(ctx context.Context, e *QueryEvent, fn runFunc) error {
+	return t.db.runWith(ctx, e, t, fn)
+}
+
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, e, err := t.db.begin(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err = t.run(ctx, e, t.db.execer(ctx, t.tx, &result))
+	e.Result = result
+	return result, t.db.end(ctx, e, err)
+}
+
+func (t *Tx) Row(query string, args ...interface{}) *Row {
+	return t.RowContext(context.Background(), query, args...)
+}
+func (t *Tx) RowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{func(dest []interface{}) error {
+		ctx, e, err := t.db.begin(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		err = t.run(ctx, e, t.db.rowScanner(ctx, t.tx, dest))
+		e.returned = dest
+		return t.db.end(ctx, e, err)
+	}}
+}
+
+func (t *Tx) Select(scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	return t.SelectContext(context.Background(), scan, query, args...)
+}
+func (t *Tx) SelectContext(ctx context.Context, scan Scanner, query string, args ...interface{}) ([]interface{}, error) {
+	ctx, e, err := t.db.begin(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.db.query(ctx, e, t.tx, scan, t.run)
+}
@@ -0,0 +1,71 @@
+package sqlpp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_WithTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	var queries []string
+	s := NewMySQL(db, WithHook(func(ctx context.Context, e *QueryEvent) {
+		queries = append(queries, e.Query)
+	}))
+
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(`^insert into foo set i = \?$`)
+	prepared.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	prepared.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectPrepare(`^select i from foo where i in \(\?,\?\)$`).
+		ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"i"}).AddRow(1).AddRow(2))
+	mock.ExpectPrepare(`^select count\(\*\) from foo$`).
+		ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	err = s.WithTx(ctx, nil, func(tx *Tx) error {
+		for i := 1; i <= 2; i++ {
+			if _, err := tx.Exec("insert into foo set i = ?", i); err != nil {
+				return err
+			}
+		}
+
+		results, err := tx.Select(func(rows *sql.Rows) (interface{}, error) {
+			var i int
+			return i, rows.Scan(&i)
+		}, "select i from foo where i in (?)", []int{1, 2})
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []interface{}{1, 2}, results)
+
+		var n int
+		if err := tx.Row("select count(*) from foo").Scan(&n); err != nil {
+			return err
+		}
+		assert.Equal(t, 2, n)
+		assert.NotNil(t, tx.Raw())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, queries, 4)
+
+	// rolled back on errors, policies apply
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	s.SetOption(WithPolicy(ReadOnly()))
+	err = s.WithTx(ctx, nil, func(tx *Tx) error {
+		_, err := tx.Exec("delete from foo")
+		return err
+	})
+	assert.True(t, errors.Is(err, ErrRestricted))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
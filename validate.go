@@ -0,0 +1,54 @@
+package sqlpp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ValidateError is returned by Validate for a query the db failed to
+// prepare. Err is the error of the driver.
+type ValidateError struct {
+	Query     string
+	Statement string
+	Err       error
+}
+
+func (e *ValidateError) Error() string {
+	return fmt.Sprintf("sqlpp: invalid query %q: %v", e.Query, e.Err)
+}
+
+func (e *ValidateError) Unwrap() error {
+	return e.Err
+}
+
+// Validate prepares query against the live schema and closes the stmt
+// right away, checking its syntax, tables and columns without running it.
+// Each (?) is checked with a single placeholder. Queries the db can't
+// prepare (mysql error 1295) are reported valid as they can only be run,
+// unless WithStrictPrepare is set. The policies check query first, hooks
+// aren't called as nothing runs, and the stmt isn't cached.
+func (sqlpp *DB) Validate(ctx context.Context, query string) error {
+	if err := sqlpp.allow(query); err != nil {
+		return err
+	}
+
+	i := strings.Index(query, "(?)")
+
+	var lengths []int
+	for n := strings.Count(query, "(?)"); n > 0; n-- {
+		lengths = append(lengths, 1)
+	}
+
+	statement := sqlpp.build(query, i, lengths)
+	stmt, err := sqlpp.DB.PrepareContext(ctx, statement)
+	if err != nil {
+		if sqlpp.fallback(err) {
+			return nil
+		}
+
+		return &ValidateError{Query: query, Statement: statement, Err: err}
+	}
+
+	return stmt.Close()
+}
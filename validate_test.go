@@ -0,0 +1,43 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Validate(t *testing.T) {
+	mDb, mMock, mErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	pDb, pMock, pErr := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, mErr)
+	assert.Nil(t, pErr)
+
+	sm := NewMySQL(mDb)
+	sp := NewPostgreSQL(pDb)
+
+	mMock.ExpectPrepare("select * from foo where i in (?) and j = ? and k in (?)").WillBeClosed()
+	mMock.ExpectPrepare("lock tables foo read").WillReturnError(errors.New("Error 1295: This command is not supported in the prepared statement protocol yet"))
+	mMock.ExpectPrepare("select nope from foo").WillReturnError(errors.New("Error 1054: Unknown column 'nope'"))
+	pMock.ExpectPrepare("select * from foo where i in ($1) and j = $2 and k in ($3)").WillBeClosed()
+	pMock.ExpectPrepare("select nope from foo").WillReturnError(errors.New(`column "nope" does not exist`))
+
+	ctx := context.Background()
+	assert.Nil(t, sm.Validate(ctx, "select * from foo where i in (?) and j = ? and k in (?)"))
+	assert.Nil(t, sp.Validate(ctx, "select * from foo where i in (?) and j = ? and k in (?)"))
+	assert.Nil(t, sm.Validate(ctx, "lock tables foo read"))
+
+	err := sm.Validate(ctx, "select nope from foo")
+	var verr *ValidateError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "select nope from foo", verr.Statement)
+	assert.EqualError(t, err, `sqlpp: invalid query "select nope from foo": Error 1054: Unknown column 'nope'`)
+
+	err = sp.Validate(ctx, "select nope from foo")
+	assert.EqualError(t, errors.Unwrap(err), `column "nope" does not exist`)
+
+	assert.Nil(t, mMock.ExpectationsWereMet())
+	assert.Nil(t, pMock.ExpectationsWereMet())
+}
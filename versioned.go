@@ -0,0 +1,71 @@
+package sqlpp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrStaleVersion = errors.New("sqlpp: stale version")
+	ErrNoVersion    = errors.New("sqlpp: no int version column")
+	ErrNoKey        = errors.New("sqlpp: no primary key column")
+)
+
+// UpdateVersioned updates the row of the struct v points to if its
+// versionColumn still holds the version in v, incrementing it in the row
+// and in v. It fails with ErrStaleVersion when another update came first.
+// The row is found by the columns tagged db:"name,pk", or the id column
+// if none is, see ColumnsOf. Soft deleted rows count as stale.
+func (sqlpp *DB) UpdateVersioned(ctx context.Context, table string, v interface{}, versionColumn string) error {
+	rv := reflect.ValueOf(v).Elem()
+	fields := fieldsOf(rv.Type())
+
+	pk := hasPK(fields)
+	where, whereArgs := sqlpp.keyOf(rv)
+
+	var version reflect.Value
+	var set []string
+	var setArgs []interface{}
+	for _, f := range fields {
+		value := rv.FieldByIndex(f.index)
+		switch {
+		case f.column == versionColumn:
+			version = value
+		case !f.isKey(pk):
+			set = append(set, sqlpp.QuoteIdent(f.column)+" = ?")
+			setArgs = append(setArgs, sqlpp.fieldArg(table, f, rv))
+		}
+	}
+
+	if !version.IsValid() || !version.CanInt() {
+		return ErrNoVersion
+	} else if len(where) == 0 {
+		return ErrNoKey
+	}
+
+	column := sqlpp.QuoteIdent(versionColumn)
+	set = append(set, column+" = "+column+" + 1")
+	where = append(where, column+" = ?")
+	if notDeleted := sqlpp.notDeleted(ctx); notDeleted != "" {
+		where = append(where, notDeleted)
+	}
+
+	args := append(append(setArgs, whereArgs...), version.Int())
+
+	result, err := sqlpp.ExecContext(ctx, "UPDATE "+sqlpp.QuoteIdent(table)+" SET "+strings.Join(set, ", ")+
+		" WHERE "+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrStaleVersion
+	}
+
+	version.SetInt(version.Int() + 1)
+	return nil
+}
@@ -0,0 +1,73 @@
+package sqlpp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type versionedDoc struct {
+	ID      int64  `db:"id"`
+	Title   string `db:"title"`
+	Version int    `db:"version"`
+}
+
+type versionedPair struct {
+	Tenant  string `db:"tenant,pk"`
+	Key     string `db:"key,pk"`
+	Value   string `db:"value"`
+	Version int64  `db:"rev"`
+}
+
+func TestDB_UpdateVersioned(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db)
+	p := NewPostgreSQL(db)
+	ctx := context.Background()
+
+	update := "UPDATE `docs` SET `title` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?"
+	prepared := mock.ExpectPrepare(update)
+	prepared.ExpectExec().WithArgs("b", 1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs("c", 1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`UPDATE "pairs" SET "value" = $1, "rev" = "rev" + 1 WHERE "tenant" = $2 AND "key" = $3 AND "rev" = $4`).
+		ExpectExec().WithArgs("v", "t", "k", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	doc := &versionedDoc{ID: 1, Title: "b", Version: 3}
+	assert.Nil(t, m.UpdateVersioned(ctx, "docs", doc, "version"))
+	assert.Equal(t, 4, doc.Version)
+
+	stale := &versionedDoc{ID: 1, Title: "c", Version: 3}
+	assert.Equal(t, ErrStaleVersion, m.UpdateVersioned(ctx, "docs", stale, "version"))
+	assert.Equal(t, 3, stale.Version)
+
+	pair := &versionedPair{Tenant: "t", Key: "k", Value: "v", Version: 7}
+	assert.Nil(t, p.UpdateVersioned(ctx, "pairs", pair, "rev"))
+	assert.Equal(t, int64(8), pair.Version)
+
+	assert.Equal(t, ErrNoVersion, m.UpdateVersioned(ctx, "docs", doc, "rev"))
+	assert.Equal(t, ErrNoKey, m.UpdateVersioned(ctx, "docs", &struct {
+		Title   string `db:"title"`
+		Version int    `db:"version"`
+	}{}, "version"))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_UpdateVersionedSoftDelete(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	m := NewMySQL(db, WithSoftDelete("deleted_at"))
+
+	mock.ExpectPrepare("UPDATE `docs` SET `title` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ? AND `deleted_at` IS NULL").
+		ExpectExec().WithArgs("b", 1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	doc := &versionedDoc{ID: 1, Title: "b", Version: 3}
+	assert.Equal(t, ErrStaleVersion, m.UpdateVersioned(context.Background(), "docs", doc, "version"))
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
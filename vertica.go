@@ -0,0 +1,18 @@
+package sqlpp
+
+import (
+	"strings"
+)
+
+var (
+	// 0A000 is the sqlstate of the statements vertica can't prepare, like
+	// most ddl and COPY, as the driver formats it
+	verticaErrUnsupported = "[0A000]"
+	// a multi statement query prepared as a single one
+	verticaErrMultipleCommands = "multiple commands into a prepared statement"
+)
+
+func isVerticaPrepareNotSupported(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), verticaErrUnsupported) ||
+		strings.Contains(err.Error(), verticaErrMultipleCommands))
+}
@@ -0,0 +1,69 @@
+package sqlpp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isVerticaPrepareNotSupported(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			errors.New(""),
+			false,
+		},
+		{
+			errors.New("Error: [0A000] COPY is not supported in prepared statements"),
+			true,
+		},
+		{
+			errors.New("Error: [42601] Cannot insert multiple commands into a prepared statement"),
+			true,
+		},
+		{
+			errors.New("Error: [42V01] Relation \"foo\" does not exist"),
+			false,
+		},
+	}
+
+	t.Parallel()
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s", c.err), func(t *testing.T) {
+			assert.Equal(t, c.want, isVerticaPrepareNotSupported(c.err))
+		})
+	}
+}
+
+func TestNewVertica(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+
+	s := NewVertica(db)
+	query, args := s.Transform("select * from foo where a = ? and b in (?)", 1, []int{2, 3})
+	assert.Equal(t, "select * from foo where a = ? and b in (?,?)", query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	literal, err := s.interpolation("insert into foo values (?, ?, ?)",
+		[]interface{}{[]byte{0xca, 0xfe}, `it's \`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, `insert into foo values (HEX_TO_BINARY('cafe'), 'it''s \', TIMESTAMP '2024-01-02 03:04:05')`, literal)
+
+	mock.ExpectPrepare("copy foo from local 'foo.csv'").
+		WillReturnError(errors.New("Error: [0A000] COPY is not supported in prepared statements"))
+	mock.ExpectExec("copy foo from local 'foo.csv'").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = s.Exec("copy foo from local 'foo.csv'")
+	assert.Nil(t, err)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}